@@ -0,0 +1,306 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/wcharczuk/lametric/pkg/apiutil"
+	"github.com/wcharczuk/lametric/pkg/config"
+	"github.com/wcharczuk/lametric/pkg/ical"
+	"github.com/wcharczuk/lametric/pkg/lametric"
+	"github.com/wcharczuk/lametric/pkg/trace"
+)
+
+// Calendar poller timing.
+const (
+	// calendarTick is how often reminders and the room status are checked.
+	calendarTick = 15 * time.Second
+	// calendarRoomRefresh is how often an unchanged room status is pushed
+	// again, in case the device restarted.
+	calendarRoomRefresh = 15 * time.Minute
+)
+
+// calendarDevices returns the devices that have a calendar.
+func calendarDevices(cfg config.Config) (output []config.Device) {
+	for _, device := range cfg.Devices {
+		if device.Calendar != nil {
+			output = append(output, device)
+		}
+	}
+	return
+}
+
+// runCalendars runs the calendar of each device that has one until the context is done.
+func runCalendars(ctx context.Context, devices []config.Device) error {
+	wg := sync.WaitGroup{}
+	wg.Add(len(devices))
+	for _, device := range devices {
+		go func(device config.Device) {
+			defer wg.Done()
+			newCalendarPoller(device).run(ctx)
+		}(device)
+	}
+	wg.Wait()
+	return ctx.Err()
+}
+
+// calendarPoller sends the meeting reminders and room status of a device calendar.
+type calendarPoller struct {
+	device   config.Device
+	cfg      config.Calendar
+	location *time.Location
+
+	calendar  *ical.Calendar
+	fetchedAt time.Time
+	// reminded are the occurrences reminders were sent for, by key, to their start.
+	reminded   map[string]time.Time
+	room       string
+	roomPushed time.Time
+}
+
+func newCalendarPoller(device config.Device) *calendarPoller {
+	// the time zone is checked when the config is validated.
+	location, _ := device.Calendar.Location()
+	return &calendarPoller{
+		device:   device,
+		cfg:      *device.Calendar,
+		location: location,
+		reminded: make(map[string]time.Time),
+	}
+}
+
+func (cp *calendarPoller) run(ctx context.Context) {
+	ticker := time.NewTicker(calendarTick)
+	defer ticker.Stop()
+	for {
+		cp.check(ctx, time.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// check fetches the calendar if it's due, then sends due reminders and the room status.
+func (cp *calendarPoller) check(ctx context.Context, now time.Time) {
+	if cp.calendar == nil || now.Sub(cp.fetchedAt) >= cp.cfg.IntervalOrDefault() {
+		calendar, err := loadCalendar(ctx, cp.cfg.URL, cp.cfg.TimeZone, cp.location)
+		// the last calendar is kept if it can't be fetched.
+		if err != nil {
+			log.Printf("calendar; %s; %v", cp.device.Label(), err)
+		} else {
+			cp.calendar = calendar
+		}
+		cp.fetchedAt = now
+	}
+	if cp.calendar == nil {
+		return
+	}
+	occurrences := meetings(cp.calendar.Occurrences(now, now.Add(cp.cfg.RemindBefore+24*time.Hour)))
+	if cp.cfg.RemindBefore > 0 {
+		cp.remind(ctx, now, occurrences)
+	}
+	if cp.cfg.Room != nil {
+		cp.pushRoom(ctx, now, occurrences)
+	}
+}
+
+// remind sends a reminder for each meeting starting within the reminder
+// duration that one hasn't been sent for.
+func (cp *calendarPoller) remind(ctx context.Context, now time.Time, occurrences []ical.Occurrence) {
+	for key, start := range cp.reminded {
+		if start.Before(now) {
+			delete(cp.reminded, key)
+		}
+	}
+	for _, o := range occurrences {
+		if !o.Start.After(now) || o.Start.Sub(now) > cp.cfg.RemindBefore {
+			continue
+		}
+		key := fmt.Sprintf("%s@%d", o.Event.UID, o.Start.Unix())
+		if _, ok := cp.reminded[key]; ok {
+			continue
+		}
+		cp.reminded[key] = o.Start
+		if err := deliver(ctx, sourceCalendar, cp.device, meetingNotification(o, now)); err != nil {
+			log.Printf("calendar; %s; %s; %v", cp.device.Label(), o.Event.Summary, err)
+		}
+	}
+}
+
+// pushRoom pushes the room status to the indicator app if it changed.
+func (cp *calendarPoller) pushRoom(ctx context.Context, now time.Time, occurrences []ical.Occurrence) {
+	status := roomStatus(occurrences, now, cp.location)
+	if status == cp.room && now.Sub(cp.roomPushed) < calendarRoomRefresh {
+		return
+	}
+	icon := lametric.IconSmile
+	if strings.HasPrefix(status, "Busy") {
+		icon = lametric.IconAttention
+	}
+	frames := []lametric.Frame{{Icon: icon, Text: status}}
+	if err := newClient(cp.device).PushApp(ctx, *cp.cfg.Room, frames); err != nil {
+		log.Printf("calendar; %s; room; %v", cp.device.Label(), err)
+		return
+	}
+	cp.room, cp.roomPushed = status, now
+}
+
+// meetings returns the occurrences that block time, i.e. that aren't all day or transparent.
+func meetings(occurrences []ical.Occurrence) (output []ical.Occurrence) {
+	for _, o := range occurrences {
+		if !o.Event.AllDay && !o.Event.Transparent {
+			output = append(output, o)
+		}
+	}
+	return
+}
+
+// meetingNotification returns the reminder for a meeting.
+func meetingNotification(o ical.Occurrence, now time.Time) lametric.Notification {
+	minutes := int(math.Ceil(o.Start.Sub(now).Minutes()))
+	summary := o.Event.Summary
+	if summary == "" {
+		summary = "(no title)"
+	}
+	return lametric.Notification{
+		Priority: lametric.NotificationPriorityWarning,
+		Model: lametric.NotificationModel{
+			Frames: []lametric.Frame{{Icon: lametric.IconCalendar, Text: fmt.Sprintf("Meeting in %d min: %s", minutes, summary)}},
+		},
+	}
+}
+
+// roomStatus returns the room status text, e.g. `Busy until 14:30`, `Free until 15:00` or `Free`.
+//
+// Back to back meetings are one busy period, and free periods only have an
+// end if the next meeting is on the same day.
+func roomStatus(occurrences []ical.Occurrence, now time.Time, location *time.Location) string {
+	var busyUntil time.Time
+	for changed := true; changed; {
+		changed = false
+		for _, o := range occurrences {
+			started := !o.Start.After(now) || (!busyUntil.IsZero() && !o.Start.After(busyUntil))
+			if started && o.End.After(now) && o.End.After(busyUntil) {
+				busyUntil, changed = o.End, true
+			}
+		}
+	}
+	if !busyUntil.IsZero() {
+		return "Busy until " + busyUntil.In(location).Format("15:04")
+	}
+	for _, o := range occurrences {
+		if o.Start.After(now) {
+			if o.Start.In(location).Format("20060102") == now.In(location).Format("20060102") {
+				return "Free until " + o.Start.In(location).Format("15:04")
+			}
+			break
+		}
+	}
+	return "Free"
+}
+
+// loadCalendar reads a calendar from a path or an http(s) or webcal url.
+func loadCalendar(ctx context.Context, source, timeZone string, location *time.Location) (calendar *ical.Calendar, err error) {
+	ctx, span := trace.Start(ctx, "calendar.fetch", trace.KindInternal)
+	span.SetAttribute("url", source)
+	defer func() { span.Finish(err) }()
+
+	var contents []byte
+	switch {
+	case strings.HasPrefix(source, "http://"), strings.HasPrefix(source, "https://"), strings.HasPrefix(source, "webcal://"):
+		if strings.HasPrefix(source, "webcal://") {
+			source = "https://" + strings.TrimPrefix(source, "webcal://")
+		}
+		client := apiutil.New(source, apiutil.OptMiddleware(apiutil.UserAgent("notifier"), trace.Middleware))
+		if _, contents, err = client.Bytes(ctx); err != nil {
+			return nil, err
+		}
+	default:
+		if contents, err = os.ReadFile(source); err != nil {
+			return nil, err
+		}
+	}
+	if timeZone != "" {
+		return ical.ParseInLocation(bytes.NewReader(contents), location)
+	}
+	return ical.Parse(bytes.NewReader(contents))
+}
+
+// calendar prints the upcoming meetings and the room status of device
+// calendars, or of a calendar file, e.g. to check a fixture `.ics` file.
+func calendar(cfg config.Config, args []string) error {
+	fs := flag.NewFlagSet("calendar", flag.ContinueOnError)
+	deviceName := fs.String("device", "", "Only print the calendar of a device")
+	file := fs.String("file", "", "A calendar path or url to print instead of the device calendars")
+	at := fs.String("at", "", "The local time to print the calendar at, e.g. `2026-11-01T09:00`; defaults to now")
+	window := fs.Duration("window", 24*time.Hour, "How far ahead to print meetings")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	now := time.Now()
+	if *at != "" {
+		var err error
+		if now, err = parseReminderTime("", *at, now); err != nil {
+			return err
+		}
+	}
+
+	type source struct {
+		name string
+		cfg  config.Calendar
+	}
+	var sources []source
+	if *file != "" {
+		sources = append(sources, source{name: *file, cfg: config.Calendar{URL: *file}})
+	} else {
+		for _, device := range calendarDevices(cfg) {
+			if *deviceName == "" || device.Name == *deviceName || device.Addr == *deviceName {
+				sources = append(sources, source{name: device.Label(), cfg: *device.Calendar})
+			}
+		}
+	}
+	if len(sources) == 0 {
+		return fmt.Errorf("calendar; no device calendars configured; use -file to print a calendar")
+	}
+
+	ctx := context.Background()
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, s := range sources {
+		location, err := s.cfg.Location()
+		if err != nil {
+			return err
+		}
+		c, err := loadCalendar(ctx, s.cfg.URL, s.cfg.TimeZone, location)
+		if err != nil {
+			return fmt.Errorf("calendar; %s; %w", s.name, err)
+		}
+		fmt.Fprintf(tw, "%s\t%s\n", s.name, roomStatus(meetings(c.Occurrences(now, now.Add(*window))), now, location))
+		fmt.Fprintln(tw, "START\tEND\tSUMMARY\tNOTES")
+		for _, o := range c.Occurrences(now, now.Add(*window)) {
+			var notes []string
+			if o.Event.AllDay {
+				notes = append(notes, "all day")
+			}
+			if o.Event.Transparent {
+				notes = append(notes, "free")
+			}
+			if o.Event.Recurring() || !o.Event.RecurrenceID.IsZero() {
+				notes = append(notes, "recurring")
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", o.Start.In(location).Format("2006-01-02 15:04 MST"), o.End.In(location).Format("2006-01-02 15:04 MST"), o.Event.Summary, orDash(strings.Join(notes, ",")))
+		}
+		fmt.Fprintln(tw)
+	}
+	return tw.Flush()
+}
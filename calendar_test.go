@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRoomStatus(t *testing.T) {
+	location, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("time zone not available; %v", err)
+	}
+	calendar, err := loadCalendar(context.Background(), "testdata/room.ics", "", location)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := [...]struct {
+		At       string
+		Expected string
+	}{
+		{At: "2026-10-22T08:00", Expected: "Free until 09:00"},
+		// back to back meetings are one busy period.
+		{At: "2026-10-22T09:15", Expected: "Busy until 10:30"},
+		{At: "2026-10-22T10:00", Expected: "Busy until 10:30"},
+		// transparent and cancelled events don't block time, nor do all day ones.
+		{At: "2026-10-22T11:30", Expected: "Free until 14:00"},
+		{At: "2026-10-22T14:30", Expected: "Busy until 15:00"},
+		// the next meeting is the next week.
+		{At: "2026-10-22T16:00", Expected: "Free"},
+		// after the end of daylight saving the review is still at 14:00 local time.
+		{At: "2026-10-29T13:00", Expected: "Free until 14:00"},
+		{At: "2026-10-29T14:59", Expected: "Busy until 15:00"},
+		// the excluded occurrence.
+		{At: "2026-11-05T14:30", Expected: "Free"},
+		// the moved occurrence.
+		{At: "2026-11-12T14:30", Expected: "Free until 16:00"},
+		{At: "2026-11-12T16:30", Expected: "Busy until 17:00"},
+		// the last occurrence, and after it.
+		{At: "2026-11-19T14:30", Expected: "Busy until 15:00"},
+		{At: "2026-11-26T14:30", Expected: "Free"},
+	}
+	for _, tc := range testCases {
+		now, err := time.ParseInLocation("2006-01-02T15:04", tc.At, location)
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual := roomStatus(meetings(calendar.Occurrences(now, now.Add(24*time.Hour))), now, location)
+		if actual != tc.Expected {
+			t.Errorf("at %s; expected %q, actual %q", tc.At, tc.Expected, actual)
+		}
+	}
+}
+
+func TestMeetingNotification(t *testing.T) {
+	location, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("time zone not available; %v", err)
+	}
+	calendar, err := loadCalendar(context.Background(), "testdata/room.ics", "", location)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 10, 22, 8, 50, 30, 0, location)
+	occurrences := meetings(calendar.Occurrences(now, now.Add(time.Hour)))
+	if len(occurrences) != 1 {
+		t.Fatalf("expected the planning meeting, actual %d meetings", len(occurrences))
+	}
+	notification := meetingNotification(occurrences[0], now)
+	if text := notification.Model.Frames[0].Text; text != "Meeting in 10 min: Planning" {
+		t.Errorf("unexpected reminder %q", text)
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wcharczuk/lametric/pkg/lametric"
+)
+
+// readChartSeries reads a time series from a given path, or stdin if the path is `-`.
+func readChartSeries(path string) ([]lametric.Point, error) {
+	if path == "-" {
+		return readSeries(os.Stdin)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return readSeries(f)
+}
+
+// readSeries reads a time series from a given reader.
+//
+// Each line is either a value, or a timestamp (unix seconds or rfc3339) and a value
+// separated by whitespace or a comma. Lines of only values may hold several values.
+// Blank lines and lines starting with `#` are ignored, as are values that
+// aren't finite (`NaN`, `Inf`), which leave a gap in the series.
+func readSeries(r io.Reader) (output []lametric.Point, err error) {
+	scanner := bufio.NewScanner(r)
+	var lineNumber, index int
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.FieldsFunc(line, func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t'
+		})
+		if len(fields) == 2 {
+			if timestamp, ok := parseTimestamp(fields[0]); ok {
+				value, err := strconv.ParseFloat(fields[1], 64)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+				}
+				if !isFinite(value) {
+					continue
+				}
+				output = append(output, lametric.Point{Time: timestamp, Value: value})
+				continue
+			}
+		}
+		for _, field := range fields {
+			value, err := strconv.ParseFloat(field, 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+			}
+			index++
+			if !isFinite(value) {
+				continue
+			}
+			output = append(output, lametric.Point{Time: time.Unix(int64(index-1), 0), Value: value})
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+func isFinite(value float64) bool {
+	return !math.IsNaN(value) && !math.IsInf(value, 0)
+}
+
+func parseTimestamp(value string) (time.Time, bool) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, true
+	}
+	// unix timestamps are at least 9 digits to not be confused with values
+	if len(value) >= 9 && !strings.ContainsAny(value, ".eE-") {
+		if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return time.Unix(seconds, 0), true
+		}
+	}
+	return time.Time{}, false
+}
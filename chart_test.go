@@ -0,0 +1,26 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadSeriesSkipsNonFinite(t *testing.T) {
+	series, err := readSeries(strings.NewReader("# load\n1 NaN 3\n+Inf\n5\n1700000000,nan\n1700000060,7\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []struct {
+		Time  int64
+		Value float64
+	}{{0, 1}, {2, 3}, {4, 5}, {1700000060, 7}}
+	if len(series) != len(expect) {
+		t.Fatalf("expected %d points, got %v", len(expect), series)
+	}
+	for index, point := range series {
+		if !point.Time.Equal(time.Unix(expect[index].Time, 0)) || point.Value != expect[index].Value {
+			t.Errorf("point %d; expected %v, got %v", index, expect[index], point)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/wcharczuk/lametric/pkg/lametric"
+)
+
+// completion writes a bash completion script for the flags and commands to a given writer.
+//
+// Install it with `source <(notifier completion)`.
+func completion(w io.Writer) error {
+	var icons []string
+	for _, info := range lametric.DefaultIconCatalog().Icons {
+		icons = append(icons, info.Name)
+	}
+	return completionTemplate.Execute(w, map[string]string{
+		"Commands":   "icons sounds discover status schedules remind calendar feeds monitor prometheus mqtt serve completion",
+		"Priorities": joinStrings(lametric.AllNotificationPriorities()),
+		"IconTypes":  joinStrings(lametric.AllIconTypes()),
+		"Sounds":     joinStrings(lametric.AllSoundIDs()),
+		"Icons":      strings.Join(icons, " "),
+	})
+}
+
+func joinStrings(values interface{}) string {
+	return strings.Trim(fmt.Sprint(values), "[]")
+}
+
+var completionTemplate = template.Must(template.New("completion").Parse(`# bash completion for notifier
+_notifier() {
+	local cur prev
+	cur="${COMP_WORDS[COMP_CWORD]}"
+	prev="${COMP_WORDS[COMP_CWORD-1]}"
+	case "${prev}" in
+		-priority|--priority)
+			COMPREPLY=($(compgen -W "{{ .Priorities }}" -- "${cur}")); return 0 ;;
+		-icon-type|--icon-type)
+			COMPREPLY=($(compgen -W "{{ .IconTypes }}" -- "${cur}")); return 0 ;;
+		-sound|--sound)
+			COMPREPLY=($(compgen -W "{{ .Sounds }}" -- "${cur}")); return 0 ;;
+		-frame|--frame)
+			COMPREPLY=($(compgen -W "{{ .Icons }}" -S ":" -- "${cur}")); compopt -o nospace; return 0 ;;
+		-config|--config|-template|--template|-chart|--chart|-cassette|--cassette)
+			COMPREPLY=($(compgen -f -- "${cur}")); return 0 ;;
+	esac
+	if [[ "${cur}" == -* ]]; then
+		COMPREPLY=($(compgen -W "--config --template --frame --priority --icon-type --sound --split --max-frames --truncate --abbreviate --chart --chart-threshold --chart-aggregation --debug --cassette --record --timeout --connect-timeout --tls-timeout" -- "${cur}"))
+		return 0
+	fi
+	COMPREPLY=($(compgen -W "{{ .Commands }}" -- "${cur}"))
+}
+complete -F _notifier notifier
+`))
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/wcharczuk/lametric/pkg/async"
+
+	"github.com/wcharczuk/lametric/pkg/apiutil"
+	"github.com/wcharczuk/lametric/pkg/config"
+	"github.com/wcharczuk/lametric/pkg/lametric"
+	"github.com/wcharczuk/lametric/pkg/notify"
+	"github.com/wcharczuk/lametric/pkg/trace"
+)
+
+// knownHosts are the pinned device certificate fingerprints.
+var knownHosts *apiutil.KnownHosts
+
+// recorder records or replays api requests if a cassette is set.
+var recorder *apiutil.Recorder
+
+// userAgent is the user agent api requests are sent with.
+const userAgent = "notifier"
+
+// breaker fails requests fast to hosts that keep failing.
+var breaker = new(apiutil.Breaker)
+
+// newTracer returns the tracer for a given config.
+func newTracer(cfg config.Tracing) *trace.Tracer {
+	tracer := &trace.Tracer{
+		Service: cfg.Service,
+		OnError: func(err error) {
+			log.Printf("trace; %v", err)
+		},
+	}
+	if tracer.Service == "" {
+		tracer.Service = "notifier"
+	}
+	if cfg.Stdout {
+		tracer.Exporters = append(tracer.Exporters, &trace.StdoutExporter{})
+	}
+	if cfg.File != "" {
+		tracer.Exporters = append(tracer.Exporters, &trace.FileExporter{Path: cfg.File, Service: tracer.Service})
+	}
+	return tracer
+}
+
+// newBreaker returns the circuit breaker for a given config that logs
+// and records its state changes.
+func newBreaker(cfg config.Breaker) *apiutil.Breaker {
+	return &apiutil.Breaker{
+		FailureThreshold: cfg.FailureThreshold,
+		CoolDown:         cfg.CoolDown,
+		OnStateChange: func(host string, from, to apiutil.BreakerState) {
+			log.Printf("breaker; %s %v -> %v", host, from, to)
+			observeBreaker(host, to)
+		},
+	}
+}
+
+// configTimeouts are the default timeouts from the config.
+var configTimeouts config.Timeouts
+
+// deviceTimeouts returns the timeouts for a device; the command line flags
+// take precedence over the device timeouts, which take precedence over the config.
+func deviceTimeouts(device config.Device) config.Timeouts {
+	flags := config.Timeouts{
+		Connect: *flagConnectTimeout,
+		TLS:     *flagTLSTimeout,
+		Request: *flagTimeout,
+	}
+	return flags.Or(device.Timeouts).Or(configTimeouts)
+}
+
+// apiOptions returns the options common to the api clients of a device.
+func apiOptions(device config.Device) []apiutil.Option {
+	opts := []apiutil.Option{
+		apiutil.OptMiddleware(
+			apiutil.UserAgent(userAgent),
+			apiutil.RequestID(),
+			breaker.Middleware(),
+			trace.Middleware,
+			apiutil.Timing(observeRequest),
+		),
+	}
+	if recorder != nil {
+		opts = append(opts, apiutil.OptMiddleware(recorder.Middleware()))
+	}
+	timeouts := deviceTimeouts(device)
+	if timeouts.Connect > 0 {
+		opts = append(opts, apiutil.OptDialTimeout(timeouts.Connect))
+	}
+	if timeouts.TLS > 0 {
+		opts = append(opts, apiutil.OptTLSHandshakeTimeout(timeouts.TLS))
+	}
+	if timeouts.Request > 0 {
+		opts = append(opts, apiutil.OptTimeout(timeouts.Request))
+	}
+	if *flagDebug {
+		opts = append(opts, apiutil.OptLog(apiutil.LoggerFunc(func(message string, fields ...interface{}) {
+			log.Println(append([]interface{}{"api;", message}, fields...)...)
+		})))
+	}
+	return opts
+}
+
+// newClient returns a lametric client for a given device.
+func newClient(device config.Device) *lametric.HTTPClient {
+	// the device address is checked with the same endpoint parsing when the
+	// config is validated, and re-resolved addresses before they're used.
+	endpoint, err := lametric.Endpoint(device.Scheme, device.Addr, device.Port)
+	if err != nil {
+		panic(err)
+	}
+	opts := apiOptions(device)
+	if endpoint.Scheme == lametric.SchemeHTTPS {
+		// pin by serial when it's known so that re-resolved addresses keep their pin
+		opts = append(opts, apiutil.OptPinnedTLS(device.Serial, device.Fingerprint, knownHosts))
+	}
+	return lametric.NewURL(endpoint.String(), device.Token, opts...)
+}
+
+// newNotifier returns the notifier for the backend of a given device.
+func newNotifier(device config.Device) notify.Notifier {
+	switch device.BackendOrDefault() {
+	case config.BackendSlack:
+		return notify.NewSlack(device.Addr, apiOptions(device)...)
+	case config.BackendWebhook:
+		return notify.NewWebhook(device.Addr, device.Token, apiOptions(device)...)
+	case config.BackendNtfy:
+		return notify.NewNtfy(device.Addr, device.Token, apiOptions(device)...)
+	case config.BackendEmail:
+		timeouts := deviceTimeouts(device)
+		return notify.Email{
+			Addr:        device.Addr,
+			From:        device.Email.From,
+			To:          device.Email.To,
+			Username:    device.Email.Username,
+			Password:    device.Email.Password,
+			DialTimeout: timeouts.Connect,
+			Timeout:     timeouts.Request,
+		}
+	default:
+		return newClient(device)
+	}
+}
+
+func send(ctx context.Context, device config.Device, notification lametric.Notification) error {
+	device = resolvedDevice(device)
+	err := newNotifier(device).Notify(ctx, notification)
+	if err != nil && device.BackendOrDefault() == config.BackendLaMetric && device.Serial != "" && ctx.Err() == nil && isUnreachable(err) {
+		resolved, resolveErr := resolveDevice(ctx, device)
+		if resolveErr != nil {
+			return err
+		}
+		err = newNotifier(resolved).Notify(ctx, notification)
+	}
+	return err
+}
+
+// delivery is the result of sending a notification to a device.
+type delivery struct {
+	Device config.Device
+	Err    error
+	// RetryAt is set if the delivery was deferred because the breaker for the
+	// device was open; the notification wasn't sent and can be retried then.
+	RetryAt time.Time
+}
+
+// newDelivery returns the delivery for the result of sending to a device.
+func newDelivery(device config.Device, err error) delivery {
+	output := delivery{Device: device, Err: err}
+	var breakerOpen *apiutil.BreakerOpenError
+	if errors.As(err, &breakerOpen) {
+		output.RetryAt = breakerOpen.RetryAt
+	}
+	return output
+}
+
+// Deferred returns if the notification wasn't sent because the breaker for the device was open.
+func (d delivery) Deferred() bool {
+	return !d.RetryAt.IsZero()
+}
+
+// deliveries are the results of a broadcast.
+type deliveries []delivery
+
+// Err returns the errors of failed deliveries, or nil if they all succeeded.
+func (d deliveries) Err() error {
+	errs := make(async.Errors, len(d))
+	for _, result := range d {
+		if result.Err != nil {
+			errs <- result.Err
+		}
+	}
+	return errs.All()
+}
+
+// deliver sends a notification to a device, counting it as sent from a given source.
+func deliver(ctx context.Context, source string, device config.Device, notification lametric.Notification) error {
+	ctx, span := trace.Start(ctx, "deliver", trace.KindInternal)
+	span.SetAttribute("device", device.Label())
+	span.SetAttribute("backend", device.BackendOrDefault())
+	span.SetAttribute("source", source)
+	err := send(ctx, device, notification)
+	switch {
+	case errors.Is(err, apiutil.ErrBreakerOpen):
+		// the request wasn't sent, so it doesn't count as a failed attempt.
+		span.SetAttribute("deferred", true)
+		metricNotificationsDeferred.Inc(device.Label(), source)
+	case err != nil:
+		metricNotificationsFailed.Inc(device.Label(), source)
+	default:
+		metricNotificationsSent.Inc(device.Label(), source)
+	}
+	span.Finish(err)
+	return err
+}
+
+// Deferred returns the deliveries that weren't sent because the breaker for
+// their device was open.
+func (d deliveries) Deferred() (output deliveries) {
+	for _, result := range d {
+		if result.Deferred() {
+			output = append(output, result)
+		}
+	}
+	return
+}
+
+// Pending returns the devices whose sends were canceled before they finished.
+func (d deliveries) Pending() (output []config.Device) {
+	for _, result := range d {
+		if errors.Is(result.Err, context.Canceled) {
+			output = append(output, result.Device)
+		}
+	}
+	return
+}
+
+// Delivered returns if the notification was sent to at least one of the
+// devices, or there weren't any to send to.
+func (d deliveries) Delivered() bool {
+	for _, result := range d {
+		if result.Err == nil {
+			return true
+		}
+	}
+	return len(d) == 0
+}
+
+// broadcast sends a notification from a given source to each of the given devices concurrently.
+func broadcast(ctx context.Context, source string, devices []config.Device, notification lametric.Notification) deliveries {
+	results := make(deliveries, len(devices))
+	wg := sync.WaitGroup{}
+	wg.Add(len(devices))
+	for x := 0; x < len(devices); x++ {
+		go func(index int) {
+			defer wg.Done()
+			results[index] = newDelivery(devices[index], deliver(ctx, source, devices[index], notification))
+		}(x)
+	}
+	wg.Wait()
+	return results
+}
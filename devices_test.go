@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wcharczuk/lametric/pkg/apiutil"
+	"github.com/wcharczuk/lametric/pkg/config"
+	"github.com/wcharczuk/lametric/pkg/lametric"
+)
+
+func TestNewDeliveryDeferred(t *testing.T) {
+	retryAt := time.Now().Add(30 * time.Second)
+	open := &url.Error{Op: "Post", URL: "https://kitchen:4343", Err: &apiutil.BreakerOpenError{Host: "kitchen:4343", RetryAt: retryAt}}
+	results := deliveries{
+		newDelivery(config.Device{Name: "kitchen"}, open),
+		newDelivery(config.Device{Name: "office"}, fmt.Errorf("connection refused")),
+		newDelivery(config.Device{Name: "slack"}, nil),
+	}
+	deferred := results.Deferred()
+	if len(deferred) != 1 || deferred[0].Device.Name != "kitchen" || !deferred[0].RetryAt.Equal(retryAt) {
+		t.Errorf("expected kitchen to be deferred, got %+v", deferred)
+	}
+	if !errors.Is(deferred[0].Err, apiutil.ErrBreakerOpen) || results[:1].Err() == nil {
+		t.Errorf("expected deferred deliveries to be errors, got %v", deferred[0].Err)
+	}
+	if seconds := retryAfter(deferred); seconds < 29 || seconds > 30 {
+		t.Errorf("expected a retry after of ~30s, got %d", seconds)
+	}
+}
+
+func TestDeliverUnnamedLabels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	notification := lametric.Notification{Model: lametric.NotificationModel{Frames: []lametric.Frame{{Text: "hello"}}}}
+	for _, backend := range []string{config.BackendWebhook, config.BackendNtfy} {
+		device := config.Device{Backend: backend, Addr: server.URL + "/hooks/T0001/s3cr3t?key=hunter2"}
+		if err := device.Validate(); err != nil {
+			t.Fatal(err)
+		}
+		if err := deliver(context.Background(), sourceCLI, device, notification); err != nil {
+			t.Fatalf("%s: %v", backend, err)
+		}
+		if label := device.Label(); label != server.URL {
+			t.Errorf("%s: expected label %q, actual %q", backend, server.URL, label)
+		}
+	}
+
+	buffer := new(bytes.Buffer)
+	if _, err := registry.WriteTo(buffer); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buffer.String(), fmt.Sprintf("device=%q", server.URL)) {
+		t.Errorf("expected the sent notifications to be labeled with the url host, got:\n%s", buffer)
+	}
+	for _, secret := range []string{"/hooks", "s3cr3t", "key=", "hunter2"} {
+		if strings.Contains(buffer.String(), secret) {
+			t.Errorf("expected the metrics not to contain %q, got:\n%s", secret, buffer)
+		}
+	}
+}
+
+// TestNewClientValidated checks that the addresses the config accepts don't
+// make newClient panic, as both parse them as endpoints.
+func TestNewClientValidated(t *testing.T) {
+	for _, device := range []config.Device{
+		{Addr: "10.0.0.2"},
+		{Addr: "10.0.0.2:9000"},
+		{Addr: "fe80::1"},
+		{Addr: "[fe80::1]:4343", Scheme: lametric.SchemeHTTPS},
+		{Addr: "https://kitchen.local/"},
+		{Addr: "kitchen.local", Port: 8443},
+		{Addr: "[kitchen]"},
+		{Addr: "http://10.0.0.2/api"},
+		{Addr: ""},
+	} {
+		err := device.Validate()
+		func() {
+			defer func() {
+				if r := recover(); r != nil && err == nil {
+					t.Errorf("%q: validated, but newClient panicked: %v", device.Addr, r)
+				}
+			}()
+			_ = newClient(device)
+		}()
+	}
+}
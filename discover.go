@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/wcharczuk/lametric/pkg/apiutil"
+	"github.com/wcharczuk/lametric/pkg/config"
+	"github.com/wcharczuk/lametric/pkg/discovery"
+	"github.com/wcharczuk/lametric/pkg/lametric"
+)
+
+// discover searches the local network for devices and prints them as a config `devices:` block.
+func discover(args []string) error {
+	fs := flag.NewFlagSet("discover", flag.ContinueOnError)
+	addr := fs.String("addr", discovery.DefaultAddr, "The ssdp address to send the search to")
+	timeout := fs.Duration("timeout", discovery.DefaultTimeout, "How long to wait for responses")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	devices, err := discovery.Searcher{Addr: *addr, Timeout: *timeout}.Search(context.Background())
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "# %d devices found; add each device's api key as its token\n", len(devices))
+	var output struct {
+		Devices []config.Device `yaml:"devices"`
+	}
+	for _, device := range devices {
+		output.Devices = append(output.Devices, config.Device{
+			Name:   device.Name,
+			Addr:   device.Addr,
+			Serial: device.Serial,
+		})
+	}
+	encoder := yaml.NewEncoder(os.Stdout)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(output); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// resolveInterval is the minimum time between searches for the same device,
+// so that sends to a device that's offline don't each wait on a search.
+const resolveInterval = time.Minute
+
+// resolved are the addresses devices were last found at, by serial.
+var resolved = struct {
+	sync.Mutex
+	addrs    map[string]string
+	searched map[string]time.Time
+}{
+	addrs:    make(map[string]string),
+	searched: make(map[string]time.Time),
+}
+
+// resolvedDevice returns a device with the address it was last found at, if
+// it was re-resolved.
+func resolvedDevice(device config.Device) config.Device {
+	if device.Serial == "" {
+		return device
+	}
+	resolved.Lock()
+	defer resolved.Unlock()
+	if addr, ok := resolved.addrs[device.Serial]; ok {
+		device.Addr = addr
+	}
+	return device
+}
+
+// resolveDevice finds a device with a serial on the local network, returning
+// the device with its address updated.
+//
+// The address is cached for later sends; a device is searched for at most
+// once every `resolveInterval`.
+func resolveDevice(ctx context.Context, device config.Device) (config.Device, error) {
+	resolved.Lock()
+	if last, ok := resolved.searched[device.Serial]; ok && time.Since(last) < resolveInterval {
+		resolved.Unlock()
+		return device, fmt.Errorf("%w; %s; searched %v ago", discovery.ErrDeviceNotFound, device.Serial, time.Since(last).Round(time.Second))
+	}
+	resolved.searched[device.Serial] = time.Now()
+	resolved.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, 2*discovery.DefaultTimeout)
+	defer cancel()
+	found, err := discovery.Resolve(ctx, device.Serial)
+	if err != nil {
+		return device, err
+	}
+	// the address is checked as the config is, so that newClient doesn't panic on it.
+	if _, err := lametric.Endpoint(device.Scheme, found.Addr, device.Port); err != nil {
+		return device, fmt.Errorf("device %s (%s); re-resolved; %w", device.Name, device.Serial, err)
+	}
+	log.Printf("device %s (%s) re-resolved from %s to %s", device.Name, device.Serial, device.Addr, found.Addr)
+	resolved.Lock()
+	resolved.addrs[device.Serial] = found.Addr
+	resolved.Unlock()
+	device.Addr = found.Addr
+	return device, nil
+}
+
+// isUnreachable returns if an error is a transport error, rather than an error from the device.
+func isUnreachable(err error) bool {
+	// requests that failed fast weren't sent, the breaker trial request re-resolves the device
+	if errors.Is(err, apiutil.ErrBreakerOpen) {
+		return false
+	}
+	// the caller gave up, which says nothing about the device; timeouts do
+	// say something, so they're unreachable unless the caller's context is
+	// done, which send checks.
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"testing"
+
+	"github.com/wcharczuk/lametric/pkg/apiutil"
+)
+
+func TestIsUnreachable(t *testing.T) {
+	testCases := []struct {
+		Name   string
+		Err    error
+		Expect bool
+	}{
+		{Name: "transport", Err: &url.Error{Op: "Post", URL: "https://10.0.0.2:4343", Err: errors.New("connect: no route to host")}, Expect: true},
+		{Name: "canceled", Err: &url.Error{Op: "Post", URL: "https://10.0.0.2:4343", Err: context.Canceled}},
+		{Name: "deadline", Err: &url.Error{Op: "Post", URL: "https://10.0.0.2:4343", Err: context.DeadlineExceeded}, Expect: true},
+		{Name: "dial timeout", Err: &url.Error{Op: "Post", URL: "https://10.0.0.2:4343", Err: &net.OpError{Op: "dial", Net: "tcp", Err: timeoutError{}}}, Expect: true},
+		{Name: "breaker open", Err: fmt.Errorf("%w; kitchen", apiutil.ErrBreakerOpen)},
+		{Name: "status", Err: &apiutil.StatusError{StatusCode: 401}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			if actual := isUnreachable(tc.Err); actual != tc.Expect {
+				t.Errorf("expected %v, got %v", tc.Expect, actual)
+			}
+		})
+	}
+}
+
+// timeoutError is a transport timeout, e.g. `dial tcp: i/o timeout`.
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
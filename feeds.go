@@ -0,0 +1,255 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/wcharczuk/lametric/pkg/apiutil"
+	"github.com/wcharczuk/lametric/pkg/config"
+	"github.com/wcharczuk/lametric/pkg/feed"
+	"github.com/wcharczuk/lametric/pkg/lametric"
+	"github.com/wcharczuk/lametric/pkg/notify"
+	"github.com/wcharczuk/lametric/pkg/trace"
+)
+
+// feedEntry is the template data for a new feed entry.
+type feedEntry struct {
+	// Feed is the configured name of the feed.
+	Feed string
+	// FeedTitle is the title of the feed from the feed itself.
+	FeedTitle string
+	Title     string
+	Link      string
+	Author    string
+	Summary   string
+	Published time.Time
+}
+
+// runFeeds polls each configured feed until the context is done.
+func runFeeds(ctx context.Context, cfg config.Config) error {
+	store, err := feed.NewStore(cfg.FeedStateOrDefault())
+	if err != nil {
+		return fmt.Errorf("feed state; %w", err)
+	}
+	wg := sync.WaitGroup{}
+	wg.Add(len(cfg.Feeds))
+	for _, f := range cfg.Feeds {
+		go func(f config.Feed) {
+			defer wg.Done()
+			ticker := time.NewTicker(f.IntervalOrDefault())
+			defer ticker.Stop()
+			for {
+				if err := pollFeed(ctx, cfg, store, f, time.Now()); err != nil && ctx.Err() == nil {
+					log.Printf("feed; %s; %v", f.Name, err)
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+			}
+		}(f)
+	}
+	wg.Wait()
+	return ctx.Err()
+}
+
+// pollFeed fetches a feed and announces its new entries.
+//
+// The entries of the first poll of a feed are marked seen without being announced,
+// and at most the feed's max per poll (the latest) are announced after that.
+// Each entry is marked seen once it's been sent to a device, so entries that
+// couldn't be sent to any are announced again on the next poll.
+func pollFeed(ctx context.Context, cfg config.Config, store *feed.Store, f config.Feed, now time.Time) (err error) {
+	ctx, span := trace.Start(ctx, "feed.poll", trace.KindInternal)
+	span.SetAttribute("feed", f.Name)
+	defer func() { span.Finish(err) }()
+
+	state := store.State(f.Name)
+	parsed, validators, err := feed.Fetch(ctx, feedClient(f.URL), state.Validators)
+	if errors.Is(err, feed.ErrNotModified) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !state.Polled() {
+		log.Printf("feed; %s; first poll, marking %d entries seen", f.Name, len(parsed.Items))
+		state.Validators = validators
+		state.MarkSeen(parsed.Items, now, feed.DefaultSeenRetention)
+		return store.SetState(f.Name, state)
+	}
+
+	unseen := latest(state.Unseen(parsed.Items), f.MaxPerPollOrDefault())
+	if skipped := len(state.Unseen(parsed.Items)) - len(unseen); skipped > 0 {
+		log.Printf("feed; %s; skipping %d older new entries", f.Name, skipped)
+	}
+	// the entries that aren't announced are marked seen (and the ids of old
+	// ones removed) up front, the announced ones as they're sent.
+	state.MarkSeen(without(parsed.Items, unseen), now, feed.DefaultSeenRetention)
+	// names are checked when the config is validated.
+	devices, _ := cfg.SelectDevices(f.Devices)
+	var unsent int
+	for _, item := range unseen {
+		notification, err := feedNotification(cfg, f, parsed, item)
+		if err != nil {
+			log.Printf("feed; %s; %q; %v", f.Name, item.Title, err)
+			unsent++
+			continue
+		}
+		log.Printf("feed; %s; sending %q", f.Name, item.Title)
+		results := broadcast(ctx, sourceFeed, devices, notification)
+		for _, result := range results {
+			if result.Err != nil {
+				log.Printf("feed; %s; %s; %v", f.Name, result.Device.Label(), result.Err)
+			}
+		}
+		if !results.Delivered() {
+			unsent++
+			continue
+		}
+		state.MarkItemSeen(item, now)
+		if err := store.SetState(f.Name, state); err != nil {
+			return err
+		}
+	}
+	// the validators are only kept if every entry was sent, as a conditional
+	// fetch wouldn't return the ones that weren't.
+	if unsent == 0 {
+		state.Validators = validators
+	}
+	return store.SetState(f.Name, state)
+}
+
+// without returns the items that aren't in a given list, in order.
+func without(items, exclude []feed.Item) (output []feed.Item) {
+	excluded := make(map[string]bool, len(exclude))
+	for _, item := range exclude {
+		excluded[item.ID] = true
+	}
+	for _, item := range items {
+		if !excluded[item.ID] {
+			output = append(output, item)
+		}
+	}
+	return
+}
+
+// latest returns at most a given number of the latest items, oldest first.
+func latest(items []feed.Item, max int) []feed.Item {
+	sorted := make([]feed.Item, len(items))
+	copy(sorted, items)
+	// items without a date keep their feed order, which is usually newest first.
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Published.After(sorted[j].Published)
+	})
+	if len(sorted) > max {
+		sorted = sorted[:max]
+	}
+	for i, j := 0, len(sorted)-1; i < j; i, j = i+1, j-1 {
+		sorted[i], sorted[j] = sorted[j], sorted[i]
+	}
+	return sorted
+}
+
+// feedClient returns the client feeds are fetched with.
+func feedClient(url string) apiutil.Client {
+	return apiutil.New(url, apiutil.OptMiddleware(apiutil.UserAgent("notifier"), trace.Middleware))
+}
+
+// feedNotification returns the notification for a new entry, rendered with the
+// feed template, or the feed name and entry title; frames without an icon get
+// the feed icon.
+func feedNotification(cfg config.Config, f config.Feed, parsed *feed.Feed, item feed.Item) (lametric.Notification, error) {
+	notification := lametric.Notification{
+		Model: lametric.NotificationModel{
+			Frames: []lametric.Frame{{Text: f.Name + ": " + item.Title}},
+		},
+	}
+	if f.Template != "" {
+		var err error
+		notification, err = notify.Render(cfg.Templates[f.Template], feedEntry{
+			Feed:      f.Name,
+			FeedTitle: parsed.Title,
+			Title:     item.Title,
+			Link:      item.Link,
+			Author:    item.Author,
+			Summary:   item.Summary,
+			Published: item.Published,
+		})
+		if err != nil {
+			return lametric.Notification{}, err
+		}
+	}
+	for index := range notification.Model.Frames {
+		if notification.Model.Frames[index].Icon == "" {
+			notification.Model.Frames[index].Icon = f.IconOrDefault()
+		}
+	}
+	return notification, nil
+}
+
+// feeds prints the entries of the configured feeds, flagging the ones that
+// `serve` hasn't seen yet; it doesn't change the feed state.
+func feeds(cfg config.Config, args []string) error {
+	fs := flag.NewFlagSet("feeds", flag.ContinueOnError)
+	name := fs.String("feed", "", "Only print the entries of a feed")
+	limit := fs.Int("limit", 10, "The number of entries to print per feed")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	store, err := feed.NewStore(cfg.FeedStateOrDefault())
+	if err != nil {
+		return fmt.Errorf("feed state; %w", err)
+	}
+	ctx := context.Background()
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	var printed bool
+	for _, f := range cfg.Feeds {
+		if *name != "" && f.Name != *name {
+			continue
+		}
+		printed = true
+		state := store.State(f.Name)
+		// fetched unconditionally so the entries are printed even if they haven't changed.
+		parsed, _, err := feed.Fetch(ctx, feedClient(f.URL), feed.Validators{})
+		if err != nil {
+			return fmt.Errorf("feed %s; %w", f.Name, err)
+		}
+		fmt.Fprintf(tw, "%s\t%s\n", f.Name, orDash(parsed.Title))
+		fmt.Fprintln(tw, "PUBLISHED\tTITLE\tNEW")
+		unseen := make(map[string]bool)
+		if state.Polled() {
+			for _, item := range state.Unseen(parsed.Items) {
+				unseen[item.ID] = true
+			}
+		}
+		for index, item := range parsed.Items {
+			if index == *limit {
+				break
+			}
+			published := "-"
+			if !item.Published.IsZero() {
+				published = item.Published.Local().Format("2006-01-02 15:04 MST")
+			}
+			isNew := "-"
+			if unseen[item.ID] {
+				isNew = "yes"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", published, item.Title, isNew)
+		}
+		fmt.Fprintln(tw)
+	}
+	if !printed {
+		return fmt.Errorf("feeds; no feeds configured")
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/wcharczuk/lametric/pkg/config"
+	"github.com/wcharczuk/lametric/pkg/feed"
+	"github.com/wcharczuk/lametric/pkg/lametric"
+	"github.com/wcharczuk/lametric/pkg/notify"
+)
+
+// testFeed serves an rss feed of given titles, with an etag that changes with them.
+type testFeed struct {
+	mu     sync.Mutex
+	titles []string
+}
+
+func (tf *testFeed) SetTitles(titles ...string) {
+	tf.mu.Lock()
+	defer tf.mu.Unlock()
+	tf.titles = titles
+}
+
+func (tf *testFeed) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	tf.mu.Lock()
+	defer tf.mu.Unlock()
+	etag := fmt.Sprintf("%q", strings.Join(tf.titles, ","))
+	rw.Header().Set("ETag", etag)
+	if req.Header.Get("If-None-Match") == etag {
+		rw.WriteHeader(http.StatusNotModified)
+		return
+	}
+	fmt.Fprint(rw, `<rss version="2.0"><channel><title>Test</title>`)
+	for _, title := range tf.titles {
+		fmt.Fprintf(rw, "<item><guid>%s</guid><title>%s</title></item>", title, title)
+	}
+	fmt.Fprint(rw, `</channel></rss>`)
+}
+
+// testWebhook records the titles of the notifications posted to it, or fails them.
+type testWebhook struct {
+	mu     sync.Mutex
+	fail   bool
+	titles []string
+}
+
+func (tw *testWebhook) SetFail(fail bool) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	tw.fail = fail
+}
+
+func (tw *testWebhook) Titles() []string {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	output := tw.titles
+	tw.titles = nil
+	return output
+}
+
+func (tw *testWebhook) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.fail {
+		http.Error(rw, "unavailable", http.StatusInternalServerError)
+		return
+	}
+	var payload notify.WebhookPayload
+	if err := json.NewDecoder(req.Body).Decode(&payload); err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+	tw.titles = append(tw.titles, strings.Join(payload.Lines, " "))
+}
+
+func TestPollFeed(t *testing.T) {
+	source := new(testFeed)
+	feedServer := httptest.NewServer(source)
+	defer feedServer.Close()
+	webhook := new(testWebhook)
+	webhookServer := httptest.NewServer(webhook)
+	defer webhookServer.Close()
+
+	cfg := config.Config{
+		Devices: []config.Device{{Name: "hook", Backend: config.BackendWebhook, Addr: webhookServer.URL}},
+		Templates: map[string]lametric.Notification{
+			// rendering fails for entries titled `Broken`.
+			"entry": {Model: lametric.NotificationModel{Frames: []lametric.Frame{{Text: `{{ if eq .Title "Broken" }}{{ .Published.Missing }}{{ end }}{{ .Title }}`}}}},
+		},
+	}
+	f := config.Feed{Name: "test", URL: feedServer.URL, Template: "entry", MaxPerPoll: 5}
+	store, err := feed.NewStore(filepath.Join(t.TempDir(), "feeds.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 10, 19, 15, 0, 0, 0, time.UTC)
+	poll := func(expected ...string) {
+		t.Helper()
+		if err := pollFeed(context.Background(), cfg, store, f, now); err != nil {
+			t.Fatal(err)
+		}
+		if actual := webhook.Titles(); strings.Join(actual, ",") != strings.Join(expected, ",") {
+			t.Fatalf("expected %q to be announced, actual %q", expected, actual)
+		}
+	}
+
+	// the entries of the first poll aren't announced.
+	source.SetTitles("one")
+	poll()
+
+	// entries that can't be sent aren't marked seen, nor is the feed version.
+	source.SetTitles("three", "two", "one")
+	webhook.SetFail(true)
+	poll()
+	state := store.State("test")
+	if len(state.Unseen([]feed.Item{{ID: "two"}, {ID: "three"}})) != 2 || state.ETag != `"one"` {
+		t.Fatalf("expected the failed entries to be unseen, actual %+v", state)
+	}
+
+	webhook.SetFail(false)
+	poll("two", "three")
+	// the feed isn't modified.
+	poll()
+
+	// an entry that fails to render doesn't hold up or repeat the others.
+	source.SetTitles("five", "Broken", "four", "three", "two", "one")
+	poll("four", "five")
+	source.SetTitles("six", "five", "Broken", "four", "three", "two", "one")
+	poll("six")
+	if unseen := store.State("test").Unseen([]feed.Item{{ID: "Broken"}}); len(unseen) != 1 {
+		t.Errorf("expected the entry that failed to render to be unseen")
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/wcharczuk/lametric/pkg/lametric"
+)
+
+// frameFlags is a repeatable flag of `icon:text` frame specifications.
+//
+// The icon may be an icon id (e.g. `i555`), a catalog name, an inline data uri
+// or a path to a png or gif file; specifications whose prefix isn't an icon
+// are all text, e.g. `Deploy: done`.
+type frameFlags []string
+
+// String implements flag.Value.
+func (ff *frameFlags) String() string {
+	if ff == nil {
+		return ""
+	}
+	return strings.Join(*ff, ",")
+}
+
+// Set implements flag.Value.
+func (ff *frameFlags) Set(spec string) error {
+	*ff = append(*ff, spec)
+	return nil
+}
+
+// Frames parses the frame specifications.
+//
+// Icon names are checked against the default icon catalog, so it must be
+// called after the configured catalog is loaded.
+func (ff frameFlags) Frames() ([]lametric.Frame, error) {
+	output := make([]lametric.Frame, 0, len(ff))
+	for _, spec := range ff {
+		frame, err := parseFrameSpec(spec)
+		if err != nil {
+			return nil, err
+		}
+		output = append(output, frame)
+	}
+	return output, nil
+}
+
+// parseFrameSpec parses a frame from an `icon:text` specification.
+func parseFrameSpec(spec string) (lametric.Frame, error) {
+	// data uris contain a colon themselves, e.g. `data:image/png;base64,...:text`.
+	if strings.HasPrefix(spec, "data:image/") {
+		index := strings.Index(spec[len("data:"):], ":")
+		if index < 0 {
+			return lametric.Frame{Icon: lametric.Icon(spec)}, nil
+		}
+		index += len("data:")
+		return lametric.Frame{Icon: lametric.Icon(spec[:index]), Text: spec[index+1:]}, nil
+	}
+	index := strings.Index(spec, ":")
+	if index <= 0 {
+		return lametric.Frame{Text: spec}, nil
+	}
+	icon, text := spec[:index], spec[index+1:]
+	if isIconPath(icon) {
+		loaded, err := lametric.LoadIcon(icon)
+		if err != nil {
+			return lametric.Frame{}, err
+		}
+		return lametric.Frame{Icon: loaded, Text: text}, nil
+	}
+	if parsed, err := lametric.ParseIcon(icon); err == nil {
+		return lametric.Frame{Icon: parsed, Text: text}, nil
+	}
+	return lametric.Frame{Text: spec}, nil
+}
+
+// isIconPath returns if an icon prefix is a path to an image file, i.e. it
+// has an image extension, or has a path separator and exists.
+func isIconPath(icon string) bool {
+	switch strings.ToLower(filepath.Ext(icon)) {
+	case ".png", ".gif":
+		return true
+	}
+	if !strings.ContainsRune(icon, filepath.Separator) {
+		return false
+	}
+	_, err := os.Stat(icon)
+	return err == nil
+}
+
+// textFlag adapts a text (un)marshaler, like the lametric enum types, to a flag.Value.
+type textFlag struct {
+	value interface {
+		encoding.TextMarshaler
+		encoding.TextUnmarshaler
+	}
+}
+
+// String implements flag.Value.
+func (tf textFlag) String() string {
+	if tf.value == nil {
+		return ""
+	}
+	text, _ := tf.value.MarshalText()
+	return string(text)
+}
+
+// Set implements flag.Value.
+func (tf textFlag) Set(value string) error {
+	return tf.value.UnmarshalText([]byte(value))
+}
+
+// stringsFlag is a repeatable string flag.
+type stringsFlag []string
+
+// String implements flag.Value.
+func (sf *stringsFlag) String() string {
+	if sf == nil {
+		return ""
+	}
+	return strings.Join(*sf, ",")
+}
+
+// Set implements flag.Value.
+func (sf *stringsFlag) Set(value string) error {
+	*sf = append(*sf, value)
+	return nil
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/wcharczuk/lametric/pkg/lametric"
+)
+
+func TestParseFrameSpec(t *testing.T) {
+	for _, tc := range []struct {
+		spec string
+		want lametric.Frame
+	}{
+		{spec: "hello", want: lametric.Frame{Text: "hello"}},
+		{spec: "i555:Deploy done", want: lametric.Frame{Icon: "i555", Text: "Deploy done"}},
+		{spec: "Deploy: done", want: lametric.Frame{Text: "Deploy: done"}},
+		{spec: ":leading colon", want: lametric.Frame{Text: ":leading colon"}},
+		{spec: "CI/CD: green", want: lametric.Frame{Text: "CI/CD: green"}},
+		{spec: "i555:time 12:30", want: lametric.Frame{Icon: "i555", Text: "time 12:30"}},
+		{spec: "data:image/png;base64,AAAA:inline", want: lametric.Frame{Icon: "data:image/png;base64,AAAA", Text: "inline"}},
+	} {
+		got, err := parseFrameSpec(tc.spec)
+		if err != nil {
+			t.Errorf("%q: %v", tc.spec, err)
+			continue
+		}
+		if got.Icon != tc.want.Icon || got.Text != tc.want.Text {
+			t.Errorf("%q: got %q %q, want %q %q", tc.spec, got.Icon, got.Text, tc.want.Icon, tc.want.Text)
+		}
+	}
+}
+
+func TestParseFrameSpecMissingImage(t *testing.T) {
+	if _, err := parseFrameSpec("missing.png:text"); err == nil {
+		t.Error("expected an error for a missing icon file")
+	}
+}
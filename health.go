@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/wcharczuk/lametric/pkg/config"
+	"github.com/wcharczuk/lametric/pkg/health"
+	"github.com/wcharczuk/lametric/pkg/lametric"
+)
+
+// newHealthChecker returns a health checker for the lametric devices that
+// records metrics and routes health events to the configured devices.
+//
+// Events are routed in order on a given queue, rather than on the goroutines
+// that check the devices.
+func newHealthChecker(cfg config.Config, events eventQueue) *health.Checker {
+	checker := &health.Checker{
+		Interval:         cfg.Health.Interval,
+		Timeout:          cfg.Health.Timeout,
+		FailureThreshold: cfg.Health.FailureThreshold,
+		OnStatus:         observeStatus,
+		OnEvent: func(ctx context.Context, event health.Event) {
+			events.Push(ctx, func() { routeHealthEvent(ctx, cfg, event) })
+		},
+	}
+	for _, device := range cfg.Devices {
+		if device.BackendOrDefault() == config.BackendLaMetric {
+			checker.Targets = append(checker.Targets, health.Target{
+				Name:   device.Label(),
+				Client: newClient(device),
+			})
+		}
+	}
+	return checker
+}
+
+// routeHealthEvent sends a health event to the configured devices, other than
+// the device the event is about.
+func routeHealthEvent(ctx context.Context, cfg config.Config, event health.Event) {
+	if event.Status.Err != nil {
+		log.Printf("health; %s %s; %v", event.Status.Name, event.Kind, event.Status.Err)
+	} else {
+		log.Printf("health; %s %s", event.Status.Name, event.Kind)
+	}
+	if len(cfg.Health.Notify) == 0 {
+		return
+	}
+	// names are checked when the config is validated.
+	targets, _ := cfg.SelectDevices(cfg.Health.Notify)
+	var devices []config.Device
+	for _, device := range targets {
+		if device.Label() != event.Status.Name {
+			devices = append(devices, device)
+		}
+	}
+	if err := broadcast(ctx, sourceHealth, devices, healthNotification(event)).Err(); err != nil {
+		log.Printf("health; %s %s; %v", event.Status.Name, event.Kind, err)
+	}
+}
+
+// healthNotification returns the notification for a health event.
+func healthNotification(event health.Event) lametric.Notification {
+	priority, icon := lametric.NotificationPriorityCritical, lametric.IconAttention
+	text := fmt.Sprintf("%s %s", event.Status.Name, event.Kind)
+	switch event.Kind {
+	case health.EventUnreachable:
+		if !event.Status.LastSeen.IsZero() {
+			text = fmt.Sprintf("%s unreachable since %s", event.Status.Name, event.Status.LastSeen.Format("2006-01-02 15:04 MST"))
+		}
+	case health.EventRecovered:
+		priority, icon = lametric.NotificationPriorityInfo, lametric.IconSmile
+	}
+	return lametric.Notification{
+		Priority: priority,
+		Model: lametric.NotificationModel{
+			Frames: []lametric.Frame{{Icon: icon, Text: text}},
+		},
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/wcharczuk/lametric/pkg/apiutil"
+	"github.com/wcharczuk/lametric/pkg/lametric"
+)
+
+// icons runs the `icons` subcommands.
+func icons(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: icons <search|refresh> [args]")
+	}
+	switch args[0] {
+	case "search":
+		return iconsSearch(args[1:])
+	case "refresh":
+		return iconsRefresh(args[1:])
+	default:
+		return fmt.Errorf("icons; invalid subcommand %q", args[0])
+	}
+}
+
+// iconsSearch prints the catalog icons matching a term.
+func iconsSearch(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: icons search <term>")
+	}
+	results := lametric.SearchIcons(strings.Join(args, " "))
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tANIMATED\tTAGS")
+	for _, info := range results {
+		fmt.Fprintf(tw, "%s\t%s\t%v\t%s\n", info.ID, info.Name, info.Animated, strings.Join(info.Tags, ","))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "%d icons found (catalog version %s)\n", len(results), lametric.DefaultIconCatalog().Version)
+	return nil
+}
+
+// iconsRefresh creates a catalog file from the developer icons api, or a dump of it.
+//
+// The embedded catalog is regenerated with `go generate ./pkg/lametric`.
+func iconsRefresh(args []string) error {
+	fs := flag.NewFlagSet("icons refresh", flag.ContinueOnError)
+	output := fs.String("o", "", "The catalog output path (defaults to stdout)")
+	version := fs.String("version", time.Now().UTC().Format("2006.01.02"), "The catalog version")
+	api := fs.String("url", lametric.DefaultIconsURL, "The developer icons api url, fetched when no dump is given")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	var catalog *lametric.IconCatalog
+	var err error
+	if fs.NArg() == 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+		client := apiutil.New(*api, apiutil.OptMiddleware(apiutil.UserAgent("notifier")))
+		if catalog, err = lametric.FetchIconDump(ctx, client, *version); err != nil {
+			return err
+		}
+	} else {
+		f, err := os.Open(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if catalog, err = lametric.ParseIconDump(f, *version); err != nil {
+			return err
+		}
+	}
+	if *output == "" {
+		_, err = catalog.WriteTo(os.Stdout)
+		return err
+	}
+	out, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	if _, err = catalog.WriteTo(out); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "%d icons written to %s\n", len(catalog.Icons), *output)
+	return nil
+}
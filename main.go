@@ -3,28 +3,133 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
 	"log"
-	"sync"
+	"math"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 
-	"github.com/wcharczuk/lametric/pkg/async"
+	"github.com/wcharczuk/lametric/pkg/apiutil"
 	"github.com/wcharczuk/lametric/pkg/config"
 	"github.com/wcharczuk/lametric/pkg/lametric"
+	"github.com/wcharczuk/lametric/pkg/trace"
 )
 
 var (
-	flagConfig = flag.String("config", "_config/config.yml", "The configuration path")
+	flagConfig         = flag.String("config", "_config/config.yml", "The configuration path")
+	flagDebug          = flag.Bool("debug", false, "Log api requests")
+	flagTimeout        = flag.Duration("timeout", 0, "The overall timeout of each api request (default 30s)")
+	flagConnectTimeout = flag.Duration("connect-timeout", 0, "The timeout to connect to each device (default 5s)")
+	flagTLSTimeout     = flag.Duration("tls-timeout", 0, "The tls handshake timeout for each device (default 5s)")
+	flagCassette       = flag.String("cassette", "", "A cassette path (.json or .yml) to replay api responses from, instead of sending requests")
+	flagRecord         = flag.Bool("record", false, "Record api requests and responses to the -cassette path")
+	flagTemplate       = flag.String("template", "", "The name of a notification template from the config to send")
+	flagFrames         frameFlags
+	flagPriority       lametric.NotificationPriority
+	flagIconType       lametric.IconType
+	flagSound          lametric.SoundID
+
+	flagSplit      = flag.Bool("split", false, "Split frame text that doesn't fit the display into multiple frames at word boundaries")
+	flagMaxFrames  = flag.Int("max-frames", 0, "The maximum number of frames each split frame produces (0 is unlimited)")
+	flagTruncate   = flag.Bool("truncate", false, "Truncate frame text that doesn't fit the display")
+	flagAbbreviate = flag.Bool("abbreviate", false, "Abbreviate common words in frame text that doesn't fit the display")
+
+	flagChart            = flag.String("chart", "", "A path to a file (or `-` for stdin) of numbers to add as a chart frame")
+	flagChartThreshold   = flag.Float64("chart-threshold", math.NaN(), "A chart value to highlight, columns at or above it are shown at full height")
+	flagChartAggregation = lametric.AggregationAvg
 )
 
 func init() {
-	flag.Parse()
-	log.SetFlags(log.Lshortfile | log.LUTC | log.Ldate | log.Ltime | log.Lmicroseconds)
+	flag.Var(&flagFrames, "frame", "A notification frame as `icon:text`, where icon is an icon id, catalog name or a png or gif path (can be repeated)")
+	flag.Var(textFlag{&flagPriority}, "priority", fmt.Sprintf("The notification priority, one of %v", lametric.AllNotificationPriorities()))
+	flag.Var(textFlag{&flagIconType}, "icon-type", fmt.Sprintf("The notification icon type, one of %v", lametric.AllIconTypes()))
+	flag.Var(textFlag{&flagSound}, "sound", "The notification sound id (see `completion` for the full list)")
+	flag.Var(textFlag{&flagChartAggregation}, "chart-aggregation", fmt.Sprintf("How chart values in the same column are combined, one of %v", lametric.AllAggregations()))
 }
 
 func main() {
+	flag.Parse()
+	log.SetFlags(log.Lshortfile | log.LUTC | log.Ldate | log.Ltime | log.Lmicroseconds)
+
+	// the icon catalog is read first so that icon names
+	// in the config are validated against it.
+	var preamble struct {
+		IconCatalog string `yaml:"iconCatalog"`
+	}
+	config.MustRead(&preamble, *flagConfig)
+	if preamble.IconCatalog != "" {
+		catalog, err := lametric.LoadIconCatalog(preamble.IconCatalog)
+		maybeFatalExit(err)
+		lametric.SetDefaultIconCatalog(catalog)
+	}
+
 	var cfg config.Config
 	config.MustRead(&cfg,
 		*flagConfig,
 	)
+	maybeFatalExit(cfg.Validate())
+	var err error
+	configTimeouts = cfg.Timeouts
+	breaker = newBreaker(cfg.Breaker)
+	trace.SetDefault(newTracer(cfg.Tracing))
+	knownHosts, err = apiutil.LoadKnownHosts(cfg.KnownHostsOrDefault())
+	maybeFatalExit(err)
+	if *flagCassette != "" {
+		mode := apiutil.CassetteReplay
+		if *flagRecord {
+			mode = apiutil.CassetteRecord
+		}
+		recorder, err = apiutil.NewRecorder(*flagCassette, mode)
+		maybeFatalExit(err)
+		recorder.Matchers = []apiutil.Matcher{apiutil.MatchMethod, apiutil.MatchHost, apiutil.MatchPath}
+	}
+
+	switch flag.Arg(0) {
+	case "icons":
+		maybeFatalExit(icons(flag.Args()[1:]))
+		return
+	case "sounds":
+		maybeFatalExit(sounds(cfg, flag.Args()[1:]))
+		return
+	case "discover":
+		maybeFatalExit(discover(flag.Args()[1:]))
+		return
+	case "status":
+		maybeFatalExit(status(cfg, flag.Args()[1:]))
+		return
+	case "serve":
+		maybeFatalExit(serve(cfg, flag.Args()[1:]))
+		return
+	case "mqtt":
+		maybeFatalExit(mqttBridge(cfg))
+		return
+	case "schedules":
+		maybeFatalExit(schedules(cfg, flag.Args()[1:]))
+		return
+	case "remind":
+		maybeFatalExit(reminders(cfg, flag.Args()[1:]))
+		return
+	case "calendar":
+		maybeFatalExit(calendar(cfg, flag.Args()[1:]))
+		return
+	case "feeds":
+		maybeFatalExit(feeds(cfg, flag.Args()[1:]))
+		return
+	case "monitor":
+		maybeFatalExit(monitors(cfg, flag.Args()[1:]))
+		return
+	case "prometheus":
+		maybeFatalExit(prometheusQueries(cfg, flag.Args()[1:]))
+		return
+	case "completion":
+		maybeFatalExit(completion(os.Stdout))
+		return
+	case "":
+	default:
+		log.Fatalf("invalid command %q", flag.Arg(0))
+	}
 
 	notification := lametric.Notification{
 		Model: lametric.NotificationModel{
@@ -45,21 +150,69 @@ func main() {
 		},
 	}
 
-	errs := make(async.Errors, len(cfg.Devices))
-	wg := sync.WaitGroup{}
-	wg.Add(len(cfg.Devices))
-	for x := 0; x < len(cfg.Devices); x++ {
-		go func(index int) {
-			defer wg.Done()
-			if err := send(context.Background(), cfg.Devices[index], notification); err != nil {
-				errs <- err
-			}
-		}(x)
+	if *flagTemplate != "" {
+		template, ok := cfg.Templates[*flagTemplate]
+		if !ok {
+			log.Fatalf("template %q not found", *flagTemplate)
+		}
+		notification = template
+	}
+	if len(flagFrames) > 0 {
+		frames, err := flagFrames.Frames()
+		maybeFatalExit(err)
+		notification.Model.Frames = frames
+	}
+	if flagPriority != "" {
+		notification.Priority = flagPriority
+	}
+	if flagIconType != "" {
+		notification.IconType = flagIconType
+	}
+	if flagSound != "" {
+		notification.Model.Sound = &lametric.Sound{
+			Category: flagSound.Category(),
+			ID:       flagSound,
+		}
 	}
-	wg.Wait()
-	if len(errs) > 0 {
-		maybeFatalExit(errs.All())
+	if *flagChart != "" {
+		series, err := readChartSeries(*flagChart)
+		maybeFatalExit(err)
+		opts := lametric.ChartOptions{
+			Aggregation: flagChartAggregation,
+		}
+		if !math.IsNaN(*flagChartThreshold) {
+			opts.Threshold = flagChartThreshold
+		}
+		notification.Model.Frames = append(notification.Model.Frames, lametric.ChartFrame(series, opts))
 	}
+	if *flagSplit || *flagTruncate || *flagAbbreviate {
+		policy := lametric.TextPolicy{
+			Split:     *flagSplit,
+			MaxFrames: *flagMaxFrames,
+			Truncate:  *flagTruncate,
+		}
+		if *flagAbbreviate {
+			policy.Abbreviations = lametric.DefaultAbbreviations
+		}
+		notification = lametric.FitNotification(notification, policy)
+	}
+	log.Printf("notification will be shown for ~%v", lametric.NotificationDuration(notification))
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ctx, span := trace.Start(ctx, "notify", trace.KindInternal)
+	span.SetAttribute("source", sourceCLI)
+	results := broadcast(ctx, sourceCLI, cfg.Devices, notification)
+	span.Finish(results.Err())
+	if pending := results.Pending(); len(pending) > 0 {
+		var labels []string
+		for _, device := range pending {
+			labels = append(labels, device.Label())
+		}
+		log.Printf("interrupted; %d of %d notifications still pending: %s", len(pending), len(cfg.Devices), strings.Join(labels, ", "))
+		os.Exit(130)
+	}
+	maybeFatalExit(results.Err())
 	log.Printf("%d notifications sent", len(cfg.Devices))
 }
 
@@ -68,9 +221,3 @@ func maybeFatalExit(err error) {
 		log.Fatal(err)
 	}
 }
-
-func send(ctx context.Context, device config.Device, notification lametric.Notification) error {
-	client := lametric.New(device.Addr, device.Token)
-	_, err := client.CreateNotification(context.Background(), notification)
-	return err
-}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/wcharczuk/lametric/pkg/apiutil"
+	"github.com/wcharczuk/lametric/pkg/health"
+	"github.com/wcharczuk/lametric/pkg/metrics"
+	"github.com/wcharczuk/lametric/pkg/monitor"
+)
+
+// Notification sources.
+const (
+	sourceCLI        = "cli"
+	sourceSounds     = "sounds"
+	sourceMQTT       = "mqtt"
+	sourceHealth     = "health"
+	sourceWebhook    = "webhook"
+	sourceSchedule   = "schedule"
+	sourceReminder   = "reminder"
+	sourceCalendar   = "calendar"
+	sourceFeed       = "feed"
+	sourceMonitor    = "monitor"
+	sourcePrometheus = "prometheus"
+)
+
+var (
+	registry = metrics.NewRegistry()
+
+	metricNotificationsSent = registry.NewCounter("notifier_notifications_sent_total",
+		"Notifications sent by device and source.", "device", "source")
+	metricNotificationsFailed = registry.NewCounter("notifier_notifications_failed_total",
+		"Notifications that failed to send by device and source.", "device", "source")
+	metricNotificationsDeferred = registry.NewCounter("notifier_notifications_deferred_total",
+		"Notifications not sent because the device circuit breaker was open, by device and source.", "device", "source")
+	metricRequestDuration = registry.NewHistogram("notifier_request_duration_seconds",
+		"Time to the response headers of api requests by host, method and status code.", nil, "host", "method", "code")
+	metricDeviceUp = registry.NewGauge("notifier_device_up",
+		"Whether the device was reachable and authorized when last checked.", "device")
+	metricDeviceLastSeen = registry.NewGauge("notifier_device_last_seen_timestamp_seconds",
+		"When the device was last reachable, as a unix timestamp.", "device")
+	metricBreakerState = registry.NewGauge("notifier_breaker_state",
+		"The circuit breaker state by host; 0 is closed, 1 open and 2 half-open.", "host")
+	metricBreakerTransitions = registry.NewCounter("notifier_breaker_transitions_total",
+		"Circuit breaker state changes by host and the state changed to.", "host", "state")
+	metricDeviceQueueDepth = registry.NewGauge("notifier_device_queue_depth",
+		"Notifications in the device queue when last checked.", "device")
+	metricMonitorUp = registry.NewGauge("notifier_monitor_up",
+		"Whether the monitor target passed its last check.", "monitor")
+	metricMonitorLatency = registry.NewGauge("notifier_monitor_latency_seconds",
+		"The latency of the last monitor check.", "monitor")
+	metricMonitorCertExpiry = registry.NewGauge("notifier_monitor_cert_expiry_timestamp_seconds",
+		"When the monitor target certificate expires, as a unix timestamp.", "monitor")
+)
+
+// observeRequest records the latency of an api request.
+func observeRequest(req *http.Request, res *http.Response, err error, elapsed time.Duration) {
+	code := "error"
+	if res != nil {
+		code = strconv.Itoa(res.StatusCode)
+	}
+	metricRequestDuration.Observe(elapsed.Seconds(), req.URL.Host, req.Method, code)
+}
+
+// observeBreaker records a circuit breaker state change.
+func observeBreaker(host string, state apiutil.BreakerState) {
+	metricBreakerState.Set(float64(state), host)
+	metricBreakerTransitions.Inc(host, state.String())
+}
+
+// observeStatus records the result of a device health check.
+func observeStatus(status health.Status) {
+	if status.Up() {
+		metricDeviceUp.Set(1, status.Name)
+		metricDeviceQueueDepth.Set(float64(status.QueueDepth), status.Name)
+	} else {
+		metricDeviceUp.Set(0, status.Name)
+	}
+	if !status.LastSeen.IsZero() {
+		metricDeviceLastSeen.Set(float64(status.LastSeen.Unix()), status.Name)
+	}
+}
+
+// observeMonitor records the result of a monitor check.
+func observeMonitor(status monitor.Status) {
+	if status.Up {
+		metricMonitorUp.Set(1, status.Name)
+	} else {
+		metricMonitorUp.Set(0, status.Name)
+	}
+	metricMonitorLatency.Set(status.Latency.Seconds(), status.Name)
+	if !status.CertExpires.IsZero() {
+		metricMonitorCertExpiry.Set(float64(status.CertExpires.Unix()), status.Name)
+	}
+}
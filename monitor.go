@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/wcharczuk/lametric/pkg/apiutil"
+	"github.com/wcharczuk/lametric/pkg/config"
+	"github.com/wcharczuk/lametric/pkg/lametric"
+	"github.com/wcharczuk/lametric/pkg/monitor"
+	"github.com/wcharczuk/lametric/pkg/notify"
+	"github.com/wcharczuk/lametric/pkg/trace"
+)
+
+// monitorEvent is the template data for a monitor state change.
+type monitorEvent struct {
+	Name string
+	// Event is `down`, `up` or `cert expiring`.
+	Event  string
+	Target string
+	// Error is why the last check failed, if it did.
+	Error string
+	// Downtime is how long the target was down, for up events.
+	Downtime    time.Duration
+	CertExpires time.Time
+}
+
+// newMonitor returns a monitor for the configured monitors that records
+// metrics and sends their state changes to their devices.
+//
+// State changes are sent in order on a given queue, rather than on the
+// goroutines that run the checks.
+func newMonitor(cfg config.Config, events eventQueue) *monitor.Monitor {
+	monitors := make(map[string]config.Monitor)
+	m := &monitor.Monitor{
+		OnStatus: observeMonitor,
+		OnEvent: func(ctx context.Context, event monitor.Event) {
+			mc := monitors[event.Status.Name]
+			events.Push(ctx, func() { routeMonitorEvent(ctx, cfg, mc, event) })
+		},
+	}
+	for _, mc := range cfg.Monitors {
+		monitors[mc.Name] = mc
+		check := monitor.Check{
+			Name:             mc.Name,
+			Interval:         mc.Interval,
+			Timeout:          mc.Timeout,
+			FailureThreshold: mc.FailureThreshold,
+		}
+		if mc.TCP != "" {
+			check.Probe = monitor.TCPProbe{Addr: mc.TCP}
+		} else {
+			check.CertExpiry = mc.CertExpiry()
+			check.Probe = monitor.HTTPProbe{
+				// the status is checked by the probe rather than the response filter.
+				Client: apiutil.New(mc.URL,
+					apiutil.OptResponseFilter(nil),
+					apiutil.OptMiddleware(apiutil.UserAgent("notifier"), trace.Middleware),
+				),
+				Method:     mc.Method,
+				Statuses:   mc.Status,
+				Body:       mc.Body,
+				MaxLatency: mc.MaxLatency,
+			}
+		}
+		m.Checks = append(m.Checks, check)
+	}
+	return m
+}
+
+// routeMonitorEvent sends a monitor state change to the monitor devices.
+func routeMonitorEvent(ctx context.Context, cfg config.Config, mc config.Monitor, event monitor.Event) {
+	if event.Status.Err != nil {
+		log.Printf("monitor; %s %s; %v", event.Status.Name, event.Kind, event.Status.Err)
+	} else {
+		log.Printf("monitor; %s %s", event.Status.Name, event.Kind)
+	}
+	ctx, span := trace.Start(ctx, "monitor.event", trace.KindInternal)
+	span.SetAttribute("monitor", mc.Name)
+	span.SetAttribute("event", string(event.Kind))
+	notification, err := monitorNotification(cfg, mc, event)
+	if err != nil {
+		log.Printf("monitor; %s %s; %v", event.Status.Name, event.Kind, err)
+		span.Finish(err)
+		return
+	}
+	// names are checked when the config is validated.
+	devices, _ := cfg.SelectDevices(mc.Devices)
+	results := broadcast(ctx, sourceMonitor, devices, notification)
+	span.Finish(results.Err())
+	for _, result := range results {
+		if result.Err != nil {
+			log.Printf("monitor; %s %s; %s; %v", event.Status.Name, event.Kind, result.Device.Label(), result.Err)
+		}
+	}
+}
+
+// monitorNotification returns the notification for a monitor state change,
+// rendered with the monitor template if it has one.
+func monitorNotification(cfg config.Config, mc config.Monitor, event monitor.Event) (lametric.Notification, error) {
+	data := monitorEvent{
+		Name:        event.Status.Name,
+		Event:       string(event.Kind),
+		Target:      event.Status.Target,
+		Downtime:    event.Downtime.Round(time.Second),
+		CertExpires: event.Status.CertExpires,
+	}
+	if event.Status.Err != nil {
+		data.Error = event.Status.Err.Error()
+	}
+	if mc.Template != "" {
+		return notify.Render(cfg.Templates[mc.Template], data)
+	}
+	notification := lametric.Notification{
+		Priority: lametric.NotificationPriorityCritical,
+		Model: lametric.NotificationModel{
+			Frames: []lametric.Frame{{Icon: lametric.IconAttention, Text: data.Name + " down"}},
+		},
+	}
+	switch event.Kind {
+	case monitor.EventDown:
+		if data.Error != "" {
+			notification.Model.Frames = append(notification.Model.Frames, lametric.Frame{Text: data.Error})
+		}
+	case monitor.EventUp:
+		notification.Priority = lametric.NotificationPriorityInfo
+		notification.Model.Frames = []lametric.Frame{{Icon: lametric.IconSmile, Text: fmt.Sprintf("%s up after %v", data.Name, data.Downtime)}}
+	case monitor.EventCertExpiring:
+		days := int(math.Floor(time.Until(data.CertExpires).Hours() / 24))
+		notification.Priority = lametric.NotificationPriorityWarning
+		notification.Model.Frames = []lametric.Frame{{Icon: lametric.IconAttention, Text: fmt.Sprintf("%s cert expires in %d days", data.Name, days)}}
+	}
+	return notification, nil
+}
+
+// monitors checks the configured monitors once and prints their status.
+func monitors(cfg config.Config, args []string) error {
+	fs := flag.NewFlagSet("monitor", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if len(cfg.Monitors) == 0 {
+		return fmt.Errorf("monitor; no monitors configured")
+	}
+	m := newMonitor(cfg, nil)
+	// only the status is printed; state changes aren't sent.
+	m.OnEvent = nil
+	statuses := m.Check(context.Background())
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tTARGET\tSTATUS\tLATENCY\tCERT EXPIRES\tERROR")
+	for _, status := range statuses {
+		state := "up"
+		if !status.Up {
+			state = "down"
+		}
+		certExpires := "-"
+		if !status.CertExpires.IsZero() {
+			certExpires = status.CertExpires.Local().Format("2006-01-02")
+		}
+		var errText string
+		if status.Err != nil {
+			errText = status.Err.Error()
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%v\t%s\t%s\n", status.Name, status.Target, state, status.Latency.Round(time.Millisecond), certExpires, orDash(errText))
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/wcharczuk/lametric/pkg/config"
+	"github.com/wcharczuk/lametric/pkg/lametric"
+	"github.com/wcharczuk/lametric/pkg/mqtt"
+	"github.com/wcharczuk/lametric/pkg/notify"
+	"github.com/wcharczuk/lametric/pkg/trace"
+)
+
+// mqttMessage is the template data for a received mqtt message.
+type mqttMessage struct {
+	Topic   string
+	Levels  []string
+	Payload string
+	JSON    interface{}
+}
+
+// mqttDeviceState is the retained state published for each device.
+type mqttDeviceState struct {
+	Reachable    bool       `json:"reachable"`
+	LastDelivery *time.Time `json:"lastDelivery,omitempty"`
+	LastError    string     `json:"lastError,omitempty"`
+	// Brightness, Volume and Mode are the device settings, published with discovery.
+	Brightness *int                    `json:"brightness,omitempty"`
+	Volume     *int                    `json:"volume,omitempty"`
+	Mode       lametric.BrightnessMode `json:"mode,omitempty"`
+}
+
+// mqttResult is published for each delivery.
+type mqttResult struct {
+	Device string    `json:"device"`
+	Topic  string    `json:"topic"`
+	OK     bool      `json:"ok"`
+	Error  string    `json:"error,omitempty"`
+	Time   time.Time `json:"time"`
+}
+
+// mqttBridge subscribes to the configured mqtt topics and forwards
+// messages to devices until interrupted.
+func mqttBridge(cfg config.Config) error {
+	if cfg.MQTT.Broker == "" {
+		return fmt.Errorf("mqtt; broker is required")
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return runMQTTBridge(ctx, cfg)
+}
+
+// runMQTTBridge runs the mqtt bridge until the context is done.
+func runMQTTBridge(ctx context.Context, cfg config.Config) error {
+	bridge := &mqttBridgeState{cfg: cfg, states: make(map[string]mqttDeviceState)}
+	opts := mqtt.Options{
+		Broker:       cfg.MQTT.Broker,
+		ClientID:     cfg.MQTT.ClientID,
+		Username:     cfg.MQTT.Username,
+		Password:     cfg.MQTT.Password,
+		KeepAlive:    cfg.MQTT.KeepAlive,
+		CleanSession: true,
+		OnConnect: func(client *mqtt.Client) {
+			log.Printf("mqtt; connected to %s", cfg.MQTT.Broker)
+			if cfg.MQTT.StateTopic != "" {
+				go bridge.publish(ctx, client, cfg.MQTT.StateTopic+"/status", true, []byte("online"))
+			}
+			if cfg.MQTT.Discovery.Enabled {
+				go bridge.announce(ctx, client)
+			}
+		},
+		OnConnectionLost: func(err error) {
+			log.Printf("mqtt; connection lost: %v", err)
+		},
+		OnDropped: func(m mqtt.Message) {
+			log.Printf("mqtt; %s; dropped, too many messages waiting to be sent", m.Topic)
+		},
+	}
+	if opts.ClientID == "" {
+		hostname, _ := os.Hostname()
+		opts.ClientID = "notifier-" + hostname
+	}
+	if cfg.MQTT.StateTopic != "" {
+		opts.Will = &mqtt.Message{
+			Topic:    cfg.MQTT.StateTopic + "/status",
+			Payload:  []byte("offline"),
+			QoS:      cfg.MQTT.QoS,
+			Retained: true,
+		}
+	}
+	client := mqtt.New(opts)
+	for _, sub := range cfg.MQTT.Subscriptions {
+		sub := sub
+		if err := client.Subscribe(ctx, sub.Topic, sub.QoS, func(m mqtt.Message) {
+			bridge.forward(ctx, client, sub, m)
+		}); err != nil {
+			return fmt.Errorf("mqtt subscription %s; %w", sub.Topic, err)
+		}
+	}
+	if cfg.MQTT.Discovery.Enabled {
+		if err := bridge.discovery(ctx, client); err != nil {
+			return err
+		}
+	}
+	if err := client.Run(ctx); err != nil && ctx.Err() == nil {
+		return err
+	}
+	return nil
+}
+
+// mqttBridgeState holds the delivery state of each device.
+type mqttBridgeState struct {
+	cfg    config.Config
+	mu     sync.Mutex
+	states map[string]mqttDeviceState
+}
+
+// forward renders a received message and broadcasts it to the subscription devices.
+func (b *mqttBridgeState) forward(ctx context.Context, client *mqtt.Client, sub config.MQTTSubscription, m mqtt.Message) {
+	ctx, span := trace.Start(ctx, "mqtt.receive", trace.KindConsumer)
+	span.SetAttribute("topic", m.Topic)
+	notification, err := mqttNotification(b.cfg, sub, m)
+	if err != nil {
+		log.Printf("mqtt; %s; %v", m.Topic, err)
+		span.Finish(err)
+		return
+	}
+	devices, err := b.cfg.SelectDevices(sub.Devices)
+	if err != nil {
+		log.Printf("mqtt; %s; %v", m.Topic, err)
+		span.Finish(err)
+		return
+	}
+	results := broadcast(ctx, sourceMQTT, devices, notification)
+	span.Finish(results.Err())
+	for _, result := range results {
+		now := time.Now().UTC()
+		label := result.Device.Label()
+		if result.Err != nil {
+			log.Printf("mqtt; %s; %s; %v", m.Topic, label, result.Err)
+		}
+		if b.cfg.MQTT.ResultsTopic != "" {
+			r := mqttResult{Device: label, Topic: m.Topic, OK: result.Err == nil, Time: now}
+			if result.Err != nil {
+				r.Error = result.Err.Error()
+			}
+			b.publishJSON(ctx, client, b.cfg.MQTT.ResultsTopic, false, r)
+		}
+		if b.cfg.MQTT.StateTopic != "" {
+			b.publishJSON(ctx, client, b.stateTopic(result.Device), true, b.update(label, now, result.Err))
+		}
+	}
+}
+
+// update records a delivery result for a device and returns its new state.
+func (b *mqttBridgeState) update(label string, now time.Time, err error) mqttDeviceState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	state := b.states[label]
+	state.Reachable = err == nil
+	if err != nil {
+		state.LastError = err.Error()
+	} else {
+		state.LastDelivery = &now
+		state.LastError = ""
+	}
+	b.states[label] = state
+	return state
+}
+
+// updateSettings records the settings of a device and returns its new state.
+func (b *mqttBridgeState) updateSettings(label string, display *lametric.Display, audio *lametric.Audio, err error) mqttDeviceState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	state := b.states[label]
+	state.Reachable = err == nil
+	if err != nil {
+		state.LastError = err.Error()
+	}
+	if display != nil {
+		brightness := display.Brightness
+		state.Brightness, state.Mode = &brightness, display.BrightnessMode
+	}
+	if audio != nil {
+		volume := audio.Volume
+		state.Volume = &volume
+	}
+	b.states[label] = state
+	return state
+}
+
+func (b *mqttBridgeState) publishJSON(ctx context.Context, client *mqtt.Client, topic string, retain bool, v interface{}) {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		log.Printf("mqtt; %s; %v", topic, err)
+		return
+	}
+	b.publish(ctx, client, topic, retain, payload)
+}
+
+func (b *mqttBridgeState) publish(ctx context.Context, client *mqtt.Client, topic string, retain bool, payload []byte) {
+	if err := client.Publish(ctx, topic, b.cfg.MQTT.QoS, retain, payload); err != nil && ctx.Err() == nil {
+		log.Printf("mqtt; publish %s; %v", topic, err)
+	}
+}
+
+// mqttNotification returns the notification for a received message, rendered with
+// the subscription template, or the payload as the text of a single frame.
+func mqttNotification(cfg config.Config, sub config.MQTTSubscription, m mqtt.Message) (lametric.Notification, error) {
+	payload := strings.TrimSpace(string(m.Payload))
+	if sub.Template == "" {
+		return lametric.Notification{
+			Model: lametric.NotificationModel{
+				Frames: []lametric.Frame{{Text: payload}},
+			},
+		}, nil
+	}
+	data := mqttMessage{
+		Topic:   m.Topic,
+		Levels:  strings.Split(m.Topic, "/"),
+		Payload: payload,
+	}
+	// the payload is decoded if it's json, otherwise `.JSON` is left empty.
+	_ = json.Unmarshal(m.Payload, &data.JSON)
+	return notify.Render(cfg.Templates[sub.Template], data)
+}
+
+// mqttTopicLevel returns a given name as a single topic level.
+func mqttTopicLevel(name string) string {
+	return strings.NewReplacer("/", "_", "+", "_", "#", "_").Replace(name)
+}
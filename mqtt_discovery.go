@@ -0,0 +1,255 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wcharczuk/lametric/pkg/config"
+	"github.com/wcharczuk/lametric/pkg/lametric"
+	"github.com/wcharczuk/lametric/pkg/mqtt"
+)
+
+// haDevice is the home assistant device an entity belongs to.
+type haDevice struct {
+	Identifiers  []string `json:"identifiers"`
+	Name         string   `json:"name"`
+	Manufacturer string   `json:"manufacturer"`
+}
+
+// haAvailability is a home assistant availability topic.
+type haAvailability struct {
+	Topic         string `json:"topic"`
+	ValueTemplate string `json:"value_template,omitempty"`
+}
+
+// haEntity is the home assistant discovery config of an entity.
+type haEntity struct {
+	Name             string           `json:"name"`
+	UniqueID         string           `json:"unique_id"`
+	CommandTopic     string           `json:"command_topic"`
+	StateTopic       string           `json:"state_topic,omitempty"`
+	ValueTemplate    string           `json:"value_template,omitempty"`
+	Min              *int             `json:"min,omitempty"`
+	Max              *int             `json:"max,omitempty"`
+	Options          []string         `json:"options,omitempty"`
+	Icon             string           `json:"icon,omitempty"`
+	Availability     []haAvailability `json:"availability"`
+	AvailabilityMode string           `json:"availability_mode"`
+	Device           haDevice         `json:"device"`
+}
+
+// Discovery entities.
+const (
+	entityBrightness = "brightness"
+	entityVolume     = "volume"
+	entityMode       = "mode"
+	entityNotify     = "notify"
+)
+
+// discovery subscribes to the entity command topics of each lametric device, and
+// to the home assistant status so discovery is re-announced when it restarts,
+// and starts publishing the device state periodically.
+func (b *mqttBridgeState) discovery(ctx context.Context, client *mqtt.Client) error {
+	prefix := b.cfg.MQTT.Discovery.PrefixOrDefault()
+	if err := client.Subscribe(ctx, prefix+"/status", 0, func(m mqtt.Message) {
+		if string(m.Payload) == "online" {
+			b.announce(ctx, client)
+		}
+	}); err != nil {
+		return err
+	}
+	for _, device := range b.discoveryDevices() {
+		device := device
+		for _, entity := range []string{entityBrightness, entityVolume, entityMode, entityNotify} {
+			entity := entity
+			if err := client.Subscribe(ctx, b.entityTopic(device, entity)+"/set", 0, func(m mqtt.Message) {
+				b.command(ctx, client, device, entity, strings.TrimSpace(string(m.Payload)))
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	go func() {
+		ticker := time.NewTicker(b.cfg.MQTT.Discovery.StatePeriodOrDefault())
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if client.Connected() {
+					b.publishSettings(ctx, client)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// announce publishes the discovery config of each entity and the current device state.
+func (b *mqttBridgeState) announce(ctx context.Context, client *mqtt.Client) {
+	prefix := b.cfg.MQTT.Discovery.PrefixOrDefault()
+	for _, device := range b.discoveryDevices() {
+		id := discoveryID(device)
+		entities := []struct {
+			component, name string
+			config          haEntity
+		}{
+			{"number", entityBrightness, b.levelEntity(device, entityBrightness, "Brightness", "mdi:brightness-6")},
+			{"number", entityVolume, b.levelEntity(device, entityVolume, "Volume", "mdi:volume-high")},
+			{"select", entityMode, b.entity(device, entityMode, "Brightness mode", "mdi:brightness-auto", func(e *haEntity) {
+				e.StateTopic = b.stateTopic(device)
+				e.ValueTemplate = "{{ value_json.mode }}"
+				for _, mode := range lametric.AllBrightnessModes() {
+					e.Options = append(e.Options, string(mode))
+				}
+			})},
+			{"text", entityNotify, b.entity(device, entityNotify, "Notify", "mdi:message-text", nil)},
+		}
+		for _, entity := range entities {
+			topic := fmt.Sprintf("%s/%s/%s/%s/config", prefix, entity.component, id, entity.name)
+			b.publishJSON(ctx, client, topic, true, entity.config)
+		}
+	}
+	b.publishSettings(ctx, client)
+}
+
+// publishSettings polls and publishes the settings of each device.
+func (b *mqttBridgeState) publishSettings(ctx context.Context, client *mqtt.Client) {
+	for _, device := range b.discoveryDevices() {
+		b.publishJSON(ctx, client, b.stateTopic(device), true, b.pollSettings(ctx, device))
+	}
+}
+
+// pollSettings reads the display and audio settings of a device.
+func (b *mqttBridgeState) pollSettings(ctx context.Context, device config.Device) mqttDeviceState {
+	client := newClient(device)
+	display, err := client.GetDisplay(ctx)
+	if err != nil {
+		log.Printf("mqtt; %s; %v", device.Label(), err)
+		return b.updateSettings(device.Label(), nil, nil, err)
+	}
+	audio, err := client.GetAudio(ctx)
+	if err != nil {
+		log.Printf("mqtt; %s; %v", device.Label(), err)
+	}
+	return b.updateSettings(device.Label(), display, audio, err)
+}
+
+// command applies an entity command to a device and publishes its new state.
+func (b *mqttBridgeState) command(ctx context.Context, client *mqtt.Client, device config.Device, entity, payload string) {
+	var err error
+	switch entity {
+	case entityBrightness:
+		var brightness int
+		if brightness, err = parseLevel(payload); err == nil {
+			// brightness is only applied in manual mode
+			_, err = newClient(device).UpdateDisplay(ctx, lametric.UpdateDisplayInput{
+				Brightness:     &brightness,
+				BrightnessMode: lametric.BrightnessModeManual,
+			})
+		}
+	case entityVolume:
+		var volume int
+		if volume, err = parseLevel(payload); err == nil {
+			_, err = newClient(device).UpdateAudio(ctx, volume)
+		}
+	case entityMode:
+		var mode lametric.BrightnessMode
+		if mode, err = lametric.ParseBrightnessMode(payload); err == nil {
+			_, err = newClient(device).UpdateDisplay(ctx, lametric.UpdateDisplayInput{BrightnessMode: mode})
+		}
+	case entityNotify:
+		err = deliver(ctx, sourceMQTT, device, lametric.Notification{
+			Model: lametric.NotificationModel{
+				Frames: []lametric.Frame{{Text: payload}},
+			},
+		})
+		b.update(device.Label(), time.Now().UTC(), err)
+	}
+	if err != nil {
+		log.Printf("mqtt; %s; %s %q; %v", device.Label(), entity, payload, err)
+	}
+	b.publishJSON(ctx, client, b.stateTopic(device), true, b.pollSettings(ctx, device))
+}
+
+// discoveryDevices returns the lametric devices.
+func (b *mqttBridgeState) discoveryDevices() (output []config.Device) {
+	for _, device := range b.cfg.Devices {
+		if device.BackendOrDefault() == config.BackendLaMetric {
+			output = append(output, device)
+		}
+	}
+	return
+}
+
+func (b *mqttBridgeState) stateTopic(device config.Device) string {
+	return b.cfg.MQTT.StateTopic + "/" + mqttTopicLevel(device.Label())
+}
+
+func (b *mqttBridgeState) entityTopic(device config.Device, entity string) string {
+	return b.stateTopic(device) + "/" + entity
+}
+
+// entity returns the discovery config of an entity, available when both
+// the bridge and the device are.
+func (b *mqttBridgeState) entity(device config.Device, entity, name, icon string, configure func(*haEntity)) haEntity {
+	id := discoveryID(device)
+	e := haEntity{
+		Name:         name,
+		UniqueID:     id + "_" + entity,
+		CommandTopic: b.entityTopic(device, entity) + "/set",
+		Icon:         icon,
+		Availability: []haAvailability{
+			{Topic: b.cfg.MQTT.StateTopic + "/status"},
+			{Topic: b.stateTopic(device), ValueTemplate: "{{ 'online' if value_json.reachable else 'offline' }}"},
+		},
+		AvailabilityMode: "all",
+		Device: haDevice{
+			Identifiers:  []string{id},
+			Name:         device.Label(),
+			Manufacturer: "LaMetric",
+		},
+	}
+	if configure != nil {
+		configure(&e)
+	}
+	return e
+}
+
+// levelEntity returns the discovery config of a 0 to 100 number entity.
+func (b *mqttBridgeState) levelEntity(device config.Device, entity, name, icon string) haEntity {
+	return b.entity(device, entity, name, icon, func(e *haEntity) {
+		min, max := 0, 100
+		e.Min, e.Max = &min, &max
+		e.StateTopic = b.stateTopic(device)
+		e.ValueTemplate = fmt.Sprintf("{{ value_json.%s }}", entity)
+	})
+}
+
+// discoveryID returns a stable id for a device, its serial if it's known.
+func discoveryID(device config.Device) string {
+	name := device.Serial
+	if name == "" {
+		name = device.Label()
+	}
+	return "lametric_" + strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// parseLevel parses a 0 to 100 level; home assistant may send numbers as floats.
+func parseLevel(value string) (int, error) {
+	level, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, err
+	}
+	return int(level), nil
+}
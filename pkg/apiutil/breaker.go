@@ -0,0 +1,214 @@
+package apiutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Breaker defaults.
+const (
+	DefaultBreakerFailureThreshold = 5
+	DefaultBreakerCoolDown         = 30 * time.Second
+)
+
+// BreakerState is the state of a circuit breaker.
+type BreakerState int
+
+// Breaker states.
+const (
+	// BreakerClosed lets requests through.
+	BreakerClosed BreakerState = iota
+	// BreakerOpen fails requests fast until the cool down has passed.
+	BreakerOpen
+	// BreakerHalfOpen lets a single trial request through, which closes
+	// the breaker if it succeeds or opens it again if it fails.
+	BreakerHalfOpen
+)
+
+// String implements fmt.Stringer.
+func (bs BreakerState) String() string {
+	switch bs {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprintf("BreakerState(%d)", int(bs))
+	}
+}
+
+// BreakerOpenError is returned for requests that fail fast because the
+// breaker for their host is open.
+//
+// The request was never sent, so callers that retry should defer it
+// until `RetryAt` rather than count it as an attempt.
+type BreakerOpenError struct {
+	Host    string
+	RetryAt time.Time
+}
+
+// Error implements error.
+func (boe *BreakerOpenError) Error() string {
+	return fmt.Sprintf("%v; %s; retry at %s", ErrBreakerOpen, boe.Host, boe.RetryAt.Format(time.RFC3339))
+}
+
+// Unwrap returns `ErrBreakerOpen`.
+func (boe *BreakerOpenError) Unwrap() error { return ErrBreakerOpen }
+
+// Breaker is a circuit breaker per request host, used as a middleware.
+//
+// It's shared by the clients it's added to, so all requests to a host count
+// towards the host's breaker.
+type Breaker struct {
+	// FailureThreshold is the number of consecutive failures that opens
+	// the breaker; defaults to `DefaultBreakerFailureThreshold`.
+	FailureThreshold int
+	// CoolDown is how long the breaker stays open before a trial request;
+	// defaults to `DefaultBreakerCoolDown`.
+	CoolDown time.Duration
+	// IsFailure returns if a request failed; defaults to errors (other than
+	// the request context being canceled) and 5xx status codes.
+	IsFailure func(*http.Response, error) bool
+	// OnStateChange is called when the breaker for a host changes state.
+	OnStateChange func(host string, from, to BreakerState)
+
+	mu    sync.Mutex
+	hosts map[string]*breakerHost
+}
+
+type breakerHost struct {
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	trial    bool
+}
+
+// Middleware returns the breaker as a middleware.
+func (b *Breaker) Middleware() Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			host := req.URL.Host
+			if err := b.allow(host); err != nil {
+				return nil, err
+			}
+			res, err := next.RoundTrip(req)
+			if errors.Is(err, context.Canceled) {
+				b.release(host)
+				return res, err
+			}
+			b.record(host, b.isFailure(res, err))
+			return res, err
+		})
+	}
+}
+
+// State returns the state of the breaker for a host.
+func (b *Breaker) State(host string) BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if h, ok := b.hosts[host]; ok {
+		return h.state
+	}
+	return BreakerClosed
+}
+
+// allow returns an error if a request to a host should fail fast.
+func (b *Breaker) allow(host string) error {
+	b.mu.Lock()
+	h := b.host(host)
+	var changed bool
+	switch h.state {
+	case BreakerOpen:
+		retryAt := h.openedAt.Add(b.coolDown())
+		if time.Now().Before(retryAt) {
+			b.mu.Unlock()
+			return &BreakerOpenError{Host: host, RetryAt: retryAt}
+		}
+		h.state, h.trial, changed = BreakerHalfOpen, true, true
+	case BreakerHalfOpen:
+		if h.trial {
+			// only one trial request at a time
+			b.mu.Unlock()
+			return &BreakerOpenError{Host: host, RetryAt: time.Now().Add(b.coolDown())}
+		}
+		h.trial = true
+	}
+	b.mu.Unlock()
+	if changed {
+		b.changed(host, BreakerOpen, BreakerHalfOpen)
+	}
+	return nil
+}
+
+// record records the outcome of a request to a host.
+func (b *Breaker) record(host string, failed bool) {
+	b.mu.Lock()
+	h := b.host(host)
+	from := h.state
+	h.trial = false
+	if failed {
+		h.failures++
+		if h.state == BreakerHalfOpen || (h.state == BreakerClosed && h.failures >= b.failureThreshold()) {
+			h.state, h.openedAt = BreakerOpen, time.Now()
+		}
+	} else {
+		h.state, h.failures = BreakerClosed, 0
+	}
+	to := h.state
+	b.mu.Unlock()
+	if from != to {
+		b.changed(host, from, to)
+	}
+}
+
+// release lets another trial request through if a trial request was canceled.
+func (b *Breaker) release(host string) {
+	b.mu.Lock()
+	b.host(host).trial = false
+	b.mu.Unlock()
+}
+
+func (b *Breaker) host(host string) *breakerHost {
+	if b.hosts == nil {
+		b.hosts = make(map[string]*breakerHost)
+	}
+	h, ok := b.hosts[host]
+	if !ok {
+		h = new(breakerHost)
+		b.hosts[host] = h
+	}
+	return h
+}
+
+func (b *Breaker) changed(host string, from, to BreakerState) {
+	if b.OnStateChange != nil {
+		b.OnStateChange(host, from, to)
+	}
+}
+
+func (b *Breaker) isFailure(res *http.Response, err error) bool {
+	if b.IsFailure != nil {
+		return b.IsFailure(res, err)
+	}
+	return err != nil || (res != nil && res.StatusCode >= http.StatusInternalServerError)
+}
+
+func (b *Breaker) failureThreshold() int {
+	if b.FailureThreshold > 0 {
+		return b.FailureThreshold
+	}
+	return DefaultBreakerFailureThreshold
+}
+
+func (b *Breaker) coolDown() time.Duration {
+	if b.CoolDown > 0 {
+		return b.CoolDown
+	}
+	return DefaultBreakerCoolDown
+}
@@ -0,0 +1,216 @@
+package apiutil
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// breakerTransport is a transport whose responses the test controls.
+type breakerTransport struct {
+	mu   sync.Mutex
+	err  error
+	sent int
+	// block, if set, holds requests until it's closed or their context is done.
+	block chan struct{}
+}
+
+func (bt *breakerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	bt.mu.Lock()
+	bt.sent++
+	err, block := bt.err, bt.block
+	bt.mu.Unlock()
+	if block != nil {
+		select {
+		case <-block:
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+}
+
+func (bt *breakerTransport) set(err error, block chan struct{}) {
+	bt.mu.Lock()
+	bt.err, bt.block = err, block
+	bt.mu.Unlock()
+}
+
+func (bt *breakerTransport) count() int {
+	bt.mu.Lock()
+	defer bt.mu.Unlock()
+	return bt.sent
+}
+
+type breakerChange struct {
+	From, To BreakerState
+}
+
+func newTestBreaker() (*Breaker, http.RoundTripper, *breakerTransport, *[]breakerChange) {
+	var mu sync.Mutex
+	changes := new([]breakerChange)
+	breaker := &Breaker{
+		FailureThreshold: 2,
+		CoolDown:         50 * time.Millisecond,
+		OnStateChange: func(host string, from, to BreakerState) {
+			mu.Lock()
+			*changes = append(*changes, breakerChange{from, to})
+			mu.Unlock()
+		},
+	}
+	transport := new(breakerTransport)
+	return breaker, breaker.Middleware()(transport), transport, changes
+}
+
+func roundTrip(ctx context.Context, rt http.RoundTripper) error {
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "https://kitchen:4343/api/v2/device", nil)
+	_, err := rt.RoundTrip(req)
+	return err
+}
+
+func TestBreaker(t *testing.T) {
+	breaker, rt, transport, changes := newTestBreaker()
+	ctx := context.Background()
+	transport.set(errors.New("connection refused"), nil)
+
+	// failures under the threshold leave the breaker closed.
+	_ = roundTrip(ctx, rt)
+	if state := breaker.State("kitchen:4343"); state != BreakerClosed {
+		t.Fatalf("expected closed, got %v", state)
+	}
+	_ = roundTrip(ctx, rt)
+	if state := breaker.State("kitchen:4343"); state != BreakerOpen {
+		t.Fatalf("expected open, got %v", state)
+	}
+
+	// requests fail fast while it's open.
+	err := roundTrip(ctx, rt)
+	var breakerOpen *BreakerOpenError
+	if !errors.As(err, &breakerOpen) || !errors.Is(err, ErrBreakerOpen) {
+		t.Fatalf("expected a breaker open error, got %v", err)
+	}
+	if breakerOpen.Host != "kitchen:4343" || time.Until(breakerOpen.RetryAt) <= 0 {
+		t.Errorf("unexpected error %+v", breakerOpen)
+	}
+	if sent := transport.count(); sent != 2 {
+		t.Errorf("expected 2 requests to be sent, got %d", sent)
+	}
+	if state := breaker.State("other:4343"); state != BreakerClosed {
+		t.Errorf("expected other hosts to be closed, got %v", state)
+	}
+
+	// after the cool down a failed trial opens it again.
+	time.Sleep(60 * time.Millisecond)
+	_ = roundTrip(ctx, rt)
+	if state := breaker.State("kitchen:4343"); state != BreakerOpen {
+		t.Fatalf("expected open, got %v", state)
+	}
+
+	// and a successful trial closes it.
+	time.Sleep(60 * time.Millisecond)
+	transport.set(nil, nil)
+	if err := roundTrip(ctx, rt); err != nil {
+		t.Fatal(err)
+	}
+	if state := breaker.State("kitchen:4343"); state != BreakerClosed {
+		t.Fatalf("expected closed, got %v", state)
+	}
+
+	expect := []breakerChange{
+		{BreakerClosed, BreakerOpen},
+		{BreakerOpen, BreakerHalfOpen},
+		{BreakerHalfOpen, BreakerOpen},
+		{BreakerOpen, BreakerHalfOpen},
+		{BreakerHalfOpen, BreakerClosed},
+	}
+	if len(*changes) != len(expect) {
+		t.Fatalf("expected changes %v, got %v", expect, *changes)
+	}
+	for index := range expect {
+		if (*changes)[index] != expect[index] {
+			t.Errorf("expected changes %v, got %v", expect, *changes)
+			break
+		}
+	}
+}
+
+func TestBreakerSingleTrial(t *testing.T) {
+	breaker, rt, transport, _ := newTestBreaker()
+	ctx := context.Background()
+	transport.set(errors.New("connection refused"), nil)
+	_ = roundTrip(ctx, rt)
+	_ = roundTrip(ctx, rt)
+	time.Sleep(60 * time.Millisecond)
+
+	// the trial request is held so that others arrive while it's in flight.
+	block := make(chan struct{})
+	transport.set(nil, block)
+	trial := make(chan error, 1)
+	go func() { trial <- roundTrip(ctx, rt) }()
+	deadline := time.Now().Add(time.Second)
+	for transport.count() < 3 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if state := breaker.State("kitchen:4343"); state != BreakerHalfOpen {
+		t.Fatalf("expected half-open, got %v", state)
+	}
+	for index := 0; index < 3; index++ {
+		if err := roundTrip(ctx, rt); !errors.Is(err, ErrBreakerOpen) {
+			t.Errorf("expected requests during the trial to fail fast, got %v", err)
+		}
+	}
+	close(block)
+	if err := <-trial; err != nil {
+		t.Fatal(err)
+	}
+	if sent := transport.count(); sent != 3 {
+		t.Errorf("expected a single trial request, got %d requests", sent)
+	}
+	if state := breaker.State("kitchen:4343"); state != BreakerClosed {
+		t.Errorf("expected closed, got %v", state)
+	}
+}
+
+func TestBreakerCanceledTrial(t *testing.T) {
+	breaker, rt, transport, _ := newTestBreaker()
+	transport.set(errors.New("connection refused"), nil)
+	_ = roundTrip(context.Background(), rt)
+	_ = roundTrip(context.Background(), rt)
+	time.Sleep(60 * time.Millisecond)
+
+	// a canceled trial neither opens nor closes the breaker, and lets another trial through.
+	transport.set(nil, make(chan struct{}))
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	if err := roundTrip(ctx, rt); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected %v, got %v", context.Canceled, err)
+	}
+	if state := breaker.State("kitchen:4343"); state != BreakerHalfOpen {
+		t.Fatalf("expected half-open, got %v", state)
+	}
+	transport.set(nil, nil)
+	if err := roundTrip(context.Background(), rt); err != nil {
+		t.Fatalf("expected another trial to be let through, got %v", err)
+	}
+	if state := breaker.State("kitchen:4343"); state != BreakerClosed {
+		t.Errorf("expected closed, got %v", state)
+	}
+}
+
+func TestBreakerStatusFailures(t *testing.T) {
+	breaker := &Breaker{FailureThreshold: 1}
+	rt := breaker.Middleware()(RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusUnauthorized, Body: http.NoBody}, nil
+	}))
+	// 4xx responses mean the device is up.
+	_ = roundTrip(context.Background(), rt)
+	if state := breaker.State("kitchen:4343"); state != BreakerClosed {
+		t.Errorf("expected closed, got %v", state)
+	}
+}
@@ -0,0 +1,324 @@
+package apiutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CassetteMode is whether a recorder records or replays.
+type CassetteMode int
+
+// Cassette modes.
+const (
+	// CassetteReplay replays recorded responses and fails requests that weren't recorded.
+	CassetteReplay CassetteMode = iota
+	// CassetteRecord sends requests with the underlying transport and records them.
+	CassetteRecord
+)
+
+// DefaultScrubHeaders are the headers whose values are redacted in cassettes.
+var DefaultScrubHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// Cassette is a list of recorded request and response pairs.
+//
+// Cassettes are stored as yaml if their path ends in `.yml` or `.yaml`, and json otherwise.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions" yaml:"interactions"`
+}
+
+// Interaction is a recorded request and response.
+type Interaction struct {
+	Request  CassetteRequest  `json:"request" yaml:"request"`
+	Response CassetteResponse `json:"response" yaml:"response"`
+}
+
+// CassetteRequest is a recorded request.
+type CassetteRequest struct {
+	Method string      `json:"method" yaml:"method"`
+	URL    string      `json:"url" yaml:"url"`
+	Header http.Header `json:"header,omitempty" yaml:"header,omitempty"`
+	Body   string      `json:"body,omitempty" yaml:"body,omitempty"`
+}
+
+// CassetteResponse is a recorded response.
+type CassetteResponse struct {
+	StatusCode int         `json:"statusCode" yaml:"statusCode"`
+	Header     http.Header `json:"header,omitempty" yaml:"header,omitempty"`
+	Body       string      `json:"body,omitempty" yaml:"body,omitempty"`
+}
+
+// LoadCassette reads a cassette from a given path.
+func LoadCassette(path string) (*Cassette, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cassette Cassette
+	if isYAMLPath(path) {
+		err = yaml.Unmarshal(contents, &cassette)
+	} else {
+		err = json.Unmarshal(contents, &cassette)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w; %s; %v", ErrCassetteInvalid, path, err)
+	}
+	return &cassette, nil
+}
+
+// Save writes the cassette to a given path.
+func (c *Cassette) Save(path string) error {
+	buffer := new(bytes.Buffer)
+	if isYAMLPath(path) {
+		encoder := yaml.NewEncoder(buffer)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(c); err != nil {
+			return err
+		}
+	} else {
+		encoder := json.NewEncoder(buffer)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(c); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(path, buffer.Bytes(), 0644)
+}
+
+// Matcher returns if a request (with its body read) matches a recorded request.
+type Matcher func(req *http.Request, body []byte, recorded CassetteRequest) bool
+
+// MatchMethod matches requests by method.
+func MatchMethod(req *http.Request, _ []byte, recorded CassetteRequest) bool {
+	return req.Method == recorded.Method
+}
+
+// MatchHost matches requests by host.
+func MatchHost(req *http.Request, _ []byte, recorded CassetteRequest) bool {
+	return req.URL.Host == recordedURL(recorded).Host
+}
+
+// MatchPath matches requests by path and query.
+func MatchPath(req *http.Request, _ []byte, recorded CassetteRequest) bool {
+	return req.URL.RequestURI() == recordedURL(recorded).RequestURI()
+}
+
+// MatchBody matches requests by body.
+func MatchBody(_ *http.Request, body []byte, recorded CassetteRequest) bool {
+	return string(body) == recorded.Body
+}
+
+// DefaultMatchers match requests by method and path.
+var DefaultMatchers = []Matcher{MatchMethod, MatchPath}
+
+// NewRecorder returns a recorder for a cassette path; the cassette is loaded if it's replayed.
+func NewRecorder(path string, mode CassetteMode) (*Recorder, error) {
+	recorder := &Recorder{
+		Path:     path,
+		Mode:     mode,
+		cassette: new(Cassette),
+	}
+	if mode == CassetteReplay {
+		cassette, err := LoadCassette(path)
+		if err != nil {
+			return nil, err
+		}
+		recorder.cassette = cassette
+	}
+	return recorder, nil
+}
+
+// Recorder is a round tripper that records requests to a cassette, or replays them from one.
+//
+// Each recorded interaction is replayed at most once, in the order it was recorded.
+type Recorder struct {
+	Path string
+	Mode CassetteMode
+	// Matchers must all match for a recorded request to be replayed; defaults to `DefaultMatchers`.
+	Matchers []Matcher
+	// ScrubHeaders are the headers redacted when recording; defaults to `DefaultScrubHeaders`.
+	ScrubHeaders []string
+	// Transport sends requests when recording; defaults to `http.DefaultTransport`.
+	Transport http.RoundTripper
+
+	mu       sync.Mutex
+	cassette *Cassette
+	replayed map[int]bool
+}
+
+// Middleware returns the recorder as a middleware that records with the transport it wraps.
+func (r *Recorder) Middleware() Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			return r.roundTrip(req, next)
+		})
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	transport := r.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return r.roundTrip(req, transport)
+}
+
+func (r *Recorder) roundTrip(req *http.Request, transport http.RoundTripper) (*http.Response, error) {
+	sent, body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	if r.Mode == CassetteReplay {
+		return r.replay(req, body)
+	}
+	res, err := transport.RoundTrip(sent)
+	if err != nil {
+		return nil, err
+	}
+	resBody, err := io.ReadAll(res.Body)
+	_ = res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(resBody))
+
+	interaction := Interaction{
+		Request: CassetteRequest{
+			Method: req.Method,
+			URL:    req.URL.Redacted(),
+			Header: r.scrub(req.Header),
+			Body:   string(body),
+		},
+		Response: CassetteResponse{
+			StatusCode: res.StatusCode,
+			Header:     r.scrub(res.Header),
+			Body:       string(resBody),
+		},
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	if err := r.cassette.Save(r.Path); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (r *Recorder) replay(req *http.Request, body []byte) (*http.Response, error) {
+	matchers := r.Matchers
+	if matchers == nil {
+		matchers = DefaultMatchers
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.replayed == nil {
+		r.replayed = make(map[int]bool)
+	}
+	for index, interaction := range r.cassette.Interactions {
+		if r.replayed[index] || !matchesAll(matchers, req, body, interaction.Request) {
+			continue
+		}
+		r.replayed[index] = true
+		header := interaction.Response.Header.Clone()
+		if header == nil {
+			header = make(http.Header)
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Response.StatusCode, http.StatusText(interaction.Response.StatusCode)),
+			StatusCode:    interaction.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          io.NopCloser(strings.NewReader(interaction.Response.Body)),
+			ContentLength: int64(len(interaction.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("%w; %s %s", ErrCassetteNoMatch, req.Method, req.URL.Redacted())
+}
+
+// scrub returns a copy of a header with the scrubbed header values redacted.
+func (r *Recorder) scrub(header http.Header) http.Header {
+	if len(header) == 0 {
+		return nil
+	}
+	scrubHeaders := r.ScrubHeaders
+	if scrubHeaders == nil {
+		scrubHeaders = DefaultScrubHeaders
+	}
+	output := header.Clone()
+	for _, name := range scrubHeaders {
+		values := output.Values(name)
+		for index, value := range values {
+			if strings.EqualFold(name, "Authorization") || strings.EqualFold(name, "Proxy-Authorization") {
+				values[index] = RedactAuthorization(value)
+			} else {
+				values[index] = "REDACTED"
+			}
+		}
+	}
+	return output
+}
+
+// readRequestBody reads the body of a request and returns a copy of the request
+// to send with the body readable again; round trippers mustn't modify the
+// request they're given.
+func readRequestBody(req *http.Request) (*http.Request, []byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return req, nil, nil
+	}
+	source := req.Body
+	if req.GetBody != nil {
+		// the original body is left unread, it's closed like a round trip would.
+		defer req.Body.Close()
+		var err error
+		if source, err = req.GetBody(); err != nil {
+			return nil, nil, err
+		}
+	}
+	body, err := io.ReadAll(source)
+	_ = source.Close()
+	if err != nil {
+		return nil, nil, err
+	}
+	output := req.Clone(req.Context())
+	output.Body = io.NopCloser(bytes.NewReader(body))
+	output.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	return output, body, nil
+}
+
+func matchesAll(matchers []Matcher, req *http.Request, body []byte, recorded CassetteRequest) bool {
+	for _, matcher := range matchers {
+		if !matcher(req, body, recorded) {
+			return false
+		}
+	}
+	return true
+}
+
+// recordedURL parses a recorded url, which was valid when it was recorded.
+func recordedURL(recorded CassetteRequest) *url.URL {
+	u, err := url.Parse(recorded.URL)
+	if err != nil {
+		return new(url.URL)
+	}
+	return u
+}
+
+func isYAMLPath(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".yml" || ext == ".yaml"
+}
@@ -0,0 +1,178 @@
+package apiutil
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func replayer(t *testing.T, path string, matchers ...Matcher) *http.Client {
+	t.Helper()
+	recorder, err := NewRecorder(path, CassetteReplay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder.Matchers = matchers
+	return &http.Client{Transport: recorder}
+}
+
+func send(t *testing.T, client *http.Client, method, url, body string) (*http.Response, string, error) {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer res.Body.Close()
+	contents, _ := io.ReadAll(res.Body)
+	return res, string(contents), nil
+}
+
+func TestRecorderReplay(t *testing.T) {
+	client := replayer(t, "testdata/notify.yml", MatchMethod, MatchHost, MatchPath, MatchBody)
+	const notifications = "https://192.168.1.20:4343/api/v2/device/notifications"
+
+	// interactions are matched by body, not only in order.
+	res, body, err := send(t, client, http.MethodPost, notifications, `{"model":{"frames":[{"text":"second"}]}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != http.StatusCreated || body != `{"success":{"id":"2"}}` {
+		t.Errorf("unexpected response %d %s", res.StatusCode, body)
+	}
+	if _, body, err = send(t, client, http.MethodPost, notifications, `{"model":{"frames":[{"text":"first"}]}}`); err != nil || body != `{"success":{"id":"1"}}` {
+		t.Errorf("unexpected response %s %v", body, err)
+	}
+	// each interaction is replayed once.
+	if _, _, err = send(t, client, http.MethodPost, notifications, `{"model":{"frames":[{"text":"first"}]}}`); !errors.Is(err, ErrCassetteNoMatch) {
+		t.Errorf("expected %v, got %v", ErrCassetteNoMatch, err)
+	}
+	if _, _, err = send(t, client, http.MethodGet, "https://192.168.1.21:4343/api/v2/device", ""); !errors.Is(err, ErrCassetteNoMatch) {
+		t.Errorf("expected the host to be matched, got %v", err)
+	}
+	res, _, err = send(t, client, http.MethodGet, "https://192.168.1.20:4343/api/v2/device", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if contentType := res.Header.Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("expected the recorded headers, got %q", contentType)
+	}
+}
+
+func TestRecorderReplayJSON(t *testing.T) {
+	client := replayer(t, "testdata/notify.json")
+	res, body, err := send(t, client, http.MethodPost, "https://ntfy.sh/alerts", "api down")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != http.StatusTooManyRequests || res.Status != "429 Too Many Requests" || !strings.Contains(body, "limit reached") {
+		t.Errorf("unexpected response %s %s", res.Status, body)
+	}
+}
+
+func TestRecorderRecord(t *testing.T) {
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		received = append(received, string(body))
+		rw.Header().Set("Set-Cookie", "session=secret")
+		rw.WriteHeader(http.StatusCreated)
+		_, _ = rw.Write([]byte(`{"success":{"id":"1"}}`))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "recorded.yml")
+	recorder, err := NewRecorder(path, CassetteRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Transport: recorder}
+
+	req, err := http.NewRequest(http.MethodPost, server.URL+"/api/v2/device/notifications", bytes.NewReader([]byte("hello")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.SetBasicAuth("dev", "api-key")
+	body, getBody, header := req.Body, req.GetBody, req.Header.Clone()
+	res, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	contents, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+	if string(contents) != `{"success":{"id":"1"}}` {
+		t.Errorf("expected the response body to be readable, got %q", contents)
+	}
+	if len(received) != 1 || received[0] != "hello" {
+		t.Errorf("expected the body to be sent, got %q", received)
+	}
+
+	// the caller's request isn't modified.
+	if req.Body != body || req.Header.Get("Authorization") != header.Get("Authorization") {
+		t.Error("expected the request to be left as is")
+	}
+	if replayed, err := getBody(); err != nil {
+		t.Fatal(err)
+	} else if contents, _ := io.ReadAll(replayed); string(contents) != "hello" {
+		t.Errorf("expected the request body to be readable again, got %q", contents)
+	}
+
+	cassette, err := LoadCassette(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cassette.Interactions) != 1 {
+		t.Fatalf("expected 1 interaction, got %d", len(cassette.Interactions))
+	}
+	interaction := cassette.Interactions[0]
+	if interaction.Request.Body != "hello" || interaction.Response.StatusCode != http.StatusCreated {
+		t.Errorf("unexpected interaction %+v", interaction)
+	}
+	if auth := interaction.Request.Header.Get("Authorization"); auth != "Basic dev:REDACTED" {
+		t.Errorf("expected the authorization to be scrubbed, got %q", auth)
+	}
+	if cookie := interaction.Response.Header.Get("Set-Cookie"); cookie != "REDACTED" {
+		t.Errorf("expected the cookie to be scrubbed, got %q", cookie)
+	}
+
+	// the recording replays.
+	replay := replayer(t, path, MatchMethod, MatchPath, MatchBody)
+	if _, body, err := send(t, replay, http.MethodPost, server.URL+"/api/v2/device/notifications", "hello"); err != nil || body != `{"success":{"id":"1"}}` {
+		t.Errorf("unexpected replay %q %v", body, err)
+	}
+}
+
+func TestRecorderMiddlewareWithoutGetBody(t *testing.T) {
+	var received string
+	next := RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		body, _ := io.ReadAll(req.Body)
+		received = string(body)
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Header: make(http.Header), Request: req}, nil
+	})
+	recorder, err := NewRecorder(filepath.Join(t.TempDir(), "recorded.json"), CassetteRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ := http.NewRequest(http.MethodPost, "http://localhost/hook", io.NopCloser(strings.NewReader("payload")))
+	if req.GetBody != nil {
+		t.Fatal("expected a body without GetBody")
+	}
+	original := req.Body
+	if _, err := recorder.Middleware()(next).RoundTrip(req); err != nil {
+		t.Fatal(err)
+	}
+	if received != "payload" {
+		t.Errorf("expected the body to be sent, got %q", received)
+	}
+	if req.Body != original {
+		t.Error("expected the request body to be left as is")
+	}
+}
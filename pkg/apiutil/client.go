@@ -4,18 +4,33 @@ import (
 	"context"
 	"encoding/json"
 	"io"
+	"net"
 	"net/http"
 	"net/url"
+	"time"
+)
+
+// Default timeouts.
+const (
+	DefaultDialTimeout         = 5 * time.Second
+	DefaultTLSHandshakeTimeout = 5 * time.Second
+	DefaultTimeout             = 30 * time.Second
 )
 
 // New creates a new client.
 //
 // The purpose of this client is to wrap r2 calls with common asks such as retries.
+//
+// Clients have their own transport, with the default dial and tls handshake
+// timeouts, and the default overall timeout.
 func New(addr string, opts ...Option) Client {
 	client := Client{
 		URL:            mustParseURL(addr),
 		ResponseFilter: InvalidHTTPStatusAsError,
-		Client:         &http.Client{},
+		Client: &http.Client{
+			Transport: NewTransport(DefaultDialTimeout, DefaultTLSHandshakeTimeout),
+			Timeout:   DefaultTimeout,
+		},
 	}
 	for _, opt := range opts {
 		opt(&client)
@@ -45,8 +60,58 @@ func OptResponseFilter(rf ResponseFilter) Option {
 	}
 }
 
-// OptTransport sets the http transport for the client.
-func OptTransport(t *http.Transport) Option {
+// OptMiddleware adds middleware to the client; the first middleware added is the outermost.
+func OptMiddleware(middleware ...Middleware) Option {
+	return func(c *Client) {
+		c.Middleware = append(c.Middleware, middleware...)
+	}
+}
+
+// OptObserver adds a `Timing` middleware with a given observer.
+func OptObserver(observer Observer) Option {
+	return OptMiddleware(Timing(observer))
+}
+
+// OptTimeout sets the overall timeout of each request, including reading the response body.
+func OptTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.Client.Timeout = timeout
+	}
+}
+
+// OptDialTimeout sets the connect timeout if the client transport is an `*http.Transport`.
+func OptDialTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		if transport, ok := c.Client.Transport.(*http.Transport); ok {
+			transport.DialContext = dialContext(timeout)
+		}
+	}
+}
+
+// OptTLSHandshakeTimeout sets the tls handshake timeout if the client transport is an `*http.Transport`.
+func OptTLSHandshakeTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		if transport, ok := c.Client.Transport.(*http.Transport); ok {
+			transport.TLSHandshakeTimeout = timeout
+		}
+	}
+}
+
+// NewTransport returns a clone of the default transport with given dial and tls handshake timeouts.
+func NewTransport(dialTimeout, tlsHandshakeTimeout time.Duration) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialContext(dialTimeout)
+	transport.TLSHandshakeTimeout = tlsHandshakeTimeout
+	return transport
+}
+
+func dialContext(timeout time.Duration) func(context.Context, string, string) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: timeout, KeepAlive: 30 * time.Second}
+	return dialer.DialContext
+}
+
+// OptTransport sets the http transport for the client, e.g. an `*http.Transport` or a `*Recorder`.
+func OptTransport(t http.RoundTripper) Option {
 	return func(c *Client) {
 		c.Client.Transport = t
 	}
@@ -58,7 +123,10 @@ type Client struct {
 	Log            Logger
 	ResponseFilter ResponseFilter
 	Defaults       []RequestOption
-	Client         *http.Client
+	// Middleware wraps the transport of each request; if the `Log` is set
+	// a `Logging` middleware is added innermost.
+	Middleware []Middleware
+	Client     *http.Client
 }
 
 // Do sends the request.
@@ -83,11 +151,7 @@ func (c Client) Do(ctx context.Context, opts ...RequestOption) (res *http.Respon
 			return nil, err
 		}
 	}
-	if c.Client != nil {
-		res, err = c.Client.Do(req)
-	} else {
-		res, err = http.DefaultClient.Do(req)
-	}
+	res, err = c.httpClient().Do(req)
 	return c.filterResponse(res, err)
 }
 
@@ -132,6 +196,28 @@ func (c Client) JSON(ctx context.Context, output interface{}, opts ...RequestOpt
 	return res, nil
 }
 
+// httpClient returns the http client with its transport wrapped in the middleware.
+func (c Client) httpClient() *http.Client {
+	client := http.DefaultClient
+	if c.Client != nil {
+		client = c.Client
+	}
+	if len(c.Middleware) == 0 && c.Log == nil {
+		return client
+	}
+	wrapped := *client
+	if wrapped.Transport == nil {
+		wrapped.Transport = http.DefaultTransport
+	}
+	if c.Log != nil {
+		wrapped.Transport = Logging(c.Log)(wrapped.Transport)
+	}
+	for index := len(c.Middleware) - 1; index >= 0; index-- {
+		wrapped.Transport = c.Middleware[index](wrapped.Transport)
+	}
+	return &wrapped
+}
+
 func (c Client) filterResponse(res *http.Response, err error) (*http.Response, error) {
 	if c.ResponseFilter != nil {
 		return c.ResponseFilter(res, err)
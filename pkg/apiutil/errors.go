@@ -10,4 +10,15 @@ func (e Error) Error() string { return string(e) }
 const (
 	ErrNon200FromRemote     Error = "non-200 status code from remote"
 	ErrNonResponseFromRetry Error = "non-http response from retry"
+	ErrKnownHostsInvalid    Error = "invalid known hosts line"
+	ErrCertificateMissing   Error = "no certificate presented by remote"
+	ErrCertificateUnpinned  Error = "no certificate fingerprint pinned for remote"
+	ErrCertificateMismatch  Error = "certificate fingerprint mismatch"
+	ErrEndpointEmpty        Error = "endpoint address is empty"
+	ErrEndpointInvalid      Error = "endpoint address is invalid"
+	ErrEndpointScheme       Error = "endpoint address scheme is unsupported"
+	ErrEndpointPath         Error = "endpoint address paths are unsupported"
+	ErrCassetteInvalid      Error = "invalid cassette"
+	ErrCassetteNoMatch      Error = "no recorded interaction matches request"
+	ErrBreakerOpen          Error = "circuit breaker open"
 )
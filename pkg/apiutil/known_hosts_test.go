@@ -0,0 +1,79 @@
+package apiutil
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestPinnedTLSConfigTrustOnFirstUse(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "known_hosts")
+	knownHosts, err := LoadKnownHosts(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := PinnedTLSConfig("kitchen", "", knownHosts)
+
+	// concurrent first connections presenting different certificates
+	// pin exactly one of them.
+	const connections = 16
+	var wg sync.WaitGroup
+	errs := make(chan error, connections)
+	for index := 0; index < connections; index++ {
+		wg.Add(1)
+		go func(index int) {
+			defer wg.Done()
+			errs <- config.VerifyPeerCertificate([][]byte{[]byte(fmt.Sprintf("cert %d", index))}, nil)
+		}(index)
+	}
+	wg.Wait()
+	close(errs)
+	var accepted int
+	for err := range errs {
+		if err == nil {
+			accepted++
+		} else if !errors.Is(err, ErrCertificateMismatch) {
+			t.Errorf("expected %v, got %v", ErrCertificateMismatch, err)
+		}
+	}
+	if accepted != 1 {
+		t.Errorf("expected 1 certificate to be accepted, got %d", accepted)
+	}
+
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(contents)), "\n")
+	if len(lines) != 1 || !strings.HasPrefix(lines[0], "kitchen ") {
+		t.Fatalf("expected a single pinned host, got %q", contents)
+	}
+
+	reloaded, err := LoadKnownHosts(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pinned, _ := reloaded.Lookup("kitchen")
+	if pinned != strings.Fields(lines[0])[1] {
+		t.Errorf("expected the pin to be reloaded, got %q", pinned)
+	}
+}
+
+func TestPinnedTLSConfigPin(t *testing.T) {
+	raw := []byte("cert")
+	pin := strings.ToLower(strings.ReplaceAll(fingerprintRaw(raw), ":", ""))
+	config := PinnedTLSConfig("kitchen", "sha256:"+pin, nil)
+	if err := config.VerifyPeerCertificate([][]byte{raw}, nil); err != nil {
+		t.Errorf("expected the pinned certificate to be accepted, got %v", err)
+	}
+	if err := config.VerifyPeerCertificate([][]byte{[]byte("other")}, nil); !errors.Is(err, ErrCertificateMismatch) {
+		t.Errorf("expected %v, got %v", ErrCertificateMismatch, err)
+	}
+	if err := PinnedTLSConfig("kitchen", "", nil).VerifyPeerCertificate([][]byte{raw}, nil); !errors.Is(err, ErrCertificateUnpinned) {
+		t.Errorf("expected %v, got %v", ErrCertificateUnpinned, err)
+	}
+}
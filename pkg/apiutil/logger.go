@@ -1,6 +1,16 @@
 package apiutil
 
 // Logger is an abstracted logger type.
+//
+// Println is called with a message and the fields that go with it.
 type Logger interface {
 	Println(string, ...interface{})
 }
+
+// LoggerFunc is a function that implements Logger.
+type LoggerFunc func(string, ...interface{})
+
+// Println implements Logger.
+func (lf LoggerFunc) Println(message string, fields ...interface{}) {
+	lf(message, fields...)
+}
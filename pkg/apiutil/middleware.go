@@ -0,0 +1,151 @@
+package apiutil
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// HeaderRequestID is the header request ids are sent in.
+const HeaderRequestID = "X-Request-Id"
+
+// DefaultRedactQuery are the query parameters (matched case insensitively)
+// whose values are redacted in logs.
+var DefaultRedactQuery = []string{"token", "access_token", "api_key", "apikey", "key", "secret", "password", "signature", "sig", "auth"}
+
+// Middleware wraps a round tripper, e.g. to observe or modify requests.
+//
+// Middleware must not modify the request it's given; clone it first.
+type Middleware func(http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc is a function that implements http.RoundTripper.
+type RoundTripperFunc func(*http.Request) (*http.Response, error)
+
+// RoundTrip implements http.RoundTripper.
+func (rtf RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return rtf(req)
+}
+
+// Observer is called with the outcome of a request and how long it took to get the response.
+//
+// The time is until the response headers are read, as the body is read by
+// the caller after the round trip; it doesn't include reading the body.
+type Observer func(req *http.Request, res *http.Response, err error, elapsed time.Duration)
+
+// Timing returns a middleware that calls a given observer after each request.
+func Timing(observer Observer) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			started := time.Now()
+			res, err := next.RoundTrip(req)
+			observer(req, res, err, time.Since(started))
+			return res, err
+		})
+	}
+}
+
+// Logging returns a middleware that logs each request and its outcome, with
+// url and basic auth passwords, bearer tokens and the `DefaultRedactQuery`
+// query parameters redacted.
+func Logging(log Logger) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			started := time.Now()
+			res, err := next.RoundTrip(req)
+			elapsed := time.Since(started).Round(time.Microsecond)
+			fields := []interface{}{req.Method, RedactURL(req.URL)}
+			if auth := req.Header.Get("Authorization"); auth != "" {
+				fields = append(fields, "authorization="+RedactAuthorization(auth))
+			}
+			if id := req.Header.Get(HeaderRequestID); id != "" {
+				fields = append(fields, "request_id="+id)
+			}
+			if err != nil {
+				log.Println("request failed", append(fields, elapsed, err)...)
+			} else {
+				log.Println("request", append(fields, res.Status, elapsed)...)
+			}
+			return res, err
+		})
+	}
+}
+
+// RedactURL returns a url as a string with its password and the values of
+// the `DefaultRedactQuery` query parameters redacted.
+func RedactURL(u *url.URL) string {
+	if u.RawQuery == "" {
+		return u.Redacted()
+	}
+	query := u.Query()
+	for name, values := range query {
+		for _, redact := range DefaultRedactQuery {
+			if strings.EqualFold(name, redact) {
+				for index := range values {
+					values[index] = "REDACTED"
+				}
+			}
+		}
+	}
+	redacted := *u
+	redacted.RawQuery = query.Encode()
+	return redacted.Redacted()
+}
+
+// RedactAuthorization returns an authorization header value with its credentials
+// redacted, leaving the scheme and basic auth username.
+func RedactAuthorization(value string) string {
+	scheme, credentials := value, ""
+	if index := strings.IndexByte(value, ' '); index >= 0 {
+		scheme, credentials = value[:index], value[index+1:]
+	}
+	if credentials == "" {
+		return "REDACTED"
+	}
+	if strings.EqualFold(scheme, "basic") {
+		if decoded, err := base64.StdEncoding.DecodeString(credentials); err == nil {
+			if index := strings.IndexByte(string(decoded), ':'); index >= 0 {
+				return scheme + " " + string(decoded[:index]) + ":REDACTED"
+			}
+		}
+	}
+	return scheme + " REDACTED"
+}
+
+// RequestID returns a middleware that sets a random request id header on
+// requests that don't already have one.
+func RequestID() Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if req.Header.Get(HeaderRequestID) == "" {
+				req = req.Clone(req.Context())
+				req.Header.Set(HeaderRequestID, NewRequestID())
+			}
+			return next.RoundTrip(req)
+		})
+	}
+}
+
+// NewRequestID returns a random request id.
+func NewRequestID() string {
+	id := make([]byte, 8)
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// UserAgent returns a middleware that sets a given user agent on requests
+// that don't already have one.
+func UserAgent(userAgent string) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if req.Header.Get("User-Agent") == "" {
+				req = req.Clone(req.Context())
+				req.Header.Set("User-Agent", userAgent)
+			}
+			return next.RoundTrip(req)
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 )
 
 // RequestOption mutates a request.
@@ -35,6 +36,14 @@ func OptPathf(format string, args ...interface{}) RequestOption {
 	}
 }
 
+// OptQuery sets the query string for the request.
+func OptQuery(query url.Values) RequestOption {
+	return func(req *http.Request) error {
+		req.URL.RawQuery = query.Encode()
+		return nil
+	}
+}
+
 // OptBasicAuth sets the basic auth header.
 func OptBasicAuth(username, password string) RequestOption {
 	return func(req *http.Request) error {
@@ -65,7 +74,18 @@ func OptJSONBody(body interface{}) RequestOption {
 			req.Header = make(http.Header)
 		}
 		req.Header.Set("Content-Type", "application/json; charset=utf-8")
+		return OptBody(contents)(req)
+	}
+}
+
+// OptBody sets the raw body.
+func OptBody(contents []byte) RequestOption {
+	return func(req *http.Request) error {
 		req.Body = io.NopCloser(bytes.NewReader(contents))
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(contents)), nil
+		}
+		req.ContentLength = int64(len(contents))
 		return nil
 	}
 }
@@ -1,6 +1,7 @@
 package apiutil
 
 import (
+	"fmt"
 	"net/http"
 )
 
@@ -9,16 +10,37 @@ type ResponseFilter func(*http.Response, error) (*http.Response, error)
 
 // InvalidHTTPStatusAsError translates status codes into errors if they're outside the acceptable range (200-299).
 //
+// The errors are `*StatusError`, which match `ErrNon200FromRemote` with `errors.Is`.
+//
 // This can be used as a Client `ResponseFilter` to retry on bad status codes.
 func InvalidHTTPStatusAsError(res *http.Response, err error) (*http.Response, error) {
 	if err != nil {
 		return res, err
 	}
 	if statusCode := res.StatusCode; statusCode < 200 || statusCode > 299 {
+		statusErr := &StatusError{StatusCode: statusCode}
 		if res.Request != nil && res.Request.URL != nil {
-			return res, ErrNon200FromRemote
+			statusErr.Method, statusErr.URL = res.Request.Method, res.Request.URL.Redacted()
 		}
-		return res, ErrNon200FromRemote
+		return res, statusErr
 	}
 	return res, nil
 }
+
+// StatusError is a non-200 status code from a remote.
+type StatusError struct {
+	StatusCode int
+	Method     string
+	URL        string
+}
+
+// Error implements error.
+func (se *StatusError) Error() string {
+	if se.URL == "" {
+		return fmt.Sprintf("%v; %d", ErrNon200FromRemote, se.StatusCode)
+	}
+	return fmt.Sprintf("%v; %s %s; %d", ErrNon200FromRemote, se.Method, se.URL, se.StatusCode)
+}
+
+// Unwrap returns `ErrNon200FromRemote`.
+func (se *StatusError) Unwrap() error { return ErrNon200FromRemote }
@@ -1,7 +1,10 @@
 package apiutil
 
 import (
+	"fmt"
+	"net"
 	"net/url"
+	"strconv"
 	"strings"
 )
 
@@ -13,6 +16,88 @@ func mustParseURL(target string) *url.URL {
 	return u
 }
 
+// ParseEndpoint parses an address into a `scheme://host:port` url.
+//
+// The address may be a host, a host:port, an ipv6 literal (bare or in brackets,
+// with or without a port) or a url. Addresses without a scheme use a given
+// default scheme, and addresses without a port use the default port for the
+// scheme. Schemes without a default port are rejected, as are urls with paths,
+// queries or credentials.
+func ParseEndpoint(addr, defaultScheme string, defaultPorts map[string]int) (*url.URL, error) {
+	addr = strings.TrimSpace(addr)
+	if addr == "" {
+		return nil, ErrEndpointEmpty
+	}
+	scheme, rest := defaultScheme, addr
+	if index := strings.Index(addr, "://"); index >= 0 {
+		scheme, rest = strings.ToLower(addr[:index]), addr[index+3:]
+	}
+	defaultPort, ok := defaultPorts[scheme]
+	if !ok {
+		return nil, fmt.Errorf("%w; %q in %q", ErrEndpointScheme, scheme, addr)
+	}
+	host := rest
+	if index := strings.IndexAny(rest, "/?#"); index >= 0 {
+		host = rest[:index]
+		if rest[index:] != "/" {
+			return nil, fmt.Errorf("%w; %q", ErrEndpointPath, addr)
+		}
+	}
+	if strings.Contains(host, "@") {
+		return nil, fmt.Errorf("%w; credentials are not supported in %q", ErrEndpointInvalid, addr)
+	}
+
+	host = removeEmptyPort(host)
+	var hostname, port string
+	bracketed := strings.HasPrefix(host, "[")
+	switch {
+	case bracketed:
+		end := strings.Index(host, "]")
+		if end < 0 {
+			return nil, fmt.Errorf("%w; unterminated ipv6 literal in %q", ErrEndpointInvalid, addr)
+		}
+		hostname = host[1:end]
+		if remainder := host[end+1:]; remainder != "" {
+			if !strings.HasPrefix(remainder, ":") {
+				return nil, fmt.Errorf("%w; %q", ErrEndpointInvalid, addr)
+			}
+			port = remainder[1:]
+		}
+	case strings.Count(host, ":") > 1:
+		// a bare ipv6 literal, which can't have a port
+		hostname = host
+	case hasPort(host):
+		var err error
+		if hostname, port, err = net.SplitHostPort(host); err != nil {
+			return nil, fmt.Errorf("%w; %v", ErrEndpointInvalid, err)
+		}
+	default:
+		hostname = host
+	}
+	if hostname == "" {
+		return nil, fmt.Errorf("%w; missing host in %q", ErrEndpointInvalid, addr)
+	}
+	// brackets are only for ipv6 literals.
+	if bracketed || strings.Contains(hostname, ":") {
+		ip := hostname
+		if zone := strings.Index(ip, "%"); zone >= 0 {
+			ip = ip[:zone]
+		}
+		if net.ParseIP(ip) == nil || !strings.Contains(ip, ":") {
+			return nil, fmt.Errorf("%w; invalid ipv6 literal in %q", ErrEndpointInvalid, addr)
+		}
+	}
+	if port == "" {
+		port = strconv.Itoa(defaultPort)
+	} else if value, err := strconv.Atoi(port); err != nil || value < 1 || value > 65535 {
+		return nil, fmt.Errorf("%w; invalid port in %q", ErrEndpointInvalid, addr)
+	}
+	return &url.URL{
+		Scheme: scheme,
+		Host:   net.JoinHostPort(hostname, port),
+	}, nil
+}
+
 // removeEmptyPort strips the empty port in ":port" to ""
 // as mandated by RFC 3986 Section 6.2.3.
 func removeEmptyPort(host string) string {
@@ -0,0 +1,12 @@
+package config
+
+import "time"
+
+// Breaker is the config for the circuit breaker per device host.
+type Breaker struct {
+	// FailureThreshold is the number of consecutive failed requests to a host
+	// before requests to it fail fast; defaults to five.
+	FailureThreshold int `yaml:"failureThreshold"`
+	// CoolDown is how long requests fail fast before a trial request; defaults to 30s.
+	CoolDown time.Duration `yaml:"coolDown"`
+}
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/wcharczuk/lametric/pkg/lametric"
+)
+
+// DefaultCalendarInterval is the default of how often calendars are fetched.
+const DefaultCalendarInterval = 5 * time.Minute
+
+// Calendar is the config for the iCalendar feed of a device, which sends
+// meeting reminders to it, or keeps a busy / free indicator on it for rooms.
+type Calendar struct {
+	// URL is the path or http(s) (or webcal) url of the iCalendar file.
+	URL string `yaml:"url"`
+	// Interval is how often the calendar is fetched; defaults to five minutes.
+	Interval time.Duration `yaml:"interval"`
+	// TimeZone is the time zone name times are shown in, and floating calendar
+	// times are in; defaults to local time.
+	TimeZone string `yaml:"timeZone"`
+	// RemindBefore, if set, is how long before meetings a reminder is sent.
+	RemindBefore time.Duration `yaml:"remindBefore"`
+	// Room, if set, is the indicator app the room status is pushed to.
+	Room *lametric.App `yaml:"room"`
+}
+
+// IntervalOrDefault returns the fetch interval or a default.
+func (c Calendar) IntervalOrDefault() time.Duration {
+	if c.Interval > 0 {
+		return c.Interval
+	}
+	return DefaultCalendarInterval
+}
+
+// Location returns the time zone location.
+func (c Calendar) Location() (*time.Location, error) {
+	if c.TimeZone == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(c.TimeZone)
+}
+
+// Validate returns an error if the calendar is invalid for a given device.
+func (c Calendar) Validate(device Device) error {
+	if c.URL == "" {
+		return fmt.Errorf("device %s; calendar url is required", device.Label())
+	}
+	if _, err := c.Location(); err != nil {
+		return fmt.Errorf("device %s; calendar; %w", device.Label(), err)
+	}
+	if c.RemindBefore <= 0 && c.Room == nil {
+		return fmt.Errorf("device %s; calendar requires remindBefore or room", device.Label())
+	}
+	if c.Room != nil {
+		if device.BackendOrDefault() != BackendLaMetric {
+			return fmt.Errorf("device %s; calendar room requires the lametric backend", device.Label())
+		}
+		if c.Room.ID == "" {
+			return fmt.Errorf("device %s; calendar room; %w", device.Label(), lametric.ErrAppIDEmpty)
+		}
+	}
+	return nil
+}
@@ -1,6 +1,171 @@
 package config
 
+import (
+	"fmt"
+
+	"github.com/wcharczuk/lametric/pkg/lametric"
+)
+
 // Config is a root config struct.
 type Config struct {
 	Devices []Device `yaml:"devices"`
+	// IconCatalog is an optional path to an icon catalog json file that
+	// replaces the catalog embedded in the binary.
+	IconCatalog string `yaml:"iconCatalog"`
+	// Templates are named notifications; frame icons may be catalog
+	// names (e.g. `attention`) which are resolved at send time.
+	Templates map[string]lametric.Notification `yaml:"templates"`
+	// KnownHosts is the path of the file device certificate fingerprints
+	// are pinned in for https; it defaults to `DefaultKnownHosts`.
+	KnownHosts string `yaml:"knownHosts"`
+	// MQTT is the config for the mqtt bridge.
+	MQTT MQTT `yaml:"mqtt"`
+	// Server is the config for `serve`.
+	Server Server `yaml:"server"`
+	// Health is the config for device health checks.
+	Health Health `yaml:"health"`
+	// Timeouts are the default timeouts for sending to devices.
+	Timeouts Timeouts `yaml:"timeouts"`
+	// Breaker is the config for the circuit breaker per device host.
+	Breaker Breaker `yaml:"breaker"`
+	// Tracing is the config for notification delivery traces.
+	Tracing Tracing `yaml:"tracing"`
+	// Schedules are notifications sent on cron schedules by `serve`.
+	Schedules []Schedule `yaml:"schedules"`
+	// ScheduleState is the path of the file the last run of each schedule
+	// is saved in; it defaults to `DefaultScheduleState`.
+	ScheduleState string `yaml:"scheduleState"`
+	// ReminderState is the path of the file reminders are saved in; it
+	// defaults to `DefaultReminderState`.
+	ReminderState string `yaml:"reminderState"`
+	// Feeds are rss or atom feeds whose new entries are announced by `serve`.
+	Feeds []Feed `yaml:"feeds"`
+	// FeedState is the path of the file the seen entries of each feed are
+	// saved in; it defaults to `DefaultFeedState`.
+	FeedState string `yaml:"feedState"`
+	// Monitors are http endpoints and tcp ports checked by `serve`.
+	Monitors []Monitor `yaml:"monitors"`
+	// Prometheus is the config for the prometheus queries run by `serve`.
+	Prometheus Prometheus `yaml:"prometheus"`
+}
+
+// DefaultKnownHosts is the default known hosts path.
+const DefaultKnownHosts = "_config/known_hosts"
+
+// DefaultReminderState is the default reminder state path.
+const DefaultReminderState = "_config/reminders.json"
+
+// KnownHostsOrDefault returns the known hosts path or a default.
+func (c Config) KnownHostsOrDefault() string {
+	if c.KnownHosts != "" {
+		return c.KnownHosts
+	}
+	return DefaultKnownHosts
+}
+
+// ScheduleStateOrDefault returns the schedule state path or a default.
+func (c Config) ScheduleStateOrDefault() string {
+	if c.ScheduleState != "" {
+		return c.ScheduleState
+	}
+	return DefaultScheduleState
+}
+
+// ReminderStateOrDefault returns the reminder state path or a default.
+func (c Config) ReminderStateOrDefault() string {
+	if c.ReminderState != "" {
+		return c.ReminderState
+	}
+	return DefaultReminderState
+}
+
+// FeedStateOrDefault returns the feed state path or a default.
+func (c Config) FeedStateOrDefault() string {
+	if c.FeedState != "" {
+		return c.FeedState
+	}
+	return DefaultFeedState
+}
+
+// Device returns the device with a given name, or by address if no device has the name.
+func (c Config) Device(name string) (Device, bool) {
+	for _, device := range c.Devices {
+		if device.Name == name {
+			return device, true
+		}
+	}
+	for _, device := range c.Devices {
+		if device.Addr == name {
+			return device, true
+		}
+	}
+	return Device{}, false
+}
+
+// Validate returns an error if the config is invalid.
+func (c Config) Validate() error {
+	names := make(map[string]bool)
+	for _, device := range c.Devices {
+		if err := device.Validate(); err != nil {
+			return err
+		}
+		if device.Name != "" {
+			if names[device.Name] {
+				return fmt.Errorf("device %s; duplicate device name", device.Name)
+			}
+			names[device.Name] = true
+		}
+	}
+	if c.MQTT.Discovery.Enabled && c.MQTT.StateTopic == "" {
+		return fmt.Errorf("mqtt discovery requires a state topic")
+	}
+	if _, err := c.SelectDevices(c.Health.Notify); err != nil {
+		return fmt.Errorf("health notify; %w", err)
+	}
+	for _, sub := range c.MQTT.Subscriptions {
+		if sub.QoS > 2 {
+			return fmt.Errorf("mqtt subscription %s; invalid qos %d", sub.Topic, sub.QoS)
+		}
+		if err := c.validateRoute(sub.Template, sub.Devices); err != nil {
+			return fmt.Errorf("mqtt subscription %s; %w", sub.Topic, err)
+		}
+	}
+	if err := c.validateSchedules(); err != nil {
+		return err
+	}
+	if err := c.validateFeeds(); err != nil {
+		return err
+	}
+	if err := c.validateMonitors(); err != nil {
+		return err
+	}
+	return c.validatePrometheus()
+}
+
+// validateRoute returns an error if a template or any devices are missing.
+func (c Config) validateRoute(template string, devices []string) error {
+	if template != "" {
+		if _, ok := c.Templates[template]; !ok {
+			return fmt.Errorf("template %q not found", template)
+		}
+	}
+	_, err := c.SelectDevices(devices)
+	return err
+}
+
+// SelectDevices returns the devices with the given names (or addresses), or all
+// the devices if no names are given.
+func (c Config) SelectDevices(names []string) ([]Device, error) {
+	if len(names) == 0 {
+		return c.Devices, nil
+	}
+	var output []Device
+	for _, name := range names {
+		device, ok := c.Device(name)
+		if !ok {
+			return nil, fmt.Errorf("device %q not found", name)
+		}
+		output = append(output, device)
+	}
+	return output, nil
 }
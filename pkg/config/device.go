@@ -1,7 +1,120 @@
 package config
 
+import (
+	"fmt"
+	"net"
+	"net/url"
+
+	"github.com/wcharczuk/lametric/pkg/lametric"
+)
+
+// Backends a device entry can send notifications to.
+const (
+	BackendLaMetric = "lametric"
+	BackendSlack    = "slack"
+	BackendWebhook  = "webhook"
+	BackendNtfy     = "ntfy"
+	BackendEmail    = "email"
+)
+
 // Device is a notification broadcast target.
 type Device struct {
-	Addr  string `yaml:"addr"`
+	// Name is an optional name used to select the device from the command line.
+	Name string `yaml:"name"`
+	// Backend is the kind of notification target, one of `lametric` (the default),
+	// `slack`, `webhook`, `ntfy` or `email`.
+	Backend string `yaml:"backend,omitempty"`
+	// Addr is the device address as a host, host:port, ipv6 literal or url.
+	//
+	// For the slack, webhook and ntfy backends it is the url to post to, and
+	// for the email backend it is the smtp server `host:port`.
+	Addr string `yaml:"addr"`
+	// Token is the device api key, or the bearer token for the webhook and ntfy backends.
 	Token string `yaml:"token"`
+	// Serial is the optional device serial number, used to find the device on
+	// the local network if it can't be reached at its address.
+	Serial string `yaml:"serial,omitempty"`
+	// Scheme is the device api scheme, either `http` (the default) or `https`.
+	Scheme string `yaml:"scheme,omitempty"`
+	// Port is the device api port; it defaults to 8080 for http and 4343 for https.
+	Port int `yaml:"port,omitempty"`
+	// Fingerprint is the optional sha-256 fingerprint of the device certificate
+	// for https; if unset the certificate is pinned on first use in the known hosts.
+	Fingerprint string `yaml:"fingerprint,omitempty"`
+	// Email holds the options for the email backend.
+	Email *Email `yaml:"email,omitempty"`
+	// Timeouts override the config timeouts for the device.
+	Timeouts Timeouts `yaml:"timeouts,omitempty"`
+	// Calendar, if set, is the calendar meeting reminders or the room status are sent from.
+	Calendar *Calendar `yaml:"calendar,omitempty"`
+}
+
+// Email are options for the email backend.
+type Email struct {
+	From     string   `yaml:"from"`
+	To       []string `yaml:"to"`
+	Username string   `yaml:"username,omitempty"`
+	Password string   `yaml:"password,omitempty"`
+}
+
+// BackendOrDefault returns the backend or a default.
+func (d Device) BackendOrDefault() string {
+	if d.Backend != "" {
+		return d.Backend
+	}
+	return BackendLaMetric
+}
+
+// Validate returns an error if the device address or backend options are invalid.
+func (d Device) Validate() error {
+	switch d.BackendOrDefault() {
+	case BackendLaMetric:
+		if d.Scheme != "" && d.Scheme != lametric.SchemeHTTP && d.Scheme != lametric.SchemeHTTPS {
+			return fmt.Errorf("device %s; invalid scheme %q", d.Label(), d.Scheme)
+		}
+		if d.Port < 0 || d.Port > 65535 {
+			return fmt.Errorf("device %s; invalid port %d", d.Label(), d.Port)
+		}
+		if _, err := lametric.Endpoint(d.Scheme, d.Addr, d.Port); err != nil {
+			return fmt.Errorf("device %s; %w", d.Label(), err)
+		}
+	case BackendSlack, BackendWebhook, BackendNtfy:
+		// the url error isn't wrapped, as it has the url in it, which may be a secret.
+		u, err := url.Parse(d.Addr)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("device %s; %s backend address must be an http or https url", d.Label(), d.Backend)
+		}
+	case BackendEmail:
+		if _, _, err := net.SplitHostPort(d.Addr); err != nil {
+			return fmt.Errorf("device %s; email backend address must be a smtp host:port; %w", d.Label(), err)
+		}
+		if d.Email == nil || d.Email.From == "" || len(d.Email.To) == 0 {
+			return fmt.Errorf("device %s; email backend requires email from and to", d.Label())
+		}
+	default:
+		return fmt.Errorf("device %s; invalid backend %q", d.Label(), d.Backend)
+	}
+	if d.Calendar != nil {
+		return d.Calendar.Validate(d)
+	}
+	return nil
+}
+
+// Label returns the device name, or its address if it's unnamed.
+//
+// The address of an unnamed slack, webhook or ntfy device is a url that is
+// often a secret (e.g. a slack webhook), so only its scheme and host are used.
+func (d Device) Label() string {
+	if d.Name != "" {
+		return d.Name
+	}
+	switch d.BackendOrDefault() {
+	case BackendSlack, BackendWebhook, BackendNtfy:
+		u, err := url.Parse(d.Addr)
+		if err != nil || u.Host == "" {
+			return d.BackendOrDefault()
+		}
+		return u.Scheme + "://" + u.Host
+	}
+	return d.Addr
 }
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/wcharczuk/lametric/pkg/lametric"
+)
+
+// Feed defaults.
+const (
+	DefaultFeedState      = "_config/feeds.json"
+	DefaultFeedInterval   = 15 * time.Minute
+	DefaultFeedMaxPerPoll = 3
+)
+
+// Feed is an rss or atom feed whose new entries are announced by `serve`.
+type Feed struct {
+	// Name identifies the feed, and its state in the state file.
+	Name string `yaml:"name"`
+	// URL is the http(s) url of the feed.
+	URL string `yaml:"url"`
+	// Interval is how often the feed is polled; defaults to 15 minutes.
+	Interval time.Duration `yaml:"interval"`
+	// Icon is the icon of frames without one; defaults to the rss icon.
+	Icon lametric.Icon `yaml:"icon"`
+	// Template is the name of the template to send; its text may use `{{ .Feed }}`,
+	// `{{ .Title }}`, `{{ .Link }}`, `{{ .Author }}`, `{{ .Summary }}` and `{{ .Published }}`.
+	// Without one the feed and entry title are sent.
+	Template string `yaml:"template"`
+	// Devices are the names of the devices to send to; defaults to all of them.
+	Devices []string `yaml:"devices"`
+	// MaxPerPoll is the most entries announced per poll, the latest ones;
+	// others are marked seen. Defaults to three.
+	MaxPerPoll int `yaml:"maxPerPoll"`
+}
+
+// IntervalOrDefault returns the poll interval or a default.
+func (f Feed) IntervalOrDefault() time.Duration {
+	if f.Interval > 0 {
+		return f.Interval
+	}
+	return DefaultFeedInterval
+}
+
+// MaxPerPollOrDefault returns the max entries announced per poll or a default.
+func (f Feed) MaxPerPollOrDefault() int {
+	if f.MaxPerPoll > 0 {
+		return f.MaxPerPoll
+	}
+	return DefaultFeedMaxPerPoll
+}
+
+// IconOrDefault returns the icon or a default.
+func (f Feed) IconOrDefault() lametric.Icon {
+	if f.Icon != "" {
+		return f.Icon
+	}
+	return lametric.IconRSS
+}
+
+// validateFeeds returns an error if any feeds are invalid.
+func (c Config) validateFeeds() error {
+	names := make(map[string]bool)
+	for _, f := range c.Feeds {
+		if f.Name == "" {
+			return fmt.Errorf("feed %q; name is required", f.URL)
+		}
+		if names[f.Name] {
+			return fmt.Errorf("feed %s; duplicate feed name", f.Name)
+		}
+		names[f.Name] = true
+		u, err := url.Parse(f.URL)
+		if err != nil {
+			return fmt.Errorf("feed %s; %w", f.Name, err)
+		}
+		if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("feed %s; url must be an http or https url", f.Name)
+		}
+		if f.MaxPerPoll < 0 {
+			return fmt.Errorf("feed %s; invalid maxPerPoll %d", f.Name, f.MaxPerPoll)
+		}
+		if err := c.validateRoute(f.Template, f.Devices); err != nil {
+			return fmt.Errorf("feed %s; %w", f.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,17 @@
+package config
+
+import "time"
+
+// Health is the config for device health checks.
+type Health struct {
+	// Interval is how often device health is checked; defaults to a minute.
+	Interval time.Duration `yaml:"interval"`
+	// Timeout is the timeout of each check; defaults to ten seconds.
+	Timeout time.Duration `yaml:"timeout"`
+	// FailureThreshold is the number of consecutive failed checks before
+	// a device is considered unreachable; defaults to one.
+	FailureThreshold int `yaml:"failureThreshold"`
+	// Notify are the names of the devices (e.g. slack or email backends) that are
+	// notified when a device becomes unreachable or unauthorized, or recovers.
+	Notify []string `yaml:"notify"`
+}
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+)
+
+// Monitor is an http endpoint or tcp port that `serve` checks, notifying when
+// it goes down or comes back up, or its certificate is about to expire.
+type Monitor struct {
+	// Name identifies the monitor in notifications.
+	Name string `yaml:"name"`
+	// URL is the http(s) url to check; one of url or tcp is required.
+	URL string `yaml:"url"`
+	// TCP is the `host:port` to check accepts connections.
+	TCP string `yaml:"tcp"`
+	// Method is the http method; defaults to `GET`.
+	Method string `yaml:"method"`
+	// Status are the expected http status codes; defaults to any 2xx.
+	Status []int `yaml:"status"`
+	// Body is text the http response body must contain, if set.
+	Body string `yaml:"body"`
+	// MaxLatency is the longest the http response may take, if set.
+	MaxLatency time.Duration `yaml:"maxLatency"`
+	// CertExpiryDays is how many days before its certificate expires an
+	// https url is warned about; defaults to 14, and 0 disables the warning.
+	CertExpiryDays *int `yaml:"certExpiryDays"`
+	// Interval is how often the monitor is checked; defaults to a minute.
+	Interval time.Duration `yaml:"interval"`
+	// Timeout is the timeout of each check; defaults to ten seconds.
+	Timeout time.Duration `yaml:"timeout"`
+	// FailureThreshold is the number of consecutive failed checks before
+	// the monitor is considered down; defaults to one.
+	FailureThreshold int `yaml:"failureThreshold"`
+	// Template is the name of the template to send; its text may use `{{ .Name }}`,
+	// `{{ .Event }}` (down, up or cert expiring), `{{ .Target }}`, `{{ .Error }}`,
+	// `{{ .Downtime }}` and `{{ .CertExpires }}`.
+	Template string `yaml:"template"`
+	// Devices are the names of the devices to send to; defaults to all of them.
+	Devices []string `yaml:"devices"`
+}
+
+// DefaultMonitorCertExpiryDays is the default days before certificate expiry monitors warn.
+const DefaultMonitorCertExpiryDays = 14
+
+// CertExpiry returns how long before its certificate expires the monitor warns.
+func (m Monitor) CertExpiry() time.Duration {
+	days := DefaultMonitorCertExpiryDays
+	if m.CertExpiryDays != nil {
+		days = *m.CertExpiryDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// validateMonitors returns an error if any monitors are invalid.
+func (c Config) validateMonitors() error {
+	names := make(map[string]bool)
+	for _, m := range c.Monitors {
+		if m.Name == "" {
+			return fmt.Errorf("monitor %q; name is required", m.URL+m.TCP)
+		}
+		if names[m.Name] {
+			return fmt.Errorf("monitor %s; duplicate monitor name", m.Name)
+		}
+		names[m.Name] = true
+		if (m.URL == "") == (m.TCP == "") {
+			return fmt.Errorf("monitor %s; one of url or tcp is required", m.Name)
+		}
+		if m.URL != "" {
+			u, err := url.Parse(m.URL)
+			if err != nil {
+				return fmt.Errorf("monitor %s; %w", m.Name, err)
+			}
+			if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("monitor %s; url must be an http or https url", m.Name)
+			}
+		}
+		if m.TCP != "" {
+			if _, _, err := net.SplitHostPort(m.TCP); err != nil {
+				return fmt.Errorf("monitor %s; %w", m.Name, err)
+			}
+			if m.Method != "" || len(m.Status) > 0 || m.Body != "" || m.MaxLatency > 0 {
+				return fmt.Errorf("monitor %s; method, status, body and maxLatency only apply to urls", m.Name)
+			}
+		}
+		for _, status := range m.Status {
+			if status < 100 || status > 599 {
+				return fmt.Errorf("monitor %s; invalid status %d", m.Name, status)
+			}
+		}
+		if m.CertExpiryDays != nil && *m.CertExpiryDays < 0 {
+			return fmt.Errorf("monitor %s; invalid certExpiryDays %d", m.Name, *m.CertExpiryDays)
+		}
+		if err := c.validateRoute(m.Template, m.Devices); err != nil {
+			return fmt.Errorf("monitor %s; %w", m.Name, err)
+		}
+	}
+	return nil
+}
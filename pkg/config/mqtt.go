@@ -0,0 +1,73 @@
+package config
+
+import "time"
+
+// MQTT is the config for the mqtt bridge.
+type MQTT struct {
+	// Broker is the broker url, e.g. `tcp://localhost:1883`.
+	Broker    string        `yaml:"broker"`
+	ClientID  string        `yaml:"clientID"`
+	Username  string        `yaml:"username"`
+	Password  string        `yaml:"password"`
+	KeepAlive time.Duration `yaml:"keepAlive"`
+	// Subscriptions map topic filters to templates and devices.
+	Subscriptions []MQTTSubscription `yaml:"subscriptions"`
+	// StateTopic, if set, is the topic prefix the bridge status and each device's
+	// delivery state are published (retained) under.
+	StateTopic string `yaml:"stateTopic"`
+	// ResultsTopic, if set, is the topic each delivery result is published to.
+	ResultsTopic string `yaml:"resultsTopic"`
+	// QoS is the qos state and results are published with.
+	QoS byte `yaml:"qos"`
+	// Discovery publishes home assistant discovery config for each lametric device.
+	Discovery MQTTDiscovery `yaml:"discovery"`
+}
+
+// Discovery defaults.
+const (
+	DefaultDiscoveryPrefix      = "homeassistant"
+	DefaultDiscoveryStatePeriod = time.Minute
+)
+
+// MQTTDiscovery is the config for home assistant mqtt discovery.
+//
+// Each lametric device gets brightness and volume number entities, a brightness
+// mode select entity and a notify text entity; their commands and state topics
+// are under the state topic, which is required.
+type MQTTDiscovery struct {
+	Enabled bool `yaml:"enabled"`
+	// Prefix is the discovery topic prefix; defaults to `homeassistant`.
+	Prefix string `yaml:"prefix"`
+	// StatePeriod is how often the device state is polled and published; defaults to a minute.
+	StatePeriod time.Duration `yaml:"statePeriod"`
+}
+
+// PrefixOrDefault returns the discovery prefix or a default.
+func (d MQTTDiscovery) PrefixOrDefault() string {
+	if d.Prefix != "" {
+		return d.Prefix
+	}
+	return DefaultDiscoveryPrefix
+}
+
+// StatePeriodOrDefault returns the state period or a default.
+func (d MQTTDiscovery) StatePeriodOrDefault() time.Duration {
+	if d.StatePeriod > 0 {
+		return d.StatePeriod
+	}
+	return DefaultDiscoveryStatePeriod
+}
+
+// MQTTSubscription maps messages on a topic filter to a template and devices.
+type MQTTSubscription struct {
+	// Topic is a topic filter, which may include `+` and `#` wildcards.
+	Topic string `yaml:"topic"`
+	QoS   byte   `yaml:"qos"`
+	// Template is the name of the template the message is rendered with; the
+	// template data has the `.Topic`, its `.Levels`, the `.Payload` as a string,
+	// and the `.JSON` decoded payload if it's json. If unset, the payload
+	// is sent as the text of a single frame.
+	Template string `yaml:"template"`
+	// Devices are the names of the devices to send to; if empty all devices are sent to.
+	Devices []string `yaml:"devices"`
+}
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"io"
 	"os"
 
 	"gopkg.in/yaml.v3"
@@ -13,9 +14,10 @@ func MustRead(cfg interface{}, preferredPath string) {
 		if !os.IsNotExist(err) {
 			panic(err)
 		}
+		return
 	}
 	defer f.Close()
-	if err := yaml.NewDecoder(f).Decode(cfg); err != nil {
+	if err := yaml.NewDecoder(f).Decode(cfg); err != nil && err != io.EOF {
 		panic(err)
 	}
 }
@@ -0,0 +1,184 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/wcharczuk/lametric/pkg/lametric"
+)
+
+// DefaultPrometheusInterval is the default of how often prometheus queries are run.
+const DefaultPrometheusInterval = time.Minute
+
+// Prometheus is the config for the prometheus queries `serve` runs, pushing their
+// values to indicator apps and sending notifications when they cross thresholds.
+type Prometheus struct {
+	// URL is the base url of the prometheus compatible api, e.g. `http://prometheus:9090`.
+	URL string `yaml:"url"`
+	// Token, if set, is sent as a bearer token.
+	Token string `yaml:"token"`
+	// Interval is how often the queries are run; defaults to a minute.
+	Interval time.Duration `yaml:"interval"`
+	// Queries are the queries to run.
+	Queries []PrometheusQuery `yaml:"queries"`
+	// Apps are the indicator apps the query frames are pushed to.
+	Apps []PrometheusApp `yaml:"apps"`
+}
+
+// IntervalOrDefault returns the query interval or a default.
+func (p Prometheus) IntervalOrDefault() time.Duration {
+	if p.Interval > 0 {
+		return p.Interval
+	}
+	return DefaultPrometheusInterval
+}
+
+// PrometheusQuery is a promql query shown as a frame.
+//
+// Instant queries are shown as their value, or as a gauge if they have a goal;
+// range queries are shown as a chart. Queries must return a single series.
+type PrometheusQuery struct {
+	// Name identifies the query in apps and notifications.
+	Name string `yaml:"name"`
+	// Query is the promql expression.
+	Query string `yaml:"query"`
+	// Range, if set, makes the query a range query over the last range.
+	Range time.Duration `yaml:"range"`
+	// Step is the range query resolution; defaults to the range over the chart width.
+	Step time.Duration `yaml:"step"`
+	// Icon is the frame icon.
+	Icon lametric.Icon `yaml:"icon"`
+	// Format is the printf format of the value, e.g. `%.1f`; defaults to two
+	// significant decimals.
+	Format string `yaml:"format"`
+	// Unit is appended to the value, e.g. `%` or ` req/s`.
+	Unit string `yaml:"unit"`
+	// Goal, if set, shows an instant query as a gauge from start to end,
+	// e.g. an error rate against its slo.
+	Goal *PrometheusGoal `yaml:"goal"`
+	// Alert, if set, sends notifications when the value crosses a threshold.
+	Alert *PrometheusAlert `yaml:"alert"`
+}
+
+// StepOrDefault returns the range query resolution or a default.
+func (pq PrometheusQuery) StepOrDefault() time.Duration {
+	if pq.Step > 0 {
+		return pq.Step
+	}
+	step := (pq.Range / lametric.ChartColumns).Round(time.Second)
+	if step < time.Second {
+		return time.Second
+	}
+	return step
+}
+
+// PrometheusGoal is the range of a gauge.
+type PrometheusGoal struct {
+	Start float64 `yaml:"start"`
+	End   float64 `yaml:"end"`
+}
+
+// PrometheusAlert is the thresholds of a query; a notification is sent when
+// the value crosses one, and when it's back within them.
+type PrometheusAlert struct {
+	// Above, if set, is the value the query must stay at or below.
+	Above *float64 `yaml:"above"`
+	// Below, if set, is the value the query must stay at or above.
+	Below *float64 `yaml:"below"`
+	// Template is the name of the template to send; its text may use `{{ .Name }}`,
+	// `{{ .Value }}`, `{{ .Text }}` (the formatted value), `{{ .Threshold }}` and
+	// `{{ .Resolved }}`.
+	Template string `yaml:"template"`
+	// Devices are the names of the devices to send to; defaults to all of them.
+	Devices []string `yaml:"devices"`
+}
+
+// Breached returns the threshold a value crosses, if it crosses one.
+func (pa PrometheusAlert) Breached(value float64) (threshold float64, breached bool) {
+	if pa.Above != nil && value > *pa.Above {
+		return *pa.Above, true
+	}
+	if pa.Below != nil && value < *pa.Below {
+		return *pa.Below, true
+	}
+	return 0, false
+}
+
+// PrometheusApp is an indicator app on a device that query frames are pushed to.
+type PrometheusApp struct {
+	// Device is the name of the device the app is on.
+	Device string `yaml:"device"`
+	// App is the indicator app.
+	App lametric.App `yaml:"app"`
+	// Queries are the names of the queries shown, in order; defaults to all of them.
+	Queries []string `yaml:"queries"`
+}
+
+// validatePrometheus returns an error if the prometheus config is invalid.
+func (c Config) validatePrometheus() error {
+	p := c.Prometheus
+	if p.URL == "" {
+		if len(p.Queries) > 0 || len(p.Apps) > 0 {
+			return fmt.Errorf("prometheus; url is required")
+		}
+		return nil
+	}
+	u, err := url.Parse(p.URL)
+	if err != nil {
+		return fmt.Errorf("prometheus; %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("prometheus; url must be an http or https url")
+	}
+	names := make(map[string]bool)
+	for _, q := range p.Queries {
+		if q.Name == "" {
+			return fmt.Errorf("prometheus query %q; name is required", q.Query)
+		}
+		if names[q.Name] {
+			return fmt.Errorf("prometheus query %s; duplicate query name", q.Name)
+		}
+		names[q.Name] = true
+		if q.Query == "" {
+			return fmt.Errorf("prometheus query %s; query is required", q.Name)
+		}
+		if q.Range < 0 || q.Step < 0 {
+			return fmt.Errorf("prometheus query %s; range and step must be positive", q.Name)
+		}
+		if q.Goal != nil {
+			if q.Range > 0 {
+				return fmt.Errorf("prometheus query %s; goal only applies to instant queries", q.Name)
+			}
+			if q.Goal.Start == q.Goal.End {
+				return fmt.Errorf("prometheus query %s; goal start and end must differ", q.Name)
+			}
+		}
+		if q.Alert != nil {
+			if q.Alert.Above == nil && q.Alert.Below == nil {
+				return fmt.Errorf("prometheus query %s; alert requires above or below", q.Name)
+			}
+			if err := c.validateRoute(q.Alert.Template, q.Alert.Devices); err != nil {
+				return fmt.Errorf("prometheus query %s; %w", q.Name, err)
+			}
+		}
+	}
+	for _, app := range p.Apps {
+		device, ok := c.Device(app.Device)
+		if !ok {
+			return fmt.Errorf("prometheus app %s; device %q not found", app.Device, app.Device)
+		}
+		if device.BackendOrDefault() != BackendLaMetric {
+			return fmt.Errorf("prometheus app %s; apps require the lametric backend", app.Device)
+		}
+		if app.App.ID == "" {
+			return fmt.Errorf("prometheus app %s; %w", app.Device, lametric.ErrAppIDEmpty)
+		}
+		for _, name := range app.Queries {
+			if !names[name] {
+				return fmt.Errorf("prometheus app %s; query %q not found", app.Device, name)
+			}
+		}
+	}
+	return nil
+}
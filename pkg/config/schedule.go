@@ -0,0 +1,87 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/wcharczuk/lametric/pkg/lametric"
+	"github.com/wcharczuk/lametric/pkg/schedule"
+)
+
+// DefaultScheduleState is the default schedule state path.
+const DefaultScheduleState = "_config/schedules.json"
+
+// DefaultMisfireGrace is the default of how late a run missed while `serve`
+// wasn't running can be and still be sent.
+const DefaultMisfireGrace = time.Hour
+
+// Schedule is a notification sent on a cron schedule by `serve`.
+type Schedule struct {
+	// Name identifies the schedule, and its last run in the state file.
+	Name string `yaml:"name"`
+	// Cron is the cron expression, `second minute hour day-of-month month day-of-week`,
+	// a five field expression without seconds, or a macro like `@daily`.
+	Cron string `yaml:"cron"`
+	// TimeZone is the time zone name the expression is evaluated in, e.g. `America/Los_Angeles`; defaults to utc.
+	TimeZone string `yaml:"timeZone"`
+	// Template is the name of the template to send; its text may use
+	// `{{ .Name }}` and `{{ .Time }}`, the time the run was scheduled for.
+	Template string `yaml:"template"`
+	// Notification is an inline notification to send instead of a template.
+	Notification *lametric.Notification `yaml:"notification"`
+	// Devices are the names of the devices to send to; defaults to all of them.
+	Devices []string `yaml:"devices"`
+	// Jitter, if set, delays each run by a random duration up to it.
+	Jitter time.Duration `yaml:"jitter"`
+	// Misfire is what's done with runs missed while `serve` wasn't running,
+	// one of `once` (the default), `skip` or `all`.
+	Misfire schedule.MisfirePolicy `yaml:"misfire"`
+	// MisfireGrace is how late a missed run can be and still be sent; defaults to an hour.
+	MisfireGrace time.Duration `yaml:"misfireGrace"`
+}
+
+// Location returns the time zone location.
+func (s Schedule) Location() (*time.Location, error) {
+	return time.LoadLocation(s.TimeZone)
+}
+
+// ParseCron parses the cron expression in the time zone.
+func (s Schedule) ParseCron() (*schedule.Cron, error) {
+	location, err := s.Location()
+	if err != nil {
+		return nil, err
+	}
+	return schedule.ParseCron(s.Cron, location)
+}
+
+// MisfireGraceOrDefault returns the misfire grace or a default.
+func (s Schedule) MisfireGraceOrDefault() time.Duration {
+	if s.MisfireGrace > 0 {
+		return s.MisfireGrace
+	}
+	return DefaultMisfireGrace
+}
+
+// validateSchedules returns an error if any schedules are invalid.
+func (c Config) validateSchedules() error {
+	names := make(map[string]bool)
+	for _, s := range c.Schedules {
+		if s.Name == "" {
+			return fmt.Errorf("schedule %q; name is required", s.Cron)
+		}
+		if names[s.Name] {
+			return fmt.Errorf("schedule %s; duplicate schedule name", s.Name)
+		}
+		names[s.Name] = true
+		if _, err := s.ParseCron(); err != nil {
+			return fmt.Errorf("schedule %s; %w", s.Name, err)
+		}
+		if (s.Template == "") == (s.Notification == nil) {
+			return fmt.Errorf("schedule %s; one of template or notification is required", s.Name)
+		}
+		if err := c.validateRoute(s.Template, s.Devices); err != nil {
+			return fmt.Errorf("schedule %s; %w", s.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package config
+
+import "net"
+
+// Server defaults.
+const (
+	// DefaultServerAddr is the default address `serve` listens on if a token is set.
+	DefaultServerAddr = ":9090"
+	// DefaultLoopbackServerAddr is the default address `serve` listens on without
+	// a token, so that notifications can only be sent from the host.
+	DefaultLoopbackServerAddr = "127.0.0.1:9090"
+)
+
+// Server is the config for `serve`.
+type Server struct {
+	// Addr is the address to listen on; defaults to `:9090` if a token is set,
+	// and `127.0.0.1:9090` otherwise.
+	Addr string `yaml:"addr"`
+	// Token is the bearer token required by the `/notify` and `/reminders`
+	// endpoints. Without a token, they're only served on loopback addresses.
+	Token string `yaml:"token"`
+}
+
+// AddrOrDefault returns the listen address or a default.
+func (s Server) AddrOrDefault() string {
+	if s.Addr != "" {
+		return s.Addr
+	}
+	if s.Token == "" {
+		return DefaultLoopbackServerAddr
+	}
+	return DefaultServerAddr
+}
+
+// APIAllowed returns if the `/notify` and `/reminders` apis may be served on a
+// given listen address, which requires a token unless the address is a loopback address.
+func (s Server) APIAllowed(addr string) bool {
+	return s.Token != "" || IsLoopbackAddr(addr)
+}
+
+// IsLoopbackAddr returns if a listen address only accepts connections from the host.
+func IsLoopbackAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
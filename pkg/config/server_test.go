@@ -0,0 +1,32 @@
+package config
+
+import "testing"
+
+func TestServerAPIAllowed(t *testing.T) {
+	testCases := []struct {
+		Server Server
+		Addr   string
+		Expect bool
+	}{
+		{Server: Server{}, Addr: Server{}.AddrOrDefault(), Expect: true},
+		{Server: Server{}, Addr: ":9090"},
+		{Server: Server{}, Addr: "0.0.0.0:9090"},
+		{Server: Server{}, Addr: "192.168.1.10:9090"},
+		{Server: Server{}, Addr: "localhost:9090", Expect: true},
+		{Server: Server{}, Addr: "[::1]:9090", Expect: true},
+		{Server: Server{}, Addr: "invalid"},
+		{Server: Server{Token: "secret"}, Addr: Server{Token: "secret"}.AddrOrDefault(), Expect: true},
+		{Server: Server{Token: "secret"}, Addr: "192.168.1.10:9090", Expect: true},
+	}
+	for _, tc := range testCases {
+		if actual := tc.Server.APIAllowed(tc.Addr); actual != tc.Expect {
+			t.Errorf("%+v on %q; expected %v, got %v", tc.Server, tc.Addr, tc.Expect, actual)
+		}
+	}
+	if addr := (Server{}).AddrOrDefault(); addr != DefaultLoopbackServerAddr {
+		t.Errorf("expected %s without a token, got %s", DefaultLoopbackServerAddr, addr)
+	}
+	if addr := (Server{Token: "secret"}).AddrOrDefault(); addr != DefaultServerAddr {
+		t.Errorf("expected %s with a token, got %s", DefaultServerAddr, addr)
+	}
+}
@@ -0,0 +1,27 @@
+package config
+
+import "time"
+
+// Timeouts are the timeouts for sending to a device; unset timeouts use the defaults.
+type Timeouts struct {
+	// Connect is the timeout to connect to the device.
+	Connect time.Duration `yaml:"connect,omitempty"`
+	// TLS is the tls handshake timeout.
+	TLS time.Duration `yaml:"tls,omitempty"`
+	// Request is the overall timeout of each request.
+	Request time.Duration `yaml:"request,omitempty"`
+}
+
+// Or returns the timeouts with unset values taken from a given fallback.
+func (t Timeouts) Or(fallback Timeouts) Timeouts {
+	if t.Connect == 0 {
+		t.Connect = fallback.Connect
+	}
+	if t.TLS == 0 {
+		t.TLS = fallback.TLS
+	}
+	if t.Request == 0 {
+		t.Request = fallback.Request
+	}
+	return t
+}
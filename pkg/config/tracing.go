@@ -0,0 +1,16 @@
+package config
+
+// Tracing is the config for notification delivery traces.
+type Tracing struct {
+	// Stdout writes a line per span to stdout.
+	Stdout bool `yaml:"stdout"`
+	// File, if set, is a path spans are appended to as otlp json lines.
+	File string `yaml:"file"`
+	// Service is the service name spans are exported with; defaults to `notifier`.
+	Service string `yaml:"service"`
+}
+
+// Enabled returns if any exporter is configured.
+func (t Tracing) Enabled() bool {
+	return t.Stdout || t.File != ""
+}
@@ -0,0 +1,264 @@
+package discovery
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/wcharczuk/lametric/pkg/apiutil"
+	"github.com/wcharczuk/lametric/pkg/async"
+)
+
+// Defaults
+const (
+	// DefaultAddr is the ssdp multicast address.
+	DefaultAddr = "239.255.255.250:1900"
+	// DefaultSearchTarget is the ssdp search target lametric devices respond to.
+	DefaultSearchTarget = "urn:schemas-upnp-org:device:LaMetric:1"
+	// DefaultTimeout is how long to wait for search responses.
+	DefaultTimeout = 3 * time.Second
+)
+
+// Search searches the local network for devices with the default options.
+func Search(ctx context.Context) ([]Device, error) {
+	return Searcher{}.Search(ctx)
+}
+
+// Resolve finds a device on the local network by serial number with the default options.
+func Resolve(ctx context.Context, serial string) (*Device, error) {
+	return Searcher{}.Resolve(ctx, serial)
+}
+
+// Device is a device found on the local network.
+type Device struct {
+	Name         string
+	Serial       string
+	Model        string
+	Manufacturer string
+	// Addr is the ip address of the device.
+	Addr string
+	// Location is the url of the device description.
+	Location string
+}
+
+// Searcher sends ssdp M-SEARCH queries and reads the device descriptions of the responses.
+type Searcher struct {
+	// Addr is the address queries are sent to; defaults to `DefaultAddr`.
+	Addr string
+	// SearchTarget is the ssdp search target; defaults to `DefaultSearchTarget`.
+	SearchTarget string
+	// Timeout is how long to wait for responses; defaults to `DefaultTimeout`.
+	Timeout time.Duration
+}
+
+// Search sends a query and returns the devices that respond before the timeout.
+//
+// Devices whose description can't be read are skipped; an error is only
+// returned if no device descriptions could be read.
+func (s Searcher) Search(ctx context.Context) ([]Device, error) {
+	locations, err := s.query(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var output []Device
+	var errs async.MultiError
+	for _, location := range locations {
+		device, err := s.describe(ctx, location)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		output = append(output, *device)
+	}
+	if len(output) == 0 && len(errs) > 0 {
+		return nil, errs
+	}
+	return output, nil
+}
+
+// Resolve finds a device by serial number.
+func (s Searcher) Resolve(ctx context.Context, serial string) (*Device, error) {
+	devices, err := s.Search(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, device := range devices {
+		if strings.EqualFold(device.Serial, serial) {
+			return &device, nil
+		}
+	}
+	return nil, fmt.Errorf("%w; %s", ErrDeviceNotFound, serial)
+}
+
+// query sends the M-SEARCH request and collects the distinct description
+// locations of the responses.
+func (s Searcher) query(ctx context.Context) ([]string, error) {
+	target, err := net.ResolveUDPAddr("udp4", s.addr())
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenUDP("udp4", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	timeout := s.timeout()
+	deadline := time.Now().Add(timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	if err = conn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+	parent := ctx
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		<-ctx.Done()
+		_ = conn.SetDeadline(time.Now())
+	}()
+
+	if _, err = conn.WriteTo(s.request(timeout), target); err != nil {
+		return nil, err
+	}
+
+	var locations []string
+	seen := make(map[string]bool)
+	buffer := make([]byte, 8192)
+	for {
+		n, from, err := conn.ReadFromUDP(buffer)
+		if err != nil {
+			if err := parent.Err(); err != nil {
+				return nil, err
+			}
+			if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+				break
+			}
+			return nil, err
+		}
+		location, ok := parseResponse(buffer[:n], s.searchTarget())
+		if !ok {
+			continue
+		}
+		location = fixLocationHost(location, from)
+		if !seen[location] {
+			seen[location] = true
+			locations = append(locations, location)
+		}
+	}
+	return locations, nil
+}
+
+func (s Searcher) request(timeout time.Duration) []byte {
+	mx := int(timeout / time.Second)
+	if mx < 1 {
+		mx = 1
+	}
+	return []byte(strings.Join([]string{
+		"M-SEARCH * HTTP/1.1",
+		"HOST: " + DefaultAddr,
+		`MAN: "ssdp:discover"`,
+		fmt.Sprintf("MX: %d", mx),
+		"ST: " + s.searchTarget(),
+		"", "",
+	}, "\r\n"))
+}
+
+// describe fetches and parses the device description at a given location.
+func (s Searcher) describe(ctx context.Context, location string) (*Device, error) {
+	_, contents, err := apiutil.New(location).Bytes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var description deviceDescription
+	if err := xml.Unmarshal(contents, &description); err != nil {
+		return nil, err
+	}
+	locationURL, err := url.Parse(location)
+	if err != nil {
+		return nil, err
+	}
+	return &Device{
+		Name:         description.Device.FriendlyName,
+		Serial:       description.Device.SerialNumber,
+		Model:        description.Device.ModelName,
+		Manufacturer: description.Device.Manufacturer,
+		Addr:         locationURL.Hostname(),
+		Location:     location,
+	}, nil
+}
+
+func (s Searcher) addr() string {
+	if s.Addr != "" {
+		return s.Addr
+	}
+	return DefaultAddr
+}
+
+func (s Searcher) searchTarget() string {
+	if s.SearchTarget != "" {
+		return s.SearchTarget
+	}
+	return DefaultSearchTarget
+}
+
+func (s Searcher) timeout() time.Duration {
+	if s.Timeout > 0 {
+		return s.Timeout
+	}
+	return DefaultTimeout
+}
+
+// parseResponse returns the location of a search response if it matches a given search target.
+func parseResponse(contents []byte, searchTarget string) (string, bool) {
+	res, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(contents)), nil)
+	if err != nil {
+		return "", false
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return "", false
+	}
+	if st := res.Header.Get("ST"); searchTarget != "ssdp:all" && st != "" && st != searchTarget {
+		return "", false
+	}
+	location := res.Header.Get("Location")
+	return location, location != ""
+}
+
+// fixLocationHost replaces an unspecified location host with the address the response came from.
+func fixLocationHost(location string, from *net.UDPAddr) string {
+	u, err := url.Parse(location)
+	if err != nil || from == nil {
+		return location
+	}
+	if host := u.Hostname(); host == "" || host == "0.0.0.0" {
+		if port := u.Port(); port != "" {
+			u.Host = net.JoinHostPort(from.IP.String(), port)
+		} else {
+			u.Host = from.IP.String()
+		}
+		return u.String()
+	}
+	return location
+}
+
+// deviceDescription is the upnp device description document.
+type deviceDescription struct {
+	XMLName xml.Name `xml:"root"`
+	Device  struct {
+		DeviceType   string `xml:"deviceType"`
+		FriendlyName string `xml:"friendlyName"`
+		Manufacturer string `xml:"manufacturer"`
+		ModelName    string `xml:"modelName"`
+		SerialNumber string `xml:"serialNumber"`
+	} `xml:"device"`
+}
@@ -0,0 +1,120 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// respond answers each search on a local udp listener with the given locations.
+func respond(t *testing.T, locations ...string) (addr string, searches <-chan string) {
+	t.Helper()
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	received := make(chan string, 8)
+	go func() {
+		buffer := make([]byte, 8192)
+		for {
+			n, from, err := conn.ReadFrom(buffer)
+			if err != nil {
+				return
+			}
+			received <- string(buffer[:n])
+			for _, location := range locations {
+				res := strings.Join([]string{
+					"HTTP/1.1 200 OK",
+					"CACHE-CONTROL: max-age=1800",
+					"ST: " + DefaultSearchTarget,
+					"LOCATION: " + location,
+					"", "",
+				}, "\r\n")
+				_, _ = conn.WriteTo([]byte(res), from)
+				// duplicate responses are collapsed.
+				_, _ = conn.WriteTo([]byte(res), from)
+			}
+			// responses for other search targets are ignored.
+			_, _ = conn.WriteTo([]byte("HTTP/1.1 200 OK\r\nST: upnp:rootdevice\r\nLOCATION: http://127.0.0.1:1/other.xml\r\n\r\n"), from)
+		}
+	}()
+	return conn.LocalAddr().String(), received
+}
+
+func describer(t *testing.T, name, serial string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		fmt.Fprintf(rw, `<?xml version="1.0"?>
+<root xmlns="urn:schemas-upnp-org:device-1-0">
+  <device>
+    <deviceType>urn:schemas-upnp-org:device:LaMetric:1</deviceType>
+    <friendlyName>%s</friendlyName>
+    <manufacturer>LaMetric Inc.</manufacturer>
+    <modelName>LaMetric Time</modelName>
+    <serialNumber>%s</serialNumber>
+  </device>
+</root>`, name, serial)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestSearch(t *testing.T) {
+	kitchen := describer(t, "Kitchen", "SA150800000001")
+	office := describer(t, "Office", "SA150800000002")
+	addr, searches := respond(t, kitchen.URL+"/description.xml", office.URL+"/description.xml")
+
+	devices, err := Searcher{Addr: addr, Timeout: 250 * time.Millisecond}.Search(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(devices) != 2 {
+		t.Fatalf("expected 2 devices, got %+v", devices)
+	}
+	if devices[0].Name != "Kitchen" || devices[0].Serial != "SA150800000001" || devices[0].Addr != "127.0.0.1" || devices[0].Model != "LaMetric Time" {
+		t.Errorf("unexpected device %+v", devices[0])
+	}
+	search := <-searches
+	for _, line := range []string{"M-SEARCH * HTTP/1.1", `MAN: "ssdp:discover"`, "ST: " + DefaultSearchTarget} {
+		if !strings.Contains(search, line) {
+			t.Errorf("expected the search to contain %q, got %q", line, search)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	office := describer(t, "Office", "SA150800000002")
+	addr, _ := respond(t, office.URL+"/description.xml")
+	searcher := Searcher{Addr: addr, Timeout: 250 * time.Millisecond}
+
+	device, err := searcher.Resolve(context.Background(), "sa150800000002")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if device.Name != "Office" {
+		t.Errorf("unexpected device %+v", device)
+	}
+	if _, err := searcher.Resolve(context.Background(), "SA150800000009"); !errors.Is(err, ErrDeviceNotFound) {
+		t.Errorf("expected %v, got %v", ErrDeviceNotFound, err)
+	}
+}
+
+func TestSearchCanceled(t *testing.T) {
+	addr, _ := respond(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	started := time.Now()
+	if _, err := (Searcher{Addr: addr, Timeout: 5 * time.Second}).Search(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected %v, got %v", context.Canceled, err)
+	}
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Errorf("expected the search to stop when canceled, took %v", elapsed)
+	}
+}
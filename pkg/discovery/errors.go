@@ -0,0 +1,12 @@
+package discovery
+
+// Error is an error constant.
+type Error string
+
+// Error implements error.
+func (e Error) Error() string { return string(e) }
+
+// Common Errors
+const (
+	ErrDeviceNotFound Error = "device not found on the local network"
+)
@@ -0,0 +1,13 @@
+package feed
+
+// Error is an error constant.
+type Error string
+
+// Error implements error.
+func (e Error) Error() string { return string(e) }
+
+// Common Errors
+const (
+	ErrFeedUnsupported Error = "unsupported feed; must be rss 2.0 or atom"
+	ErrNotModified     Error = "feed not modified"
+)
@@ -0,0 +1,237 @@
+package feed
+
+import (
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"time"
+)
+
+// Feed is a parsed rss or atom feed.
+type Feed struct {
+	Title string
+	Link  string
+	Items []Item
+}
+
+// Item is a feed entry.
+type Item struct {
+	// ID is the rss guid or atom id, or the link if there isn't one.
+	ID        string
+	Title     string
+	Link      string
+	Author    string
+	Summary   string
+	Published time.Time
+}
+
+// Parse parses an rss 2.0 or atom feed.
+func Parse(r io.Reader) (*Feed, error) {
+	decoder := xml.NewDecoder(r)
+	decoder.CharsetReader = charsetReader
+	decoder.Strict = false
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil, ErrFeedUnsupported
+		}
+		if err != nil {
+			return nil, err
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch start.Name.Local {
+		case "rss":
+			var doc rssDocument
+			if err := decoder.DecodeElement(&doc, &start); err != nil {
+				return nil, err
+			}
+			return doc.feed(), nil
+		case "feed":
+			var doc atomDocument
+			if err := decoder.DecodeElement(&doc, &start); err != nil {
+				return nil, err
+			}
+			return doc.feed(), nil
+		default:
+			return nil, fmt.Errorf("%w; root element %q", ErrFeedUnsupported, start.Name.Local)
+		}
+	}
+}
+
+type rssDocument struct {
+	Channel struct {
+		Title string    `xml:"title"`
+		Links []string  `xml:"link"`
+		Items []rssItem `xml:"item"`
+	} `xml:"channel"`
+}
+
+type rssItem struct {
+	GUID        string   `xml:"guid"`
+	Title       string   `xml:"title"`
+	Links       []string `xml:"link"`
+	Author      string   `xml:"author"`
+	Creator     string   `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Description string   `xml:"description"`
+	PubDate     string   `xml:"pubDate"`
+	Date        string   `xml:"http://purl.org/dc/elements/1.1/ date"`
+}
+
+func (doc rssDocument) feed() *Feed {
+	f := &Feed{Title: strings.TrimSpace(doc.Channel.Title), Link: firstNonEmpty(doc.Channel.Links...)}
+	for _, item := range doc.Channel.Items {
+		i := Item{
+			ID:        strings.TrimSpace(item.GUID),
+			Title:     strings.TrimSpace(item.Title),
+			Link:      firstNonEmpty(item.Links...),
+			Author:    strings.TrimSpace(firstNonEmpty(item.Author, item.Creator)),
+			Summary:   plainText(item.Description),
+			Published: parseDate(firstNonEmpty(item.PubDate, item.Date)),
+		}
+		f.Items = append(f.Items, i.withID())
+	}
+	return f
+}
+
+type atomDocument struct {
+	Title   string      `xml:"title"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+}
+
+type atomEntry struct {
+	ID        string     `xml:"id"`
+	Title     string     `xml:"title"`
+	Links     []atomLink `xml:"link"`
+	Published string     `xml:"published"`
+	Updated   string     `xml:"updated"`
+	Summary   string     `xml:"summary"`
+	Content   string     `xml:"content"`
+	Authors   []struct {
+		Name string `xml:"name"`
+	} `xml:"author"`
+}
+
+func (doc atomDocument) feed() *Feed {
+	f := &Feed{Title: strings.TrimSpace(doc.Title), Link: alternateLink(doc.Links)}
+	for _, entry := range doc.Entries {
+		i := Item{
+			ID:        strings.TrimSpace(entry.ID),
+			Title:     plainText(entry.Title),
+			Link:      alternateLink(entry.Links),
+			Summary:   plainText(firstNonEmpty(entry.Summary, entry.Content)),
+			Published: parseDate(firstNonEmpty(entry.Published, entry.Updated)),
+		}
+		if len(entry.Authors) > 0 {
+			i.Author = strings.TrimSpace(entry.Authors[0].Name)
+		}
+		f.Items = append(f.Items, i.withID())
+	}
+	return f
+}
+
+// alternateLink returns the `alternate` link, or the first link.
+func alternateLink(links []atomLink) string {
+	for _, link := range links {
+		if link.Rel == "" || link.Rel == "alternate" {
+			return strings.TrimSpace(link.Href)
+		}
+	}
+	if len(links) > 0 {
+		return strings.TrimSpace(links[0].Href)
+	}
+	return ""
+}
+
+// withID sets the id to the link, or the title and date, if it's empty.
+func (i Item) withID() Item {
+	if i.ID == "" {
+		i.ID = i.Link
+	}
+	if i.ID == "" {
+		i.ID = i.Title + "@" + i.Published.UTC().Format(time.RFC3339)
+	}
+	return i
+}
+
+// dateLayouts are the layouts feed dates are parsed with, rfc 822 variants and then rfc 3339.
+var dateLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	"2 Jan 2006 15:04:05 MST",
+	"Mon, 2 Jan 2006 15:04 -0700",
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+// parseDate parses a feed date, returning the zero time if it's invalid.
+func parseDate(value string) time.Time {
+	value = strings.TrimSpace(value)
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+// plainText returns html as text, without tags and with collapsed whitespace.
+func plainText(value string) string {
+	var output strings.Builder
+	inTag := false
+	for _, r := range value {
+		switch {
+		case r == '<':
+			inTag = true
+		case r == '>' && inTag:
+			inTag = false
+			output.WriteRune(' ')
+		case !inTag:
+			output.WriteRune(r)
+		}
+	}
+	return strings.Join(strings.Fields(html.UnescapeString(output.String())), " ")
+}
+
+// charsetReader decodes latin-1 feeds; other charsets than utf-8 aren't supported.
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	switch strings.ToLower(charset) {
+	case "utf-8", "us-ascii", "ascii":
+		return input, nil
+	case "iso-8859-1", "latin1", "latin-1", "windows-1252":
+		contents, err := io.ReadAll(input)
+		if err != nil {
+			return nil, err
+		}
+		var output strings.Builder
+		for _, b := range contents {
+			output.WriteRune(rune(b))
+		}
+		return strings.NewReader(output.String()), nil
+	default:
+		return nil, fmt.Errorf("%w; charset %q", ErrFeedUnsupported, charset)
+	}
+}
@@ -0,0 +1,98 @@
+package feed
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func parseTestFeed(t *testing.T, path string) *Feed {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	parsed, err := Parse(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed
+}
+
+func TestParse(t *testing.T) {
+	testCases := [...]struct {
+		Path     string
+		Expected Feed
+	}{
+		{
+			Path: "testdata/rss.xml",
+			Expected: Feed{
+				Title: "Status Blog",
+				Link:  "https://status.example.com/",
+				Items: []Item{
+					{
+						ID:        "incident-42",
+						Title:     "Elevated error rates",
+						Link:      "https://status.example.com/incidents/42",
+						Author:    "On call",
+						Summary:   "We're investigating elevated error rates & latency.",
+						Published: time.Date(2026, 10, 19, 14, 5, 0, 0, time.UTC),
+					},
+					{
+						// without a guid the link is the id.
+						ID:        "https://status.example.com/maintenance/7",
+						Title:     "Scheduled maintenance",
+						Link:      "https://status.example.com/maintenance/7",
+						Author:    "ops@example.com (Ops)",
+						Summary:   "Database upgrade from 02:00 to 04:00 UTC.",
+						Published: time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC),
+					},
+					{
+						ID:        "incident-41",
+						Title:     "Resolved: login failures",
+						Link:      "https://status.example.com/incidents/41",
+						Published: time.Date(2026, 10, 16, 22, 30, 0, 0, time.UTC),
+					},
+				},
+			},
+		},
+		{
+			Path: "testdata/atom.xml",
+			Expected: Feed{
+				Title: "Releases",
+				Link:  "https://example.com/releases",
+				Items: []Item{
+					{
+						ID:        "tag:example.com,2026:release-2.1.0",
+						Title:     "v2.1.0 beta",
+						Link:      "https://example.com/releases/2.1.0",
+						Author:    "Release Bot",
+						Summary:   "Faster sync Fixes",
+						Published: time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC),
+					},
+					{
+						ID:        "tag:example.com,2026:release-2.0.1",
+						Title:     "v2.0.1",
+						Link:      "https://example.com/releases/2.0.1",
+						Summary:   "Patch release.",
+						Published: time.Date(2026, 10, 12, 6, 0, 0, 0, time.UTC),
+					},
+				},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Path, func(t *testing.T) {
+			actual := parseTestFeed(t, tc.Path)
+			// dates are compared as instants, they keep the offset they were written with.
+			for index := range actual.Items {
+				actual.Items[index].Published = actual.Items[index].Published.UTC()
+			}
+			if !reflect.DeepEqual(tc.Expected, *actual) {
+				t.Errorf("expected %+v\nactual %+v", tc.Expected, *actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/wcharczuk/lametric/pkg/apiutil"
+)
+
+// Validators are the response headers of the last fetch a conditional fetch is made with.
+type Validators struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+}
+
+// Fetch fetches and parses a feed with a given client, with a conditional request
+// if validators are given, returning the validators for the next fetch.
+//
+// It returns `ErrNotModified` if the feed hasn't changed since the validators.
+func Fetch(ctx context.Context, client apiutil.Client, validators Validators) (*Feed, Validators, error) {
+	opts := []apiutil.RequestOption{
+		apiutil.OptHeader("Accept", "application/rss+xml, application/atom+xml, application/xml;q=0.9, text/xml;q=0.8"),
+	}
+	if validators.ETag != "" {
+		opts = append(opts, apiutil.OptHeader("If-None-Match", validators.ETag))
+	}
+	if validators.LastModified != "" {
+		opts = append(opts, apiutil.OptHeader("If-Modified-Since", validators.LastModified))
+	}
+	res, err := client.Do(ctx, opts...)
+	if res != nil {
+		defer res.Body.Close()
+	}
+	var statusErr *apiutil.StatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotModified {
+		return nil, validators, ErrNotModified
+	}
+	if err != nil {
+		return nil, validators, err
+	}
+	feed, err := Parse(res.Body)
+	if err != nil {
+		return nil, validators, err
+	}
+	return feed, Validators{ETag: res.Header.Get("ETag"), LastModified: res.Header.Get("Last-Modified")}, nil
+}
@@ -0,0 +1,106 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/wcharczuk/lametric/pkg/apiutil"
+)
+
+// feedServer serves a feed fixture with a given etag and last modified time,
+// answering conditional requests that match them with not modified.
+type feedServer struct {
+	Path         string
+	ETag         string
+	LastModified string
+
+	mu         sync.Mutex
+	conditions []string
+}
+
+func (fs *feedServer) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	fs.mu.Lock()
+	fs.conditions = append(fs.conditions, req.Header.Get("If-None-Match")+"|"+req.Header.Get("If-Modified-Since"))
+	fs.mu.Unlock()
+	if fs.ETag != "" {
+		rw.Header().Set("ETag", fs.ETag)
+		if req.Header.Get("If-None-Match") == fs.ETag {
+			rw.WriteHeader(http.StatusNotModified)
+			return
+		}
+	} else if fs.LastModified != "" && req.Header.Get("If-Modified-Since") == fs.LastModified {
+		rw.WriteHeader(http.StatusNotModified)
+		return
+	}
+	if fs.LastModified != "" {
+		rw.Header().Set("Last-Modified", fs.LastModified)
+	}
+	contents, err := os.ReadFile(fs.Path)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	rw.Header().Set("Content-Type", "application/xml")
+	_, _ = rw.Write(contents)
+}
+
+func TestFetchConditional(t *testing.T) {
+	testCases := [...]struct {
+		Name   string
+		Server *feedServer
+	}{
+		{Name: "etag", Server: &feedServer{Path: "testdata/rss.xml", ETag: `"v1"`, LastModified: "Mon, 19 Oct 2026 14:05:00 GMT"}},
+		{Name: "last modified", Server: &feedServer{Path: "testdata/atom.xml", LastModified: "Mon, 19 Oct 2026 12:30:00 GMT"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			server := httptest.NewServer(tc.Server)
+			defer server.Close()
+			client := apiutil.New(server.URL)
+
+			parsed, validators, err := Fetch(context.Background(), client, Validators{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(parsed.Items) == 0 {
+				t.Fatal("expected the feed items")
+			}
+			expected := Validators{ETag: tc.Server.ETag, LastModified: tc.Server.LastModified}
+			if validators != expected {
+				t.Fatalf("expected validators %+v, actual %+v", expected, validators)
+			}
+
+			parsed, next, err := Fetch(context.Background(), client, validators)
+			if !errors.Is(err, ErrNotModified) {
+				t.Fatalf("expected not modified, actual %v", err)
+			}
+			if parsed != nil || next != validators {
+				t.Errorf("expected no feed and the same validators, actual %v and %+v", parsed, next)
+			}
+			conditions := tc.Server.conditions
+			if len(conditions) != 2 || conditions[0] != "|" || conditions[1] != validators.ETag+"|"+validators.LastModified {
+				t.Errorf("unexpected conditional headers %q", conditions)
+			}
+		})
+	}
+}
+
+func TestFetchError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		http.Error(rw, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	validators := Validators{ETag: `"v1"`}
+	_, next, err := Fetch(context.Background(), apiutil.New(server.URL), validators)
+	if err == nil || errors.Is(err, ErrNotModified) {
+		t.Fatalf("expected an error, actual %v", err)
+	}
+	if next != validators {
+		t.Errorf("expected the validators to be kept, actual %+v", next)
+	}
+}
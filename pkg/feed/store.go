@@ -0,0 +1,123 @@
+package feed
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/wcharczuk/lametric/pkg/fileutil"
+)
+
+// DefaultSeenRetention is how long seen item ids that are no longer in a feed are kept.
+const DefaultSeenRetention = 30 * 24 * time.Hour
+
+// State is the persisted state of a feed.
+type State struct {
+	Validators
+	// Seen are the ids of the items that have been seen, to when they were first seen.
+	Seen map[string]time.Time `json:"seen"`
+}
+
+// Polled returns if the feed has been polled before.
+func (s State) Polled() bool {
+	return s.Seen != nil
+}
+
+// Unseen returns the items that haven't been seen, in feed order.
+func (s State) Unseen(items []Item) (output []Item) {
+	for _, item := range items {
+		if _, ok := s.Seen[item.ID]; !ok {
+			output = append(output, item)
+		}
+	}
+	return
+}
+
+// MarkSeen marks items as seen, and removes the ids of items that are no
+// longer in the feed and were first seen longer than the retention ago.
+func (s *State) MarkSeen(items []Item, now time.Time, retention time.Duration) {
+	if s.Seen == nil {
+		s.Seen = make(map[string]time.Time)
+	}
+	current := make(map[string]bool, len(items))
+	for _, item := range items {
+		current[item.ID] = true
+		if _, ok := s.Seen[item.ID]; !ok {
+			s.Seen[item.ID] = now.UTC()
+		}
+	}
+	for id, seen := range s.Seen {
+		if !current[id] && now.Sub(seen) > retention {
+			delete(s.Seen, id)
+		}
+	}
+}
+
+// MarkItemSeen marks a single item as seen, e.g. once it's been announced.
+func (s *State) MarkItemSeen(item Item, now time.Time) {
+	if s.Seen == nil {
+		s.Seen = make(map[string]time.Time)
+	}
+	if _, ok := s.Seen[item.ID]; !ok {
+		s.Seen[item.ID] = now.UTC()
+	}
+}
+
+// NewStore returns a store backed by a json file, reading it if it exists.
+func NewStore(path string) (*Store, error) {
+	store := &Store{Path: path, states: make(map[string]State)}
+	contents, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(contents) > 0 {
+		if err := json.Unmarshal(contents, &store.states); err != nil {
+			return nil, err
+		}
+	}
+	return store, nil
+}
+
+// Store persists the state of feeds by name in a json file.
+type Store struct {
+	Path string
+
+	mu     sync.Mutex
+	states map[string]State
+}
+
+// State returns a copy of the state of a feed.
+func (s *Store) State(name string) State {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state := s.states[name]
+	if state.Seen != nil {
+		seen := make(map[string]time.Time, len(state.Seen))
+		for id, t := range state.Seen {
+			seen[id] = t
+		}
+		state.Seen = seen
+	}
+	return state
+}
+
+// SetState sets the state of a feed, writing the file.
+func (s *Store) SetState(name string, state State) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.states == nil {
+		s.states = make(map[string]State)
+	}
+	s.states[name] = state
+	contents, err := json.MarshalIndent(s.states, "", "  ")
+	if err != nil {
+		return err
+	}
+	return fileutil.WriteFileAtomic(s.Path, append(contents, '\n'), 0644)
+}
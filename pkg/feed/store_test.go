@@ -0,0 +1,65 @@
+package feed
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStorePersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "feeds.json")
+	store, err := NewStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if store.State("status").Polled() {
+		t.Fatal("expected a new feed not to be polled")
+	}
+
+	now := time.Date(2026, 10, 19, 15, 0, 0, 0, time.UTC)
+	items := parseTestFeed(t, "testdata/rss.xml").Items
+	state := State{Validators: Validators{ETag: `"v1"`}}
+	state.MarkSeen(items[1:], now, DefaultSeenRetention)
+	if err := store.SetState("status", state); err != nil {
+		t.Fatal(err)
+	}
+	state.MarkItemSeen(items[0], now.Add(time.Minute))
+	if err := store.SetState("status", state); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := NewStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	persisted := reopened.State("status")
+	if persisted.ETag != `"v1"` || len(persisted.Unseen(items)) != 0 {
+		t.Errorf("expected the validators and seen items to be persisted, actual %+v", persisted)
+	}
+	if !persisted.Seen[items[0].ID].Equal(now.Add(time.Minute)) {
+		t.Errorf("expected the item to be seen when it was marked, actual %v", persisted.Seen[items[0].ID])
+	}
+	// state is a copy, it's only changed by setting it.
+	persisted.MarkItemSeen(Item{ID: "other"}, now)
+	if _, ok := reopened.State("status").Seen["other"]; ok {
+		t.Error("expected the store state to be unchanged")
+	}
+}
+
+func TestStateMarkSeenRetention(t *testing.T) {
+	now := time.Date(2026, 10, 19, 15, 0, 0, 0, time.UTC)
+	state := State{Seen: map[string]time.Time{
+		"current": now.Add(-60 * 24 * time.Hour),
+		"recent":  now.Add(-time.Hour),
+		"old":     now.Add(-60 * 24 * time.Hour),
+	}}
+	state.MarkSeen([]Item{{ID: "current"}, {ID: "new"}}, now, DefaultSeenRetention)
+	for _, id := range []string{"current", "recent", "new"} {
+		if _, ok := state.Seen[id]; !ok {
+			t.Errorf("expected %s to be kept", id)
+		}
+	}
+	if _, ok := state.Seen["old"]; ok {
+		t.Error("expected old to be removed")
+	}
+}
@@ -0,0 +1,41 @@
+package fileutil
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Lock takes an exclusive os file lock on the file at a given path, creating it
+// (and its directory) if it doesn't exist, and waits until other processes or
+// other locks on it release it.
+//
+// Lock files are usually kept next to the file they guard, e.g. `state.json.lock`,
+// because the guarded file itself may be replaced.
+func Lock(path string) (*FileLock, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &FileLock{f: f}, nil
+}
+
+// FileLock is a held lock on a lock file.
+type FileLock struct {
+	f *os.File
+}
+
+// Unlock releases the lock.
+func (fl *FileLock) Unlock() error {
+	err := unlockFile(fl.f)
+	if closeErr := fl.f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
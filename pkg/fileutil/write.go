@@ -0,0 +1,47 @@
+package fileutil
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// WriteFileAtomic writes a file by writing a temporary file in the same
+// directory (creating it if it doesn't exist) and renaming it over the path,
+// so readers and crashes never see a partial file.
+//
+// Each write has its own temporary file, so concurrent writers don't write
+// over each other's; the last rename wins.
+func WriteFileAtomic(path string, contents []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	temp := f.Name()
+	fail := func(err error) error {
+		f.Close()
+		os.Remove(temp)
+		return err
+	}
+	if _, err := f.Write(contents); err != nil {
+		return fail(err)
+	}
+	if err := f.Chmod(perm); err != nil {
+		return fail(err)
+	}
+	if err := f.Sync(); err != nil {
+		return fail(err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(temp)
+		return err
+	}
+	if err := os.Rename(temp, path); err != nil {
+		os.Remove(temp)
+		return err
+	}
+	return nil
+}
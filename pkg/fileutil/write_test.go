@@ -0,0 +1,49 @@
+package fileutil
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state", "feeds.json")
+
+	var wg sync.WaitGroup
+	for index := 0; index < 16; index++ {
+		wg.Add(1)
+		go func(index int) {
+			defer wg.Done()
+			if err := WriteFileAtomic(path, []byte(fmt.Sprintf("{\"writer\": %d}\n", index)), 0600); err != nil {
+				t.Error(err)
+			}
+		}(index)
+	}
+	wg.Wait()
+
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var writer int
+	if _, err := fmt.Sscanf(string(contents), "{\"writer\": %d}\n", &writer); err != nil {
+		t.Fatalf("expected one whole write, actual %q", contents)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("expected the file mode to be 0600, actual %v", perm)
+	}
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("expected the temporary files to be renamed, actual %d files", len(entries))
+	}
+}
@@ -0,0 +1,222 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/wcharczuk/lametric/pkg/apiutil"
+	"github.com/wcharczuk/lametric/pkg/lametric"
+)
+
+// Defaults
+const (
+	DefaultInterval = time.Minute
+	DefaultTimeout  = 10 * time.Second
+)
+
+// Target is a device to check.
+type Target struct {
+	Name   string
+	Client lametric.Client
+}
+
+// Status is the result of checking a device.
+type Status struct {
+	Name string
+	// Reachable is if the device responded, even if it rejected the api key.
+	Reachable bool
+	// Authorized is if the device accepted the api key.
+	Authorized     bool
+	Model          string
+	Serial         string
+	Firmware       string
+	QueueDepth     int
+	Brightness     int
+	BrightnessMode lametric.BrightnessMode
+	Volume         int
+	CheckedAt      time.Time
+	// LastSeen is when the device was last reachable.
+	LastSeen time.Time
+	// Failures is the number of consecutive checks the device was unreachable.
+	Failures int
+	Err      error
+}
+
+// Up returns if the device is reachable and authorized.
+func (s Status) Up() bool {
+	return s.Reachable && s.Authorized
+}
+
+// EventKind is a kind of health event.
+type EventKind string
+
+// Event kinds.
+const (
+	EventUnreachable  EventKind = "unreachable"
+	EventUnauthorized EventKind = "unauthorized"
+	EventRecovered    EventKind = "recovered"
+)
+
+// Event is a change in the health of a device.
+type Event struct {
+	Kind   EventKind
+	Status Status
+}
+
+// Checker periodically checks the health of devices.
+type Checker struct {
+	Targets []Target
+	// Interval is how often devices are checked; defaults to a minute.
+	Interval time.Duration
+	// Timeout is the timeout of each device check; defaults to ten seconds.
+	Timeout time.Duration
+	// FailureThreshold is the number of consecutive failed checks before
+	// a device is considered unreachable; defaults to one.
+	FailureThreshold int
+	// OnStatus is called with the status of each device after it's checked.
+	OnStatus func(Status)
+	// OnEvent is called when a device becomes unreachable or unauthorized, or
+	// recovers, with the context the checker runs with.
+	OnEvent func(context.Context, Event)
+
+	mu       sync.Mutex
+	statuses map[string]Status
+	down     map[string]EventKind
+}
+
+// Run checks the devices immediately, then on the interval, until the context is done.
+func (c *Checker) Run(ctx context.Context) error {
+	interval := c.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		c.Check(ctx)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check checks each device concurrently and returns their statuses.
+func (c *Checker) Check(ctx context.Context) []Status {
+	statuses := make([]Status, len(c.Targets))
+	wg := sync.WaitGroup{}
+	wg.Add(len(c.Targets))
+	for x := 0; x < len(c.Targets); x++ {
+		go func(index int) {
+			defer wg.Done()
+			statuses[index] = c.check(ctx, c.Targets[index])
+		}(x)
+	}
+	wg.Wait()
+	return statuses
+}
+
+// Statuses returns the last status of each checked device, sorted by name.
+func (c *Checker) Statuses() []Status {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	output := make([]Status, 0, len(c.statuses))
+	for _, status := range c.statuses {
+		output = append(output, status)
+	}
+	sort.Slice(output, func(i, j int) bool { return output[i].Name < output[j].Name })
+	return output
+}
+
+func (c *Checker) check(ctx context.Context, target Target) Status {
+	status := probe(ctx, target, c.Timeout)
+
+	c.mu.Lock()
+	if c.statuses == nil {
+		c.statuses = make(map[string]Status)
+		c.down = make(map[string]EventKind)
+	}
+	previous := c.statuses[target.Name]
+	if !status.Reachable {
+		status.LastSeen = previous.LastSeen
+		status.Failures = previous.Failures + 1
+	}
+	c.statuses[target.Name] = status
+	kind, changed := c.transition(status)
+	c.mu.Unlock()
+
+	if c.OnStatus != nil {
+		c.OnStatus(status)
+	}
+	if changed && c.OnEvent != nil {
+		c.OnEvent(ctx, Event{Kind: kind, Status: status})
+	}
+	return status
+}
+
+// transition returns the event for a status if the device health changed.
+func (c *Checker) transition(status Status) (EventKind, bool) {
+	threshold := c.FailureThreshold
+	if threshold <= 0 {
+		threshold = 1
+	}
+	var kind EventKind
+	switch {
+	case !status.Reachable && status.Failures >= threshold:
+		kind = EventUnreachable
+	case !status.Reachable:
+		// not unreachable for long enough to change
+		return "", false
+	case !status.Authorized:
+		kind = EventUnauthorized
+	default:
+		kind = EventRecovered
+	}
+	previous, wasDown := c.down[status.Name]
+	if kind == EventRecovered {
+		delete(c.down, status.Name)
+		return kind, wasDown
+	}
+	c.down[status.Name] = kind
+	return kind, previous != kind
+}
+
+// probe checks a single device.
+func probe(ctx context.Context, target Target, timeout time.Duration) Status {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	status := Status{Name: target.Name, CheckedAt: time.Now().UTC()}
+	device, err := target.Client.GetDevice(ctx)
+	if err != nil {
+		status.Err = err
+		var statusErr *apiutil.StatusError
+		if errors.As(err, &statusErr) {
+			status.Reachable = true
+			status.LastSeen = status.CheckedAt
+			// other status codes are a device problem rather than the api key
+			status.Authorized = statusErr.StatusCode != http.StatusUnauthorized && statusErr.StatusCode != http.StatusForbidden
+		}
+		return status
+	}
+	status.Reachable, status.Authorized, status.LastSeen = true, true, status.CheckedAt
+	status.Model, status.Serial, status.Firmware = device.Model, device.SerialNumber, device.OSVersion
+	status.Brightness, status.BrightnessMode = device.Display.Brightness, device.Display.BrightnessMode
+	status.Volume = device.Audio.Volume
+
+	queue, err := target.Client.GetNotifications(ctx)
+	if err != nil {
+		status.Err = err
+		return status
+	}
+	status.QueueDepth = len(queue)
+	return status
+}
@@ -0,0 +1,290 @@
+package ical
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Event statuses.
+const (
+	StatusTentative = "TENTATIVE"
+	StatusConfirmed = "CONFIRMED"
+	StatusCancelled = "CANCELLED"
+)
+
+// Calendar is a parsed iCalendar file.
+type Calendar struct {
+	Name   string
+	Events []Event
+}
+
+// Event is a `VEVENT`; recurring events have a rule or dates, and events
+// that change a single occurrence of one have a recurrence id.
+type Event struct {
+	UID         string
+	Summary     string
+	Location    string
+	Description string
+	Status      string
+	// Transparent is if the event doesn't block time, e.g. a reminder.
+	Transparent bool
+	Start       time.Time
+	End         time.Time
+	AllDay      bool
+
+	RRule   *RRule
+	RDates  []time.Time
+	ExDates []time.Time
+	// RecurrenceID is the original start of the occurrence the event replaces.
+	RecurrenceID time.Time
+
+	zone zone
+}
+
+// Occurrence is a single occurrence of an event.
+type Occurrence struct {
+	Event Event
+	Start time.Time
+	End   time.Time
+}
+
+// Parse parses an iCalendar stream, with floating times in the calendar
+// `X-WR-TIMEZONE` if it's set and otherwise in local time.
+func Parse(r io.Reader) (*Calendar, error) {
+	return ParseInLocation(r, nil)
+}
+
+// ParseInLocation parses an iCalendar stream, with floating times in a given
+// location (local time if nil) rather than the calendar `X-WR-TIMEZONE`.
+func ParseInLocation(r io.Reader, location *time.Location) (*Calendar, error) {
+	roots, err := parseComponents(r)
+	if err != nil {
+		return nil, err
+	}
+	var root *component
+	for _, c := range roots {
+		if c.Name == "VCALENDAR" {
+			root = c
+			break
+		}
+	}
+	if root == nil {
+		return nil, fmt.Errorf("%w; missing VCALENDAR", ErrCalendarInvalid)
+	}
+
+	z := &zones{defined: make(map[string]*vtimezone)}
+	for _, c := range root.Components {
+		if c.Name == "VTIMEZONE" {
+			v, err := parseVTimezone(c)
+			if err != nil {
+				return nil, err
+			}
+			z.defined[v.id] = v
+		}
+	}
+	switch {
+	case location != nil:
+		z.floating = locationZone{location}
+	case root.text("X-WR-TIMEZONE") != "":
+		if z.floating, err = z.lookup(root.text("X-WR-TIMEZONE")); err != nil {
+			return nil, err
+		}
+	default:
+		z.floating = locationZone{time.Local}
+	}
+
+	calendar := &Calendar{Name: root.text("X-WR-CALNAME")}
+	for _, c := range root.Components {
+		if c.Name != "VEVENT" {
+			continue
+		}
+		event, err := parseEvent(z, c)
+		if err != nil {
+			return nil, err
+		}
+		calendar.Events = append(calendar.Events, event)
+	}
+	return calendar, nil
+}
+
+// parseEvent parses a `VEVENT` component.
+func parseEvent(z *zones, c *component) (Event, error) {
+	e := Event{
+		UID:         c.text("UID"),
+		Summary:     c.text("SUMMARY"),
+		Location:    c.text("LOCATION"),
+		Description: c.text("DESCRIPTION"),
+		Status:      strings.ToUpper(c.text("STATUS")),
+		Transparent: strings.EqualFold(c.text("TRANSP"), "TRANSPARENT"),
+	}
+	fail := func(err error) (Event, error) {
+		return Event{}, fmt.Errorf("event %q; %w", e.UID, err)
+	}
+	p, ok := c.first("DTSTART")
+	if !ok {
+		return fail(fmt.Errorf("%w; missing DTSTART", ErrCalendarInvalid))
+	}
+	start, err := z.parseDateTime(p)
+	if err != nil {
+		return fail(err)
+	}
+	e.Start, e.AllDay, e.zone = start.Time, start.AllDay, start.Zone
+
+	if p, ok := c.first("DTEND"); ok {
+		end, err := z.parseDateTime(p)
+		if err != nil {
+			return fail(err)
+		}
+		e.End = end.Time
+	} else if p, ok := c.first("DURATION"); ok {
+		duration, err := parseDuration(p.Value)
+		if err != nil {
+			return fail(err)
+		}
+		e.End = e.Start.Add(duration)
+		if e.AllDay && duration%(24*time.Hour) == 0 {
+			e.End = e.zone.at(e.zone.wall(e.Start).AddDate(0, 0, int(duration/(24*time.Hour))))
+		}
+	} else if e.AllDay {
+		e.End = e.zone.at(e.zone.wall(e.Start).AddDate(0, 0, 1))
+	} else {
+		e.End = e.Start
+	}
+	if e.End.Before(e.Start) {
+		e.End = e.Start
+	}
+
+	if p, ok := c.first("RRULE"); ok {
+		if e.RRule, err = ParseRRule(p.Value); err != nil {
+			return fail(err)
+		}
+	}
+	for _, p := range c.all("RDATE") {
+		values, err := z.parseDateTimes(p)
+		if err != nil {
+			return fail(err)
+		}
+		for _, value := range values {
+			e.RDates = append(e.RDates, value.Time)
+		}
+	}
+	for _, p := range c.all("EXDATE") {
+		values, err := z.parseDateTimes(p)
+		if err != nil {
+			return fail(err)
+		}
+		for _, value := range values {
+			e.ExDates = append(e.ExDates, value.Time)
+		}
+	}
+	if p, ok := c.first("RECURRENCE-ID"); ok {
+		id, err := z.parseDateTime(p)
+		if err != nil {
+			return fail(err)
+		}
+		e.RecurrenceID = id.Time
+	}
+	return e, nil
+}
+
+// Recurring returns if the event has a rule or dates.
+func (e Event) Recurring() bool {
+	return e.RRule != nil || len(e.RDates) > 0
+}
+
+// Occurrences returns the occurrences of the events that overlap a time range,
+// ordered by start, with recurring events expanded, excluded dates removed,
+// single occurrences replaced by their changed events, and cancelled
+// occurrences removed.
+func (c *Calendar) Occurrences(from, to time.Time) []Occurrence {
+	overrides := make(map[string]bool)
+	for _, e := range c.Events {
+		if !e.RecurrenceID.IsZero() {
+			overrides[occurrenceKey(e.UID, e.RecurrenceID)] = true
+		}
+	}
+	var output []Occurrence
+	add := func(e Event, start time.Time) {
+		end := start.Add(e.End.Sub(e.Start))
+		if e.AllDay && e.zone != nil {
+			// all day events keep their length in days across daylight saving changes.
+			days := int(e.zone.wall(e.End).Sub(e.zone.wall(e.Start)) / (24 * time.Hour))
+			end = e.zone.at(e.zone.wall(start).AddDate(0, 0, days))
+		}
+		if start.Before(to) && (end.After(from) || (end.Equal(start) && !start.Before(from))) {
+			output = append(output, Occurrence{Event: e, Start: start, End: end})
+		}
+	}
+	for _, e := range c.Events {
+		if e.Status == StatusCancelled {
+			continue
+		}
+		if !e.RecurrenceID.IsZero() || !e.Recurring() {
+			add(e, e.Start)
+			continue
+		}
+		for _, start := range e.starts(to) {
+			if overrides[occurrenceKey(e.UID, start)] || e.excluded(start) {
+				continue
+			}
+			add(e, start)
+		}
+	}
+	sort.SliceStable(output, func(i, j int) bool { return output[i].Start.Before(output[j].Start) })
+	return output
+}
+
+// starts returns the starts of a recurring event before a given time.
+func (e Event) starts(before time.Time) []time.Time {
+	seen := make(map[int64]bool)
+	var output []time.Time
+	add := func(start time.Time) {
+		if !seen[start.Unix()] {
+			seen[start.Unix()] = true
+			output = append(output, start)
+		}
+	}
+	if e.RRule != nil {
+		rule := *e.RRule
+		if rule.UntilUTC {
+			rule.Until = e.zone.wall(rule.Until)
+		}
+		rule.Each(e.zone.wall(e.Start), func(wall time.Time) bool {
+			start := e.zone.at(wall)
+			if !start.Before(before) {
+				return false
+			}
+			add(start)
+			return true
+		})
+	} else {
+		add(e.Start)
+	}
+	for _, rdate := range e.RDates {
+		if rdate.Before(before) {
+			add(rdate)
+		}
+	}
+	return output
+}
+
+// excluded returns if an occurrence start is an excluded date.
+func (e Event) excluded(start time.Time) bool {
+	for _, exdate := range e.ExDates {
+		if exdate.Equal(start) {
+			return true
+		}
+		// dates exclude the whole day of all day events.
+		if e.AllDay && e.zone.wall(exdate).Format("20060102") == e.zone.wall(start).Format("20060102") {
+			return true
+		}
+	}
+	return false
+}
+
+func occurrenceKey(uid string, start time.Time) string {
+	return fmt.Sprintf("%s@%d", uid, start.Unix())
+}
@@ -0,0 +1,140 @@
+package ical
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func loadTestCalendar(t *testing.T, path string, location *time.Location) *Calendar {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	calendar, err := ParseInLocation(f, location)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return calendar
+}
+
+func TestCalendarOccurrences(t *testing.T) {
+	calendar := loadTestCalendar(t, "testdata/office.ics", time.UTC)
+	if calendar.Name != "Office" {
+		t.Errorf("expected the calendar name, actual %q", calendar.Name)
+	}
+
+	testCases := [...]struct {
+		Name     string
+		UID      string
+		From, To string
+		// Expected are the occurrence starts in utc, with their summary.
+		Expected []string
+	}{
+		{
+			// the defined time zone ends daylight saving on the 25th, the excluded
+			// friday is left out and the wednesday after is moved.
+			Name: "weekly by day with until, exdate and recurrence id",
+			UID:  "standup",
+			From: "2026-10-19T00:00:00Z",
+			To:   "2026-11-30T00:00:00Z",
+			Expected: []string{
+				"2026-10-19T07:30:00Z Standup",
+				"2026-10-21T07:30:00Z Standup",
+				"2026-10-26T08:30:00Z Standup",
+				"2026-10-28T10:00:00Z Standup (moved)",
+				"2026-10-30T08:30:00Z Standup",
+				"2026-11-02T08:30:00Z Standup",
+				"2026-11-04T08:30:00Z Standup",
+				"2026-11-06T08:30:00Z Standup",
+			},
+		},
+		{
+			Name: "monthly last weekday with count",
+			UID:  "month-end",
+			From: "2026-10-01T00:00:00Z",
+			To:   "2027-06-01T00:00:00Z",
+			Expected: []string{
+				"2026-10-30T20:00:00Z Month end close",
+				"2026-11-30T21:00:00Z Month end close",
+				"2026-12-31T21:00:00Z Month end close",
+			},
+		},
+		{
+			Name: "monthly ordinal weekday with until",
+			UID:  "first-monday",
+			From: "2026-10-01T00:00:00Z",
+			To:   "2027-06-01T00:00:00Z",
+			Expected: []string{
+				"2026-10-05T08:00:00Z Planning",
+				"2026-11-02T09:00:00Z Planning",
+				"2026-12-07T09:00:00Z Planning",
+			},
+		},
+		{
+			Name: "daily with count across the end of daylight saving",
+			UID:  "on-call",
+			From: "2026-10-01T00:00:00Z",
+			To:   "2026-12-01T00:00:00Z",
+			Expected: []string{
+				"2026-10-31T12:30:00Z On call handover",
+				"2026-11-01T13:30:00Z On call handover",
+				"2026-11-02T13:30:00Z On call handover",
+			},
+		},
+		{
+			Name:     "range overlapping an occurrence",
+			UID:      "standup",
+			From:     "2026-10-19T07:40:00Z",
+			To:       "2026-10-21T07:30:00Z",
+			Expected: []string{"2026-10-19T07:30:00Z Standup"},
+		},
+		{
+			Name:     "all day in the floating zone",
+			UID:      "offsite",
+			From:     "2026-10-30T12:00:00Z",
+			To:       "2026-10-30T13:00:00Z",
+			Expected: []string{"2026-10-29T00:00:00Z Offsite"},
+		},
+		{
+			Name: "cancelled",
+			UID:  "cancelled",
+			From: "2026-10-01T00:00:00Z",
+			To:   "2026-12-01T00:00:00Z",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			from, _ := time.Parse(time.RFC3339, tc.From)
+			to, _ := time.Parse(time.RFC3339, tc.To)
+			var actual []string
+			for _, o := range calendar.Occurrences(from, to) {
+				if o.Event.UID == tc.UID {
+					actual = append(actual, o.Start.UTC().Format(time.RFC3339)+" "+o.Event.Summary)
+				}
+			}
+			if !reflect.DeepEqual(tc.Expected, actual) {
+				t.Errorf("expected %q, actual %q", tc.Expected, actual)
+			}
+		})
+	}
+}
+
+func TestCalendarOccurrencesOrdered(t *testing.T) {
+	calendar := loadTestCalendar(t, "testdata/office.ics", time.UTC)
+	occurrences := calendar.Occurrences(time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC))
+	for index := 1; index < len(occurrences); index++ {
+		if occurrences[index].Start.Before(occurrences[index-1].Start) {
+			t.Fatalf("%s before %s", occurrences[index].Start, occurrences[index-1].Start)
+		}
+	}
+	for _, o := range occurrences {
+		if o.Event.UID == "standup" && o.End.Sub(o.Start) != 15*time.Minute {
+			t.Errorf("expected each standup to last 15 minutes, %s lasts %v", o.Start, o.End.Sub(o.Start))
+		}
+	}
+}
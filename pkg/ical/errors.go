@@ -0,0 +1,17 @@
+package ical
+
+// Error is an error constant.
+type Error string
+
+// Error implements error.
+func (e Error) Error() string { return string(e) }
+
+// Common Errors
+const (
+	ErrCalendarInvalid  Error = "invalid calendar"
+	ErrDateTimeInvalid  Error = "invalid date or date-time"
+	ErrDurationInvalid  Error = "invalid duration"
+	ErrRRuleInvalid     Error = "invalid recurrence rule"
+	ErrRRuleUnsupported Error = "unsupported recurrence rule"
+	ErrTimeZoneUnknown  Error = "unknown time zone"
+)
@@ -0,0 +1,169 @@
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// property is a content line, e.g. `DTSTART;TZID=Europe/Berlin:20261101T090000`.
+type property struct {
+	Name   string
+	Params map[string]string
+	Value  string
+}
+
+// component is a `BEGIN` / `END` block and its properties.
+type component struct {
+	Name       string
+	Properties []property
+	Components []*component
+}
+
+// first returns the first property with a given name.
+func (c *component) first(name string) (property, bool) {
+	for _, p := range c.Properties {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return property{}, false
+}
+
+// all returns the properties with a given name.
+func (c *component) all(name string) (output []property) {
+	for _, p := range c.Properties {
+		if p.Name == name {
+			output = append(output, p)
+		}
+	}
+	return
+}
+
+// text returns the unescaped text value of the first property with a given name.
+func (c *component) text(name string) string {
+	p, _ := c.first(name)
+	return unescapeText(p.Value)
+}
+
+// parseComponents reads the components of an iCalendar stream.
+func parseComponents(r io.Reader) ([]*component, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+	var roots []*component
+	var stack []*component
+	for number, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		p, err := parseProperty(line)
+		if err != nil {
+			return nil, fmt.Errorf("%w; line %d; %v", ErrCalendarInvalid, number+1, err)
+		}
+		switch p.Name {
+		case "BEGIN":
+			c := &component{Name: strings.ToUpper(p.Value)}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.Components = append(parent.Components, c)
+			} else {
+				roots = append(roots, c)
+			}
+			stack = append(stack, c)
+		case "END":
+			if len(stack) == 0 || stack[len(stack)-1].Name != strings.ToUpper(p.Value) {
+				return nil, fmt.Errorf("%w; line %d; unexpected END:%s", ErrCalendarInvalid, number+1, p.Value)
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("%w; line %d; property outside a component", ErrCalendarInvalid, number+1)
+			}
+			current := stack[len(stack)-1]
+			current.Properties = append(current.Properties, p)
+		}
+	}
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("%w; missing END:%s", ErrCalendarInvalid, stack[len(stack)-1].Name)
+	}
+	return roots, nil
+}
+
+// unfold returns the content lines, joining lines folded onto lines starting with whitespace.
+func unfold(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+// parseProperty parses a content line.
+func parseProperty(line string) (property, error) {
+	var p property
+	inQuotes := false
+	colon := -1
+	for index, r := range line {
+		if r == '"' {
+			inQuotes = !inQuotes
+		}
+		if r == ':' && !inQuotes {
+			colon = index
+			break
+		}
+	}
+	if colon < 0 {
+		return p, fmt.Errorf("missing ':' in %q", line)
+	}
+	p.Value = line[colon+1:]
+	parts := splitUnquoted(line[:colon], ';')
+	p.Name = strings.ToUpper(parts[0])
+	if p.Name == "" {
+		return p, fmt.Errorf("missing name in %q", line)
+	}
+	for _, param := range parts[1:] {
+		index := strings.IndexByte(param, '=')
+		if index < 0 {
+			continue
+		}
+		if p.Params == nil {
+			p.Params = make(map[string]string)
+		}
+		p.Params[strings.ToUpper(param[:index])] = strings.Trim(param[index+1:], `"`)
+	}
+	return p, nil
+}
+
+// splitUnquoted splits a value on a separator outside of double quotes.
+func splitUnquoted(value string, separator rune) []string {
+	var parts []string
+	inQuotes, start := false, 0
+	for index, r := range value {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+		case r == separator && !inQuotes:
+			parts = append(parts, value[start:index])
+			start = index + 1
+		}
+	}
+	return append(parts, value[start:])
+}
+
+// unescapeText unescapes a text value.
+func unescapeText(value string) string {
+	if !strings.Contains(value, `\`) {
+		return value
+	}
+	return strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(value)
+}
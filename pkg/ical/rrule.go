@@ -0,0 +1,333 @@
+package ical
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxPeriods is the most periods (e.g. days or weeks) a rule is expanded over,
+// so rules that never match don't loop forever.
+const maxPeriods = 100000
+
+// Frequency is a recurrence rule frequency.
+type Frequency string
+
+// Frequencies
+const (
+	FrequencyDaily   Frequency = "DAILY"
+	FrequencyWeekly  Frequency = "WEEKLY"
+	FrequencyMonthly Frequency = "MONTHLY"
+	FrequencyYearly  Frequency = "YEARLY"
+)
+
+// WeekdayNum is a `BYDAY` value, e.g. `MO`, `1MO` (the first monday) or `-1FR` (the last friday).
+type WeekdayNum struct {
+	N       int
+	Weekday time.Weekday
+}
+
+var weekdays = map[string]time.Weekday{"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday, "TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday}
+
+// RRule is a recurrence rule.
+//
+// The `DAILY`, `WEEKLY`, `MONTHLY` and `YEARLY` frequencies are supported with
+// `INTERVAL`, `COUNT`, `UNTIL`, `BYDAY`, `BYMONTHDAY`, `BYMONTH`, `BYSETPOS` and `WKST`;
+// ordinal `BYDAY` values are within the month for both monthly and yearly rules.
+type RRule struct {
+	Freq     Frequency
+	Interval int
+	Count    int
+	// Until is the last time as a wall clock time; it's utc if `UntilUTC` is set.
+	Until      time.Time
+	UntilUTC   bool
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+	ByMonth    []time.Month
+	BySetPos   []int
+	WeekStart  time.Weekday
+}
+
+// ParseRRule parses a recurrence rule value, e.g. `FREQ=WEEKLY;BYDAY=MO,WE;COUNT=10`.
+func ParseRRule(value string) (*RRule, error) {
+	r := &RRule{Interval: 1, WeekStart: time.Monday}
+	for _, part := range strings.Split(value, ";") {
+		if part == "" {
+			continue
+		}
+		index := strings.IndexByte(part, '=')
+		if index < 0 {
+			return nil, fmt.Errorf("%w; %q", ErrRRuleInvalid, value)
+		}
+		name, arg := strings.ToUpper(part[:index]), strings.ToUpper(part[index+1:])
+		var err error
+		switch name {
+		case "FREQ":
+			r.Freq = Frequency(arg)
+			switch r.Freq {
+			case FrequencyDaily, FrequencyWeekly, FrequencyMonthly, FrequencyYearly:
+			default:
+				return nil, fmt.Errorf("%w; FREQ=%s", ErrRRuleUnsupported, arg)
+			}
+		case "INTERVAL":
+			if r.Interval, err = strconv.Atoi(arg); err != nil || r.Interval < 1 {
+				return nil, fmt.Errorf("%w; %q", ErrRRuleInvalid, part)
+			}
+		case "COUNT":
+			if r.Count, err = strconv.Atoi(arg); err != nil || r.Count < 1 {
+				return nil, fmt.Errorf("%w; %q", ErrRRuleInvalid, part)
+			}
+		case "UNTIL":
+			var allDay bool
+			if r.Until, allDay, r.UntilUTC, err = parseWall(arg); err != nil {
+				return nil, fmt.Errorf("%w; %q", ErrRRuleInvalid, part)
+			}
+			if allDay {
+				// the whole of the last day.
+				r.Until = r.Until.Add(24*time.Hour - time.Second)
+			}
+		case "BYDAY":
+			for _, day := range strings.Split(arg, ",") {
+				if len(day) < 2 {
+					return nil, fmt.Errorf("%w; %q", ErrRRuleInvalid, part)
+				}
+				weekday, ok := weekdays[day[len(day)-2:]]
+				if !ok {
+					return nil, fmt.Errorf("%w; %q", ErrRRuleInvalid, part)
+				}
+				wn := WeekdayNum{Weekday: weekday}
+				if ordinal := day[:len(day)-2]; ordinal != "" {
+					if wn.N, err = strconv.Atoi(strings.TrimPrefix(ordinal, "+")); err != nil || wn.N == 0 || wn.N > 53 || wn.N < -53 {
+						return nil, fmt.Errorf("%w; %q", ErrRRuleInvalid, part)
+					}
+				}
+				r.ByDay = append(r.ByDay, wn)
+			}
+		case "BYMONTHDAY":
+			if r.ByMonthDay, err = parseInts(arg, 1, 31); err != nil {
+				return nil, fmt.Errorf("%w; %q", ErrRRuleInvalid, part)
+			}
+		case "BYMONTH":
+			months, err := parseInts(arg, 1, 12)
+			if err != nil {
+				return nil, fmt.Errorf("%w; %q", ErrRRuleInvalid, part)
+			}
+			for _, month := range months {
+				if month < 0 {
+					return nil, fmt.Errorf("%w; %q", ErrRRuleInvalid, part)
+				}
+				r.ByMonth = append(r.ByMonth, time.Month(month))
+			}
+		case "BYSETPOS":
+			if r.BySetPos, err = parseInts(arg, 1, 366); err != nil {
+				return nil, fmt.Errorf("%w; %q", ErrRRuleInvalid, part)
+			}
+		case "WKST":
+			weekday, ok := weekdays[arg]
+			if !ok {
+				return nil, fmt.Errorf("%w; %q", ErrRRuleInvalid, part)
+			}
+			r.WeekStart = weekday
+		default:
+			return nil, fmt.Errorf("%w; %s", ErrRRuleUnsupported, name)
+		}
+	}
+	if r.Freq == "" {
+		return nil, fmt.Errorf("%w; FREQ is required in %q", ErrRRuleInvalid, value)
+	}
+	return r, nil
+}
+
+// parseInts parses comma separated integers that are from min to max, or from -max to -min.
+func parseInts(value string, min, max int) ([]int, error) {
+	var output []int
+	for _, part := range strings.Split(value, ",") {
+		parsed, err := strconv.Atoi(strings.TrimPrefix(part, "+"))
+		if err != nil {
+			return nil, err
+		}
+		if abs := parsed; abs < 0 {
+			abs = -abs
+			if abs < min || abs > max {
+				return nil, fmt.Errorf("%d out of range", parsed)
+			}
+		} else if parsed < min || parsed > max {
+			return nil, fmt.Errorf("%d out of range", parsed)
+		}
+		output = append(output, parsed)
+	}
+	return output, nil
+}
+
+// Each calls a given function with each occurrence of the rule from a start
+// wall clock time, in order, until it returns false or the rule ends.
+//
+// Occurrences have the wall clock time of day of the start.
+func (r *RRule) Each(start time.Time, fn func(time.Time) bool) {
+	interval := r.Interval
+	if interval < 1 {
+		interval = 1
+	}
+	count := 0
+	for period := 0; period < maxPeriods; period++ {
+		for _, occurrence := range r.candidates(start, period*interval) {
+			if occurrence.Before(start) {
+				continue
+			}
+			if !r.Until.IsZero() && occurrence.After(r.Until) {
+				return
+			}
+			if !fn(occurrence) {
+				return
+			}
+			count++
+			if r.Count > 0 && count >= r.Count {
+				return
+			}
+		}
+	}
+}
+
+// candidates returns the occurrences of the rule in the period a given number
+// of periods after the period of the start, in order.
+func (r *RRule) candidates(start time.Time, offset int) []time.Time {
+	var days []time.Time
+	switch r.Freq {
+	case FrequencyDaily:
+		day := time.Date(start.Year(), start.Month(), start.Day()+offset, 0, 0, 0, 0, start.Location())
+		if r.matchesMonth(day) && r.matchesMonthDay(day) && r.matchesWeekday(day) {
+			days = append(days, day)
+		}
+	case FrequencyWeekly:
+		back := (int(start.Weekday()) - int(r.WeekStart) + 7) % 7
+		weekStart := time.Date(start.Year(), start.Month(), start.Day()-back+7*offset, 0, 0, 0, 0, start.Location())
+		for index := 0; index < 7; index++ {
+			day := weekStart.AddDate(0, 0, index)
+			matches := day.Weekday() == start.Weekday()
+			if len(r.ByDay) > 0 {
+				matches = r.matchesWeekday(day)
+			}
+			if matches && r.matchesMonth(day) {
+				days = append(days, day)
+			}
+		}
+	case FrequencyMonthly:
+		month := time.Date(start.Year(), start.Month()+time.Month(offset), 1, 0, 0, 0, 0, start.Location())
+		if r.matchesMonth(month) {
+			days = r.monthDays(start, month)
+		}
+	case FrequencyYearly:
+		months := r.ByMonth
+		if len(months) == 0 {
+			months = []time.Month{start.Month()}
+		}
+		sorted := append([]time.Month(nil), months...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		for _, month := range sorted {
+			days = append(days, r.monthDays(start, time.Date(start.Year()+offset, month, 1, 0, 0, 0, 0, start.Location()))...)
+		}
+	}
+	days = r.setPositions(days)
+	output := make([]time.Time, 0, len(days))
+	for _, day := range days {
+		output = append(output, time.Date(day.Year(), day.Month(), day.Day(), start.Hour(), start.Minute(), start.Second(), 0, start.Location()))
+	}
+	return output
+}
+
+// monthDays returns the days of a month that match the rule, in order.
+func (r *RRule) monthDays(start, month time.Time) []time.Time {
+	daysIn := time.Date(month.Year(), month.Month()+1, 0, 0, 0, 0, 0, month.Location()).Day()
+	var days []time.Time
+	for day := 1; day <= daysIn; day++ {
+		date := time.Date(month.Year(), month.Month(), day, 0, 0, 0, 0, month.Location())
+		switch {
+		case len(r.ByMonthDay) == 0 && len(r.ByDay) == 0:
+			if day == start.Day() {
+				days = append(days, date)
+			}
+		case r.matchesMonthDay(date) && r.matchesWeekdayInMonth(date, daysIn):
+			days = append(days, date)
+		}
+	}
+	return days
+}
+
+// setPositions returns the days at the `BYSETPOS` positions, or all of them if it's unset.
+func (r *RRule) setPositions(days []time.Time) []time.Time {
+	if len(r.BySetPos) == 0 || len(days) == 0 {
+		return days
+	}
+	var output []time.Time
+	for index, day := range days {
+		for _, position := range r.BySetPos {
+			if position == index+1 || position == index-len(days) {
+				output = append(output, day)
+				break
+			}
+		}
+	}
+	return output
+}
+
+func (r *RRule) matchesMonth(day time.Time) bool {
+	if len(r.ByMonth) == 0 {
+		return true
+	}
+	for _, month := range r.ByMonth {
+		if day.Month() == month {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *RRule) matchesMonthDay(day time.Time) bool {
+	if len(r.ByMonthDay) == 0 {
+		return true
+	}
+	daysIn := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, day.Location()).Day()
+	for _, monthDay := range r.ByMonthDay {
+		if monthDay == day.Day() || (monthDay < 0 && daysIn+1+monthDay == day.Day()) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesWeekday returns if a day is one of the `BYDAY` weekdays, ignoring ordinals.
+func (r *RRule) matchesWeekday(day time.Time) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	for _, wn := range r.ByDay {
+		if wn.Weekday == day.Weekday() {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesWeekdayInMonth returns if a day is one of the `BYDAY` weekdays, with
+// ordinals counted within the month.
+func (r *RRule) matchesWeekdayInMonth(day time.Time, daysIn int) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	for _, wn := range r.ByDay {
+		if wn.Weekday != day.Weekday() {
+			continue
+		}
+		switch {
+		case wn.N == 0:
+			return true
+		case wn.N > 0 && (day.Day()-1)/7+1 == wn.N:
+			return true
+		case wn.N < 0 && (daysIn-day.Day())/7+1 == -wn.N:
+			return true
+		}
+	}
+	return false
+}
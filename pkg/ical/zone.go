@@ -0,0 +1,352 @@
+package ical
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// zone converts between times and wall clock times in a time zone.
+//
+// Wall clock times are "floating" times in utc, e.g. 09:00 in a zone is 09:00 utc.
+type zone interface {
+	at(wall time.Time) time.Time
+	wall(t time.Time) time.Time
+}
+
+// locationZone is a zone from the tz database.
+type locationZone struct{ *time.Location }
+
+func (lz locationZone) at(wall time.Time) time.Time {
+	return time.Date(wall.Year(), wall.Month(), wall.Day(), wall.Hour(), wall.Minute(), wall.Second(), 0, lz.Location)
+}
+
+func (lz locationZone) wall(t time.Time) time.Time {
+	t = t.In(lz.Location)
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+}
+
+// windowsZones are the tz database names of common windows time zone names.
+var windowsZones = map[string]string{
+	"AUS Eastern Standard Time":    "Australia/Sydney",
+	"Central Europe Standard Time": "Europe/Budapest",
+	"Central Standard Time":        "America/Chicago",
+	"China Standard Time":          "Asia/Shanghai",
+	"E. Europe Standard Time":      "Europe/Chisinau",
+	"Eastern Standard Time":        "America/New_York",
+	"GMT Standard Time":            "Europe/London",
+	"India Standard Time":          "Asia/Kolkata",
+	"Mountain Standard Time":       "America/Denver",
+	"Pacific Standard Time":        "America/Los_Angeles",
+	"Romance Standard Time":        "Europe/Paris",
+	"Tokyo Standard Time":          "Asia/Tokyo",
+	"UTC":                          "UTC",
+	"W. Europe Standard Time":      "Europe/Berlin",
+}
+
+// zones resolves the time zones of a calendar.
+type zones struct {
+	floating zone
+	defined  map[string]*vtimezone
+	resolved map[string]zone
+}
+
+// lookup returns the zone for a tzid, from the tz database if it's known there
+// (possibly by a suffix, e.g. `/mozilla.org/20050126_1/Europe/Berlin`, or a windows
+// name) and otherwise from the calendar `VTIMEZONE` definitions.
+func (z *zones) lookup(tzid string) (zone, error) {
+	if resolved, ok := z.resolved[tzid]; ok {
+		return resolved, nil
+	}
+	var resolved zone
+	if location, ok := loadLocation(tzid); ok {
+		resolved = locationZone{location}
+	} else if defined, ok := z.defined[tzid]; ok {
+		resolved = defined
+	} else {
+		return nil, fmt.Errorf("%w; %q", ErrTimeZoneUnknown, tzid)
+	}
+	if z.resolved == nil {
+		z.resolved = make(map[string]zone)
+	}
+	z.resolved[tzid] = resolved
+	return resolved, nil
+}
+
+func loadLocation(tzid string) (*time.Location, bool) {
+	if name, ok := windowsZones[tzid]; ok {
+		tzid = name
+	}
+	if tzid == "" || strings.EqualFold(tzid, "local") {
+		return nil, false
+	}
+	if location, err := time.LoadLocation(tzid); err == nil {
+		return location, true
+	}
+	parts := strings.Split(strings.Trim(tzid, "/"), "/")
+	for index := 1; index < len(parts)-1; index++ {
+		if location, err := time.LoadLocation(strings.Join(parts[index:], "/")); err == nil {
+			return location, true
+		}
+	}
+	return nil, false
+}
+
+// dateTime is a parsed DATE or DATE-TIME value.
+type dateTime struct {
+	Time   time.Time
+	AllDay bool
+	Zone   zone
+}
+
+// parseDateTime parses a property DATE or DATE-TIME value.
+func (z *zones) parseDateTime(p property) (dateTime, error) {
+	values, err := z.parseDateTimes(p)
+	if err != nil {
+		return dateTime{}, err
+	}
+	if len(values) != 1 {
+		return dateTime{}, fmt.Errorf("%w; %s; expected a single value", ErrDateTimeInvalid, p.Name)
+	}
+	return values[0], nil
+}
+
+// parseDateTimes parses a property comma separated DATE or DATE-TIME values.
+func (z *zones) parseDateTimes(p property) ([]dateTime, error) {
+	if strings.EqualFold(p.Params["VALUE"], "PERIOD") {
+		return nil, fmt.Errorf("%w; %s; periods are not supported", ErrDateTimeInvalid, p.Name)
+	}
+	var output []dateTime
+	for _, value := range strings.Split(p.Value, ",") {
+		value = strings.TrimSpace(value)
+		wall, allDay, utc, err := parseWall(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w; %s; %q", ErrDateTimeInvalid, p.Name, value)
+		}
+		dt := dateTime{AllDay: allDay || strings.EqualFold(p.Params["VALUE"], "DATE"), Zone: z.floating}
+		switch {
+		case utc:
+			dt.Zone = locationZone{time.UTC}
+		case !dt.AllDay && p.Params["TZID"] != "":
+			if dt.Zone, err = z.lookup(p.Params["TZID"]); err != nil {
+				return nil, err
+			}
+		}
+		dt.Time = dt.Zone.at(wall)
+		output = append(output, dt)
+	}
+	return output, nil
+}
+
+// parseWall parses a `YYYYMMDD`, `YYYYMMDDTHHMMSS` or `YYYYMMDDTHHMMSSZ` value as a wall clock time.
+func parseWall(value string) (wall time.Time, allDay, utc bool, err error) {
+	switch len(value) {
+	case 8:
+		wall, err = time.Parse("20060102", value)
+		return wall, true, false, err
+	case 15:
+		wall, err = time.Parse("20060102T150405", value)
+		return wall, false, false, err
+	case 16:
+		if value[15] != 'Z' && value[15] != 'z' {
+			return time.Time{}, false, false, ErrDateTimeInvalid
+		}
+		wall, err = time.Parse("20060102T150405", value[:15])
+		return wall, false, true, err
+	default:
+		return time.Time{}, false, false, ErrDateTimeInvalid
+	}
+}
+
+// parseDuration parses a DURATION value, e.g. `PT30M`, `P1D` or `-PT15M`.
+func parseDuration(value string) (time.Duration, error) {
+	original := value
+	sign := time.Duration(1)
+	switch {
+	case strings.HasPrefix(value, "-"):
+		sign, value = -1, value[1:]
+	case strings.HasPrefix(value, "+"):
+		value = value[1:]
+	}
+	if !strings.HasPrefix(value, "P") || len(value) < 3 {
+		return 0, fmt.Errorf("%w; %q", ErrDurationInvalid, original)
+	}
+	var output time.Duration
+	inTime := false
+	number := ""
+	for _, r := range value[1:] {
+		switch {
+		case r >= '0' && r <= '9':
+			number += string(r)
+			continue
+		case r == 'T':
+			inTime = true
+			continue
+		}
+		amount, err := strconv.Atoi(number)
+		if err != nil {
+			return 0, fmt.Errorf("%w; %q", ErrDurationInvalid, original)
+		}
+		number = ""
+		unit := map[rune]time.Duration{'W': 7 * 24 * time.Hour, 'D': 24 * time.Hour}
+		if inTime {
+			unit = map[rune]time.Duration{'H': time.Hour, 'M': time.Minute, 'S': time.Second}
+		}
+		scale, ok := unit[r]
+		if !ok {
+			return 0, fmt.Errorf("%w; %q", ErrDurationInvalid, original)
+		}
+		output += time.Duration(amount) * scale
+	}
+	if number != "" {
+		return 0, fmt.Errorf("%w; %q", ErrDurationInvalid, original)
+	}
+	return sign * output, nil
+}
+
+// vtimezone is a time zone defined in a calendar by its observances.
+type vtimezone struct {
+	id          string
+	observances []observance
+}
+
+// observance is a `STANDARD` or `DAYLIGHT` part of a time zone.
+type observance struct {
+	name       string
+	onset      time.Time
+	offsetFrom int
+	offsetTo   int
+	rule       *RRule
+	onsets     []time.Time
+}
+
+// parseVTimezone parses a `VTIMEZONE` component.
+func parseVTimezone(c *component) (*vtimezone, error) {
+	v := &vtimezone{id: c.text("TZID")}
+	for _, sub := range c.Components {
+		if sub.Name != "STANDARD" && sub.Name != "DAYLIGHT" {
+			continue
+		}
+		o := observance{name: sub.text("TZNAME")}
+		start, _ := sub.first("DTSTART")
+		var err error
+		if o.onset, _, _, err = parseWall(start.Value); err != nil {
+			return nil, fmt.Errorf("%w; %s; DTSTART %q", ErrCalendarInvalid, v.id, start.Value)
+		}
+		from, _ := sub.first("TZOFFSETFROM")
+		to, _ := sub.first("TZOFFSETTO")
+		if o.offsetFrom, err = parseOffset(from.Value); err != nil {
+			return nil, fmt.Errorf("%w; %s; %v", ErrCalendarInvalid, v.id, err)
+		}
+		if o.offsetTo, err = parseOffset(to.Value); err != nil {
+			return nil, fmt.Errorf("%w; %s; %v", ErrCalendarInvalid, v.id, err)
+		}
+		if rule, ok := sub.first("RRULE"); ok {
+			if o.rule, err = ParseRRule(rule.Value); err != nil {
+				return nil, fmt.Errorf("%s; %w", v.id, err)
+			}
+		}
+		for _, rdate := range sub.all("RDATE") {
+			for _, value := range strings.Split(rdate.Value, ",") {
+				if wall, _, _, err := parseWall(value); err == nil {
+					o.onsets = append(o.onsets, wall)
+				}
+			}
+		}
+		v.observances = append(v.observances, o)
+	}
+	if len(v.observances) == 0 {
+		return nil, fmt.Errorf("%w; %s; no observances", ErrCalendarInvalid, v.id)
+	}
+	return v, nil
+}
+
+// parseOffset parses a utc offset, e.g. `+0100` or `-083000`, in seconds.
+func parseOffset(value string) (int, error) {
+	if len(value) != 5 && len(value) != 7 {
+		return 0, fmt.Errorf("invalid utc offset %q", value)
+	}
+	sign := 1
+	switch value[0] {
+	case '-':
+		sign = -1
+	case '+':
+	default:
+		return 0, fmt.Errorf("invalid utc offset %q", value)
+	}
+	var seconds int
+	for index, scale := range []int{3600, 60, 1} {
+		if 1+2*index >= len(value) {
+			break
+		}
+		part, err := strconv.Atoi(value[1+2*index : 3+2*index])
+		if err != nil {
+			return 0, fmt.Errorf("invalid utc offset %q", value)
+		}
+		seconds += part * scale
+	}
+	return sign * seconds, nil
+}
+
+// offset returns the utc offset and name at a wall clock time, from the
+// observance with the latest onset at or before it.
+func (v *vtimezone) offset(wall time.Time) (int, string) {
+	var latest time.Time
+	var current *observance
+	for index := range v.observances {
+		o := &v.observances[index]
+		onset := o.latestOnset(wall)
+		if !onset.IsZero() && (current == nil || onset.After(latest)) {
+			latest, current = onset, o
+		}
+	}
+	if current == nil {
+		// before every onset, so the offset before the earliest one.
+		earliest := &v.observances[0]
+		for index := range v.observances {
+			if v.observances[index].onset.Before(earliest.onset) {
+				earliest = &v.observances[index]
+			}
+		}
+		return earliest.offsetFrom, earliest.name
+	}
+	return current.offsetTo, current.name
+}
+
+// latestOnset returns the latest onset of the observance at or before a wall clock time.
+func (o *observance) latestOnset(wall time.Time) (latest time.Time) {
+	if o.onset.After(wall) {
+		return
+	}
+	latest = o.onset
+	if o.rule != nil {
+		o.rule.Each(o.onset, func(onset time.Time) bool {
+			if onset.After(wall) {
+				return false
+			}
+			latest = onset
+			return true
+		})
+	}
+	for _, onset := range o.onsets {
+		if !onset.After(wall) && onset.After(latest) {
+			latest = onset
+		}
+	}
+	return
+}
+
+func (v *vtimezone) at(wall time.Time) time.Time {
+	offset, name := v.offset(wall)
+	return time.Date(wall.Year(), wall.Month(), wall.Day(), wall.Hour(), wall.Minute(), wall.Second(), 0, time.FixedZone(name, offset))
+}
+
+func (v *vtimezone) wall(t time.Time) time.Time {
+	// the offset depends on the wall clock time, so it's found from the utc
+	// time and then corrected once.
+	t = t.UTC()
+	offset, _ := v.offset(t)
+	offset, _ = v.offset(t.Add(time.Duration(offset) * time.Second))
+	return t.Add(time.Duration(offset) * time.Second)
+}
@@ -0,0 +1,245 @@
+package lametric
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// ChartColumns is the number of columns a chart frame can show.
+const ChartColumns = DisplayWidth
+
+// ChartScale is the default maximum value of normalized chart data.
+const ChartScale = 100
+
+// Point is a time series sample.
+type Point struct {
+	Time  time.Time
+	Value float64
+}
+
+// Aggregation is how samples that fall in the same chart column are combined.
+type Aggregation string
+
+// Aggregations
+const (
+	AggregationAvg  Aggregation = "avg"
+	AggregationMin  Aggregation = "min"
+	AggregationMax  Aggregation = "max"
+	AggregationLast Aggregation = "last"
+)
+
+// AllAggregations returns the valid aggregations.
+func AllAggregations() []Aggregation {
+	return []Aggregation{
+		AggregationAvg,
+		AggregationMin,
+		AggregationMax,
+		AggregationLast,
+	}
+}
+
+// ParseAggregation parses an aggregation, returning an error if it's unknown.
+func ParseAggregation(value string) (Aggregation, error) {
+	for _, aggregation := range AllAggregations() {
+		if string(aggregation) == value {
+			return aggregation, nil
+		}
+	}
+	return "", fmt.Errorf("%w; %q is not one of %v", ErrAggregationUnknown, value, AllAggregations())
+}
+
+// String implements fmt.Stringer.
+func (a Aggregation) String() string { return string(a) }
+
+// MarshalText implements encoding.TextMarshaler.
+func (a Aggregation) MarshalText() ([]byte, error) { return []byte(a), nil }
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (a *Aggregation) UnmarshalText(text []byte) (err error) {
+	*a, err = ParseAggregation(string(text))
+	return
+}
+
+// ChartOptions are options for creating chart data.
+type ChartOptions struct {
+	// Columns is the number of columns to resample to; defaults to `ChartColumns`.
+	Columns int
+	// Aggregation combines samples in the same column; defaults to `AggregationAvg`.
+	Aggregation Aggregation
+	// Scale is the maximum normalized value; defaults to `ChartScale`.
+	Scale int
+	// ZeroBased normalizes from zero rather than from the minimum value.
+	ZeroBased bool
+	// Threshold, if set, is included in the normalized range and columns
+	// at or above it are pinned to the full scale so they stand out.
+	Threshold *float64
+}
+
+// ChartFrame returns a chart frame for a given time series.
+func ChartFrame(points []Point, opts ChartOptions) Frame {
+	return Frame{
+		ChartData: ChartData(points, opts),
+	}
+}
+
+// ChartData resamples and normalizes a given time series to chart data.
+func ChartData(points []Point, opts ChartOptions) []int {
+	columns := opts.Columns
+	if columns <= 0 {
+		columns = ChartColumns
+	}
+	return Normalize(Resample(points, columns, opts.Aggregation), opts)
+}
+
+// ValuePoints returns evenly spaced points for a given list of values.
+func ValuePoints(values []float64) []Point {
+	output := make([]Point, len(values))
+	for index, value := range values {
+		output[index] = Point{Time: time.Unix(int64(index), 0), Value: value}
+	}
+	return output
+}
+
+// Resample combines the points of a time series into at most a given number
+// of equal duration columns with a given aggregation.
+//
+// Points that aren't finite (`NaN`, `±Inf`) are dropped. Columns without
+// samples carry the previous column's value forward. Series with no more
+// points than columns are returned as is.
+func Resample(points []Point, columns int, aggregation Aggregation) []float64 {
+	if columns <= 0 {
+		return nil
+	}
+	sorted := make([]Point, 0, len(points))
+	for _, point := range points {
+		if isFinite(point.Value) {
+			sorted = append(sorted, point)
+		}
+	}
+	if len(sorted) == 0 {
+		return nil
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Time.Before(sorted[j].Time)
+	})
+	if len(sorted) <= columns {
+		output := make([]float64, len(sorted))
+		for index, point := range sorted {
+			output[index] = point.Value
+		}
+		return output
+	}
+
+	start, end := sorted[0].Time, sorted[len(sorted)-1].Time
+	span := end.Sub(start)
+	buckets := make([][]float64, columns)
+	for index, point := range sorted {
+		var column int
+		if span > 0 {
+			column = int(float64(point.Time.Sub(start)) / float64(span) * float64(columns))
+		} else {
+			column = index * columns / len(sorted)
+		}
+		if column >= columns {
+			column = columns - 1
+		}
+		buckets[column] = append(buckets[column], point.Value)
+	}
+
+	output := make([]float64, columns)
+	for index, bucket := range buckets {
+		if len(bucket) == 0 {
+			if index > 0 {
+				output[index] = output[index-1]
+			} else {
+				output[index] = sorted[0].Value
+			}
+			continue
+		}
+		output[index] = aggregate(bucket, aggregation)
+	}
+	return output
+}
+
+// Normalize maps a given list of values onto the integer range [0, scale].
+//
+// Values that aren't finite are left out of the range; `+Inf` is shown at
+// the full scale and `NaN` and `-Inf` at zero.
+func Normalize(values []float64, opts ChartOptions) []int {
+	if len(values) == 0 {
+		return nil
+	}
+	scale := opts.Scale
+	if scale <= 0 {
+		scale = ChartScale
+	}
+	min, max := math.Inf(1), math.Inf(-1)
+	for _, value := range values {
+		if !isFinite(value) {
+			continue
+		}
+		min = math.Min(min, value)
+		max = math.Max(max, value)
+	}
+	if opts.Threshold != nil {
+		min = math.Min(min, *opts.Threshold)
+		max = math.Max(max, *opts.Threshold)
+	}
+	if opts.ZeroBased {
+		min = math.Min(min, 0)
+	}
+
+	output := make([]int, len(values))
+	for index, value := range values {
+		if math.IsInf(value, 1) {
+			output[index] = scale
+			continue
+		}
+		if !isFinite(value) {
+			continue
+		}
+		if opts.Threshold != nil && value >= *opts.Threshold {
+			output[index] = scale
+			continue
+		}
+		if max == min {
+			if value > 0 {
+				output[index] = scale
+			}
+			continue
+		}
+		output[index] = int(math.Round((value - min) / (max - min) * float64(scale)))
+	}
+	return output
+}
+
+func isFinite(value float64) bool {
+	return !math.IsNaN(value) && !math.IsInf(value, 0)
+}
+
+func aggregate(values []float64, aggregation Aggregation) float64 {
+	switch aggregation {
+	case AggregationMin:
+		output := values[0]
+		for _, value := range values[1:] {
+			output = math.Min(output, value)
+		}
+		return output
+	case AggregationMax:
+		output := values[0]
+		for _, value := range values[1:] {
+			output = math.Max(output, value)
+		}
+		return output
+	case AggregationLast:
+		return values[len(values)-1]
+	default:
+		var total float64
+		for _, value := range values {
+			total += value
+		}
+		return total / float64(len(values))
+	}
+}
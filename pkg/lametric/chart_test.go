@@ -0,0 +1,63 @@
+package lametric
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	threshold := 8.0
+	testCases := []struct {
+		Name   string
+		Values []float64
+		Opts   ChartOptions
+		Expect []int
+	}{
+		{Name: "range", Values: []float64{0, 5, 10}, Expect: []int{0, 50, 100}},
+		{Name: "nan", Values: []float64{0, math.NaN(), 10}, Expect: []int{0, 0, 100}},
+		{Name: "inf", Values: []float64{2, math.Inf(1), 4, math.Inf(-1), 6}, Expect: []int{0, 100, 50, 0, 100}},
+		{Name: "only nan", Values: []float64{math.NaN(), math.NaN()}, Expect: []int{0, 0}},
+		{Name: "constant", Values: []float64{3, 3, 3}, Expect: []int{100, 100, 100}},
+		{Name: "constant zero", Values: []float64{0, 0}, Expect: []int{0, 0}},
+		{Name: "zero based", Values: []float64{5, 10}, Opts: ChartOptions{ZeroBased: true}, Expect: []int{50, 100}},
+		{Name: "threshold", Values: []float64{0, 4, 9}, Opts: ChartOptions{Threshold: &threshold}, Expect: []int{0, 44, 100}},
+		{Name: "threshold constant", Values: []float64{4, 4}, Opts: ChartOptions{Threshold: &threshold, Scale: 8}, Expect: []int{0, 0}},
+		{Name: "threshold nan", Values: []float64{0, math.NaN(), 16}, Opts: ChartOptions{Threshold: &threshold}, Expect: []int{0, 0, 100}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			if actual := Normalize(tc.Values, tc.Opts); !reflect.DeepEqual(actual, tc.Expect) {
+				t.Errorf("expected %v, got %v", tc.Expect, actual)
+			}
+		})
+	}
+}
+
+func TestResampleDropsNonFinite(t *testing.T) {
+	points := ValuePoints([]float64{1, math.NaN(), 3, math.Inf(1), 5, math.Inf(-1), 7, 9})
+	actual := Resample(points, 2, AggregationAvg)
+	if expect := []float64{2, 7}; !reflect.DeepEqual(actual, expect) {
+		t.Errorf("expected %v, got %v", expect, actual)
+	}
+	if actual := Resample(ValuePoints([]float64{math.NaN()}), 2, AggregationAvg); actual != nil {
+		t.Errorf("expected nil, got %v", actual)
+	}
+}
+
+func TestChartDataNonFinite(t *testing.T) {
+	values := make([]float64, 100)
+	for index := range values {
+		values[index] = float64(index)
+		if index%10 == 0 {
+			values[index] = math.NaN()
+		}
+	}
+	data := ChartData(ValuePoints(values), ChartOptions{})
+	if len(data) != ChartColumns {
+		t.Fatalf("expected %d columns, got %d", ChartColumns, len(data))
+	}
+	if data[0] != 0 || data[len(data)-1] != ChartScale {
+		t.Errorf("expected the range to span the finite values, got %v", data)
+	}
+}
@@ -8,4 +8,10 @@ import (
 type Client interface {
 	CreateNotification(context.Context, Notification) (*CreateNotificationOutput, error)
 	GetNotifications(context.Context) ([]Notification, error)
+	GetDevice(context.Context) (*Device, error)
+	GetDisplay(context.Context) (*Display, error)
+	UpdateDisplay(context.Context, UpdateDisplayInput) (*Display, error)
+	GetAudio(context.Context) (*Audio, error)
+	UpdateAudio(context.Context, int) (*Audio, error)
+	PushApp(context.Context, App, []Frame) error
 }
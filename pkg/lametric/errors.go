@@ -0,0 +1,24 @@
+package lametric
+
+// Error is an error constant.
+type Error string
+
+// Error implements error.
+func (e Error) Error() string { return string(e) }
+
+// Common Errors
+const (
+	ErrIconEmpty             Error = "icon image is empty"
+	ErrIconFormatUnsupported Error = "icon image format unsupported; must be png or gif"
+	ErrIconTooManyColors     Error = "icon image has too many colors to encode"
+	ErrIconUnknown           Error = "icon not found in catalog"
+
+	ErrNotificationPriorityUnknown Error = "unknown notification priority"
+	ErrIconTypeUnknown             Error = "unknown icon type"
+	ErrSoundCategoryUnknown        Error = "unknown sound category"
+	ErrSoundIDUnknown              Error = "unknown sound id"
+	ErrAggregationUnknown          Error = "unknown chart aggregation"
+	ErrBrightnessModeUnknown       Error = "unknown brightness mode"
+	ErrLevelOutOfRange             Error = "level must be between 0 and 100"
+	ErrAppIDEmpty                  Error = "app id is empty"
+)
@@ -2,16 +2,60 @@ package lametric
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 
 	"github.com/wcharczuk/lametric/pkg/apiutil"
 )
 
-// New returns a new http client.
+// Schemes and their default ports the device api is served on.
+const (
+	SchemeHTTP  = "http"
+	SchemeHTTPS = "https"
+	PortHTTP    = 8080
+	PortHTTPS   = 4343
+)
+
+// Endpoint returns the device api base url for a given address.
+//
+// The address may be a host, host:port, ipv6 literal or url (see `apiutil.ParseEndpoint`).
+// The scheme and port are used if the address doesn't have them; an empty
+// scheme defaults to http and a zero port defaults to the port for the scheme.
+func Endpoint(scheme, addr string, port int) (*url.URL, error) {
+	if scheme == "" {
+		scheme = SchemeHTTP
+	}
+	defaultPorts := map[string]int{
+		SchemeHTTP:  PortHTTP,
+		SchemeHTTPS: PortHTTPS,
+	}
+	if port != 0 {
+		defaultPorts[SchemeHTTP] = port
+		defaultPorts[SchemeHTTPS] = port
+	}
+	return apiutil.ParseEndpoint(addr, scheme, defaultPorts)
+}
+
+// New returns a new http client for a device address.
+//
+// It panics if the address is invalid; use `Endpoint` to validate addresses.
 func New(addr, token string, opts ...apiutil.Option) *HTTPClient {
+	endpoint, err := Endpoint(SchemeHTTP, addr, 0)
+	if err != nil {
+		panic(err)
+	}
+	return NewURL(endpoint.String(), token, opts...)
+}
+
+// NewURL returns a new http client for a given device api base url.
+//
+// Devices serve https with a self-signed certificate; use `apiutil.OptPinnedTLS`
+// to verify it by fingerprint.
+func NewURL(baseURL, token string, opts ...apiutil.Option) *HTTPClient {
 	hc := HTTPClient{
-		Client: apiutil.New(fmt.Sprintf("http://%s:8080", addr),
+		Client: apiutil.New(baseURL,
 			append(opts,
 				apiutil.OptDefaults(
 					apiutil.OptBasicAuth("dev", token),
@@ -23,6 +67,8 @@ func New(addr, token string, opts ...apiutil.Option) *HTTPClient {
 	return &hc
 }
 
+var _ Client = (*HTTPClient)(nil)
+
 // HTTPClient is a concrete implementation of Client.
 type HTTPClient struct {
 	apiutil.Client
@@ -30,6 +76,10 @@ type HTTPClient struct {
 
 // CreateNotification creates a notification.
 func (hc HTTPClient) CreateNotification(ctx context.Context, args Notification) (*CreateNotificationOutput, error) {
+	args, err := DefaultIconCatalog().ResolveNotification(args)
+	if err != nil {
+		return nil, err
+	}
 	var output CreateNotificationOutput
 	if _, err := hc.Client.JSON(ctx, &output,
 		apiutil.OptMethod(http.MethodPost),
@@ -40,3 +90,143 @@ func (hc HTTPClient) CreateNotification(ctx context.Context, args Notification)
 	}
 	return &output, nil
 }
+
+// Notify creates a notification, discarding the output.
+func (hc HTTPClient) Notify(ctx context.Context, args Notification) error {
+	_, err := hc.CreateNotification(ctx, args)
+	return err
+}
+
+// GetNotifications returns the notifications in the device queue.
+func (hc HTTPClient) GetNotifications(ctx context.Context) ([]Notification, error) {
+	var output []Notification
+	if _, err := hc.Client.JSON(ctx, &output,
+		apiutil.OptPath("/api/v2/device/notifications"),
+	); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+// GetDevice returns the device info, including its audio and display state.
+func (hc HTTPClient) GetDevice(ctx context.Context) (*Device, error) {
+	var output Device
+	if _, err := hc.Client.JSON(ctx, &output,
+		apiutil.OptPath("/api/v2/device"),
+	); err != nil {
+		return nil, err
+	}
+	return &output, nil
+}
+
+// GetDisplay returns the display state.
+func (hc HTTPClient) GetDisplay(ctx context.Context) (*Display, error) {
+	var output Display
+	if _, err := hc.Client.JSON(ctx, &output,
+		apiutil.OptPath("/api/v2/device/display"),
+	); err != nil {
+		return nil, err
+	}
+	return &output, nil
+}
+
+// UpdateDisplay updates the display brightness or brightness mode.
+func (hc HTTPClient) UpdateDisplay(ctx context.Context, args UpdateDisplayInput) (*Display, error) {
+	if args.Brightness != nil {
+		if err := validateLevel(*args.Brightness); err != nil {
+			return nil, err
+		}
+	}
+	if args.BrightnessMode != "" {
+		if _, err := ParseBrightnessMode(string(args.BrightnessMode)); err != nil {
+			return nil, err
+		}
+	}
+	var output updateOutput
+	if _, err := hc.Client.JSON(ctx, &output,
+		apiutil.OptMethod(http.MethodPut),
+		apiutil.OptPath("/api/v2/device/display"),
+		apiutil.OptJSONBody(args),
+	); err != nil {
+		return nil, err
+	}
+	var display Display
+	if err := output.decode(&display); err != nil {
+		return nil, err
+	}
+	return &display, nil
+}
+
+// GetAudio returns the audio state.
+func (hc HTTPClient) GetAudio(ctx context.Context) (*Audio, error) {
+	var output Audio
+	if _, err := hc.Client.JSON(ctx, &output,
+		apiutil.OptPath("/api/v2/device/audio"),
+	); err != nil {
+		return nil, err
+	}
+	return &output, nil
+}
+
+// UpdateAudio sets the volume, from 0 to 100.
+func (hc HTTPClient) UpdateAudio(ctx context.Context, volume int) (*Audio, error) {
+	if err := validateLevel(volume); err != nil {
+		return nil, err
+	}
+	var output updateOutput
+	if _, err := hc.Client.JSON(ctx, &output,
+		apiutil.OptMethod(http.MethodPut),
+		apiutil.OptPath("/api/v2/device/audio"),
+		apiutil.OptJSONBody(Audio{Volume: volume}),
+	); err != nil {
+		return nil, err
+	}
+	var audio Audio
+	if err := output.decode(&audio); err != nil {
+		return nil, err
+	}
+	return &audio, nil
+}
+
+// PushApp replaces the frames of a local push indicator app.
+func (hc HTTPClient) PushApp(ctx context.Context, app App, frames []Frame) error {
+	if app.ID == "" {
+		return ErrAppIDEmpty
+	}
+	resolved, err := DefaultIconCatalog().ResolveNotification(Notification{Model: NotificationModel{Frames: frames}})
+	if err != nil {
+		return err
+	}
+	version := app.Version
+	if version <= 0 {
+		version = 1
+	}
+	_, err = hc.Client.Discard(ctx,
+		apiutil.OptMethod(http.MethodPost),
+		apiutil.OptPathf("/api/v1/dev/widget/update/%s/%d", url.PathEscape(app.ID), version),
+		apiutil.OptHeader("X-Access-Token", app.Token),
+		apiutil.OptJSONBody(AppFrames{Frames: resolved.Model.Frames}),
+	)
+	return err
+}
+
+// updateOutput is the output of settings updates, which echo the updated settings.
+type updateOutput struct {
+	Success struct {
+		Data json.RawMessage `json:"data"`
+	} `json:"success"`
+}
+
+func (uo updateOutput) decode(output interface{}) error {
+	if len(uo.Success.Data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(uo.Success.Data, output)
+}
+
+func validateLevel(level int) error {
+	if level < 0 || level > 100 {
+		return fmt.Errorf("%w; %d", ErrLevelOutOfRange, level)
+	}
+	return nil
+}
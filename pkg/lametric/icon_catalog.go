@@ -0,0 +1,341 @@
+package lametric
+
+import (
+	"bytes"
+	"context"
+	_ "embed" // required for the embedded icon catalog
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/wcharczuk/lametric/pkg/apiutil"
+)
+
+//go:generate go run ../.. icons refresh -o icon_catalog.json
+
+//go:embed icon_catalog.json
+var iconCatalogJSON []byte
+
+// DefaultIconsURL is the url of the lametric developer icons api.
+const DefaultIconsURL = "https://developer.lametric.com/api/v2/icons"
+
+// iconsPageSize is the number of icons fetched per page of the icons api.
+const iconsPageSize = 1000
+
+var (
+	defaultIconCatalogMu   sync.Mutex
+	defaultIconCatalog     *IconCatalog
+	defaultIconCatalogOnce sync.Once
+)
+
+// DefaultIconCatalog returns the icon catalog used to resolve icon names.
+//
+// Unless replaced with `SetDefaultIconCatalog` this is the catalog
+// embedded in the binary.
+func DefaultIconCatalog() *IconCatalog {
+	defaultIconCatalogOnce.Do(func() {
+		catalog, err := ReadIconCatalog(bytes.NewReader(iconCatalogJSON))
+		if err != nil {
+			panic(err)
+		}
+		defaultIconCatalogMu.Lock()
+		if defaultIconCatalog == nil {
+			defaultIconCatalog = catalog
+		}
+		defaultIconCatalogMu.Unlock()
+	})
+	defaultIconCatalogMu.Lock()
+	defer defaultIconCatalogMu.Unlock()
+	return defaultIconCatalog
+}
+
+// SetDefaultIconCatalog replaces the icon catalog used to resolve icon names.
+func SetDefaultIconCatalog(catalog *IconCatalog) {
+	DefaultIconCatalog()
+	defaultIconCatalogMu.Lock()
+	defaultIconCatalog = catalog
+	defaultIconCatalogMu.Unlock()
+}
+
+// LookupIcon finds an icon by name or id in the default catalog.
+func LookupIcon(name string) (IconInfo, bool) {
+	return DefaultIconCatalog().Lookup(name)
+}
+
+// SearchIcons searches the default catalog for icons matching a term.
+func SearchIcons(term string) []IconInfo {
+	return DefaultIconCatalog().Search(term)
+}
+
+// LoadIconCatalog reads an icon catalog from a json file.
+func LoadIconCatalog(path string) (*IconCatalog, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadIconCatalog(f)
+}
+
+// ReadIconCatalog reads an icon catalog as json from a given reader.
+func ReadIconCatalog(r io.Reader) (*IconCatalog, error) {
+	var catalog IconCatalog
+	if err := json.NewDecoder(r).Decode(&catalog); err != nil {
+		return nil, err
+	}
+	catalog.index()
+	return &catalog, nil
+}
+
+// ParseIconDump creates a catalog with a given version from a dump of the
+// lametric developer icons api (i.e. `GET /api/v2/icons`).
+//
+// Both the api envelope (`{"data":[...]}`) and a bare array of icons are accepted.
+func ParseIconDump(r io.Reader, version string) (*IconCatalog, error) {
+	contents, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var dump []iconDumpEntry
+	if trimmed := strings.TrimSpace(string(contents)); strings.HasPrefix(trimmed, "[") {
+		err = json.Unmarshal(contents, &dump)
+	} else {
+		var envelope struct {
+			Data []iconDumpEntry `json:"data"`
+		}
+		err = json.Unmarshal(contents, &envelope)
+		dump = envelope.Data
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newIconCatalog(dump, version), nil
+}
+
+// FetchIconDump fetches every page of the lametric developer icons api with a
+// given client and creates a catalog with a given version from them.
+func FetchIconDump(ctx context.Context, client apiutil.Client, version string) (*IconCatalog, error) {
+	var dump []iconDumpEntry
+	for page := 0; ; page++ {
+		var envelope struct {
+			Data []iconDumpEntry `json:"data"`
+		}
+		query := url.Values{
+			"page":      []string{strconv.Itoa(page)},
+			"page_size": []string{strconv.Itoa(iconsPageSize)},
+			"fields":    []string{"id,title,code,type,category"},
+		}
+		if _, err := client.JSON(ctx, &envelope, apiutil.OptQuery(query)); err != nil {
+			return nil, err
+		}
+		dump = append(dump, envelope.Data...)
+		if len(envelope.Data) < iconsPageSize {
+			break
+		}
+	}
+	if len(dump) == 0 {
+		return nil, fmt.Errorf("icons api; no icons returned")
+	}
+	return newIconCatalog(dump, version), nil
+}
+
+func newIconCatalog(dump []iconDumpEntry, version string) *IconCatalog {
+	catalog := IconCatalog{Version: version}
+	for _, entry := range dump {
+		id := entry.Code
+		if id == "" {
+			prefix := "i"
+			if entry.Type == "movie" {
+				prefix = "a"
+			}
+			id = fmt.Sprintf("%s%d", prefix, entry.ID)
+		}
+		catalog.Icons = append(catalog.Icons, IconInfo{
+			ID:       Icon(id),
+			Name:     iconName(entry.Title),
+			Tags:     iconTags(entry.Title, entry.Category),
+			Animated: entry.Type == "movie" || strings.HasPrefix(id, "a"),
+		})
+	}
+	sort.SliceStable(catalog.Icons, func(i, j int) bool {
+		return catalog.Icons[i].Name < catalog.Icons[j].Name
+	})
+	catalog.index()
+	return &catalog
+}
+
+type iconDumpEntry struct {
+	ID       int    `json:"id"`
+	Title    string `json:"title"`
+	Code     string `json:"code"`
+	Type     string `json:"type"`
+	Category string `json:"category"`
+}
+
+// IconCatalog is a versioned list of known icons.
+type IconCatalog struct {
+	Version string     `json:"version"`
+	Icons   []IconInfo `json:"icons"`
+
+	byName map[string]int
+	byID   map[Icon]int
+}
+
+// IconInfo is an entry in the icon catalog.
+type IconInfo struct {
+	ID       Icon     `json:"id"`
+	Name     string   `json:"name"`
+	Tags     []string `json:"tags,omitempty"`
+	Animated bool     `json:"animated,omitempty"`
+}
+
+// WriteTo writes the catalog as json to a given writer.
+func (ic *IconCatalog) WriteTo(w io.Writer) (int64, error) {
+	contents, err := json.MarshalIndent(ic, "", "\t")
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(append(contents, '\n'))
+	return int64(n), err
+}
+
+// Lookup finds an icon by name or by id.
+//
+// Names are matched case insensitively; the first icon with a given name wins.
+func (ic *IconCatalog) Lookup(name string) (IconInfo, bool) {
+	if ic == nil {
+		return IconInfo{}, false
+	}
+	if index, ok := ic.byName[strings.ToLower(strings.TrimSpace(name))]; ok {
+		return ic.Icons[index], true
+	}
+	if index, ok := ic.byID[Icon(strings.TrimSpace(name))]; ok {
+		return ic.Icons[index], true
+	}
+	return IconInfo{}, false
+}
+
+// Search returns the icons whose name or tags contain a given term.
+//
+// Results are ranked by exact name matches, then name prefix matches,
+// then name substring matches, then tag matches.
+func (ic *IconCatalog) Search(term string) []IconInfo {
+	if ic == nil {
+		return nil
+	}
+	term = strings.ToLower(strings.TrimSpace(term))
+	type ranked struct {
+		rank int
+		info IconInfo
+	}
+	var results []ranked
+	for _, info := range ic.Icons {
+		name := strings.ToLower(info.Name)
+		switch {
+		case name == term || string(info.ID) == term:
+			results = append(results, ranked{0, info})
+		case strings.HasPrefix(name, term):
+			results = append(results, ranked{1, info})
+		case strings.Contains(name, term):
+			results = append(results, ranked{2, info})
+		default:
+			for _, tag := range info.Tags {
+				if strings.Contains(strings.ToLower(tag), term) {
+					results = append(results, ranked{3, info})
+					break
+				}
+			}
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].rank < results[j].rank
+	})
+	output := make([]IconInfo, len(results))
+	for index := range results {
+		output[index] = results[index].info
+	}
+	return output
+}
+
+// Resolve returns the icon id for a given icon value.
+//
+// Icon ids (e.g. `i555`) and inline data uris are returned as is; names
+// are looked up in the catalog and an error is returned if they're not found.
+func (ic *IconCatalog) Resolve(icon Icon) (Icon, error) {
+	if icon == "" || isIconID(string(icon)) || strings.HasPrefix(string(icon), "data:") {
+		return icon, nil
+	}
+	info, ok := ic.Lookup(string(icon))
+	if !ok {
+		return "", fmt.Errorf("%w; %q", ErrIconUnknown, icon)
+	}
+	return info.ID, nil
+}
+
+// ResolveNotification returns a copy of a given notification with any
+// icon names in its frames resolved to icon ids.
+func (ic *IconCatalog) ResolveNotification(n Notification) (Notification, error) {
+	if len(n.Model.Frames) == 0 {
+		return n, nil
+	}
+	frames := make([]Frame, len(n.Model.Frames))
+	for index, frame := range n.Model.Frames {
+		icon, err := ic.Resolve(frame.Icon)
+		if err != nil {
+			return n, err
+		}
+		frame.Icon = icon
+		frames[index] = frame
+	}
+	n.Model.Frames = frames
+	return n, nil
+}
+
+func (ic *IconCatalog) index() {
+	ic.byName = make(map[string]int, len(ic.Icons))
+	ic.byID = make(map[Icon]int, len(ic.Icons))
+	for index, info := range ic.Icons {
+		name := strings.ToLower(info.Name)
+		if _, ok := ic.byName[name]; !ok {
+			ic.byName[name] = index
+		}
+		ic.byID[info.ID] = index
+	}
+}
+
+// isIconID returns if a value is of the form `i123` or `a123`.
+func isIconID(value string) bool {
+	if len(value) < 2 || (value[0] != 'i' && value[0] != 'a') {
+		return false
+	}
+	for _, r := range value[1:] {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func iconName(title string) string {
+	return strings.Join(strings.Fields(strings.ToLower(title)), "-")
+}
+
+func iconTags(title, category string) []string {
+	var tags []string
+	seen := make(map[string]bool)
+	for _, field := range append(strings.Fields(strings.ToLower(title)), strings.Fields(strings.ToLower(category))...) {
+		field = strings.Trim(field, ".,;:!?()[]\"'")
+		if field == "" || seen[field] {
+			continue
+		}
+		seen[field] = true
+		tags = append(tags, field)
+	}
+	return tags
+}
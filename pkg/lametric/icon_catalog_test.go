@@ -0,0 +1,83 @@
+package lametric
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/wcharczuk/lametric/pkg/apiutil"
+)
+
+func TestFetchIconDump(t *testing.T) {
+	var pages []string
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		page, _ := strconv.Atoi(req.URL.Query().Get("page"))
+		pages = append(pages, req.URL.Query().Get("page"))
+		var data []iconDumpEntry
+		switch page {
+		case 0:
+			for x := 0; x < iconsPageSize; x++ {
+				data = append(data, iconDumpEntry{ID: 1000 + x, Title: fmt.Sprintf("Icon %d", x), Type: "picture"})
+			}
+		case 1:
+			data = []iconDumpEntry{
+				{ID: 7, Title: "Rocket Launch", Type: "movie", Category: "space"},
+				{ID: 8, Title: "Coffee", Code: "i8", Type: "picture"},
+			}
+		}
+		_ = json.NewEncoder(rw).Encode(map[string]interface{}{"data": data})
+	}))
+	defer server.Close()
+
+	catalog, err := FetchIconDump(context.Background(), apiutil.New(server.URL), "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(pages, ",") != "0,1" {
+		t.Errorf("pages fetched: %v", pages)
+	}
+	if len(catalog.Icons) != iconsPageSize+2 {
+		t.Errorf("icons: %d", len(catalog.Icons))
+	}
+	rocket, ok := catalog.Lookup("rocket-launch")
+	if !ok || rocket.ID != "a7" || !rocket.Animated {
+		t.Errorf("rocket-launch: %+v %v", rocket, ok)
+	}
+	if icon, err := catalog.Resolve("coffee"); err != nil || icon != "i8" {
+		t.Errorf("coffee: %v %v", icon, err)
+	}
+}
+
+func TestDefaultIconCatalog(t *testing.T) {
+	catalog, err := ReadIconCatalog(bytes.NewReader(iconCatalogJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if catalog.Version == "" {
+		t.Errorf("expected a catalog version")
+	}
+	for _, icon := range AllIcons() {
+		if _, ok := catalog.Lookup(string(icon)); !ok {
+			t.Errorf("icon %q not in the shipped catalog", icon)
+		}
+	}
+	for _, name := range []string{"attention", "calendar", "smile"} {
+		info, ok := LookupIcon(name)
+		if !ok {
+			t.Errorf("%s: not found", name)
+			continue
+		}
+		if parsed, err := ParseIcon(name); err != nil || parsed != Icon(name) {
+			t.Errorf("%s: parsed %q, %v", name, parsed, err)
+		}
+		if resolved, err := catalog.Resolve(Icon(name)); err != nil || resolved != info.ID {
+			t.Errorf("%s: resolved %q, %v", name, resolved, err)
+		}
+	}
+}
@@ -0,0 +1,288 @@
+package lametric
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"io"
+	"os"
+)
+
+// IconSize is the width and height of a device icon in pixels.
+const IconSize = 8
+
+// IconColorLevels is the default number of levels per color channel
+// icon images are quantized to before encoding.
+const IconColorLevels = 32
+
+// Data uri prefixes for inline icons.
+const (
+	IconDataURIPNG = "data:image/png;base64,"
+	IconDataURIGIF = "data:image/gif;base64,"
+)
+
+// LoadIcon reads a png or gif file from a given path and returns it
+// as an inline icon.
+//
+// Gifs with more than one frame are encoded as animated icons.
+func LoadIcon(path string) (Icon, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	return ReadIcon(f)
+}
+
+// ReadIcon reads a png or gif image from a given reader and returns it
+// as an inline icon.
+func ReadIcon(r io.Reader) (Icon, error) {
+	contents, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case bytes.HasPrefix(contents, []byte("GIF8")):
+		g, err := gif.DecodeAll(bytes.NewReader(contents))
+		if err != nil {
+			return "", err
+		}
+		if len(g.Image) > 1 {
+			return EncodeAnimatedIcon(g)
+		}
+		if len(g.Image) == 0 {
+			return "", ErrIconEmpty
+		}
+		return EncodeIcon(g.Image[0])
+	case bytes.HasPrefix(contents, []byte("\x89PNG")):
+		img, err := png.Decode(bytes.NewReader(contents))
+		if err != nil {
+			return "", err
+		}
+		return EncodeIcon(img)
+	default:
+		return "", ErrIconFormatUnsupported
+	}
+}
+
+// EncodeIcon scales a given image to the icon size, quantizes its colors
+// and returns it as an inline png icon.
+func EncodeIcon(img image.Image) (Icon, error) {
+	if img == nil || img.Bounds().Empty() {
+		return "", ErrIconEmpty
+	}
+	scaled := scaleIcon(img)
+	var paletted *image.Paletted
+	for levels := IconColorLevels; levels > 1; levels = levels >> 1 {
+		quantizeIcon(scaled, levels)
+		palette := iconPalette(scaled)
+		if len(palette) <= 256 {
+			paletted = image.NewPaletted(scaled.Bounds(), palette)
+			draw.Draw(paletted, paletted.Bounds(), scaled, image.Point{}, draw.Src)
+			break
+		}
+	}
+	if paletted == nil {
+		return "", ErrIconTooManyColors
+	}
+	buffer := new(bytes.Buffer)
+	if err := png.Encode(buffer, paletted); err != nil {
+		return "", err
+	}
+	return Icon(IconDataURIPNG + base64.StdEncoding.EncodeToString(buffer.Bytes())), nil
+}
+
+// EncodeAnimatedIcon scales each frame of a given gif to the icon size,
+// quantizes the colors to a shared palette and returns it as an inline gif icon.
+//
+// Frame delays and the loop count of the original gif are preserved.
+func EncodeAnimatedIcon(g *gif.GIF) (Icon, error) {
+	if g == nil || len(g.Image) == 0 {
+		return "", ErrIconEmpty
+	}
+	frames := compositeGIF(g)
+	scaled := make([]*image.NRGBA, len(frames))
+	for index, frame := range frames {
+		scaled[index] = scaleIcon(frame)
+	}
+
+	var palette color.Palette
+	for levels := IconColorLevels; levels > 1; levels = levels >> 1 {
+		for _, frame := range scaled {
+			quantizeIcon(frame, levels)
+		}
+		palette = iconPalette(scaled...)
+		if len(palette) <= 256 {
+			break
+		}
+	}
+	if len(palette) > 256 {
+		return "", ErrIconTooManyColors
+	}
+
+	output := &gif.GIF{
+		LoopCount: g.LoopCount,
+	}
+	for index, frame := range scaled {
+		paletted := image.NewPaletted(frame.Bounds(), palette)
+		draw.Draw(paletted, paletted.Bounds(), frame, image.Point{}, draw.Src)
+		output.Image = append(output.Image, paletted)
+		var delay int
+		if index < len(g.Delay) {
+			delay = g.Delay[index]
+		}
+		output.Delay = append(output.Delay, delay)
+		output.Disposal = append(output.Disposal, gif.DisposalNone)
+	}
+	buffer := new(bytes.Buffer)
+	if err := gif.EncodeAll(buffer, output); err != nil {
+		return "", err
+	}
+	return Icon(IconDataURIGIF + base64.StdEncoding.EncodeToString(buffer.Bytes())), nil
+}
+
+// compositeGIF renders each (possibly partial) frame of a gif onto
+// the full canvas, respecting the frame disposal methods.
+func compositeGIF(g *gif.GIF) []image.Image {
+	bounds := image.Rect(0, 0, g.Config.Width, g.Config.Height)
+	if bounds.Empty() {
+		for _, frame := range g.Image {
+			bounds = bounds.Union(frame.Bounds())
+		}
+	}
+	canvas := image.NewNRGBA(bounds)
+	var output []image.Image
+	for index, frame := range g.Image {
+		var previous *image.NRGBA
+		var disposal byte
+		if index < len(g.Disposal) {
+			disposal = g.Disposal[index]
+		}
+		if disposal == gif.DisposalPrevious {
+			previous = image.NewNRGBA(bounds)
+			draw.Draw(previous, bounds, canvas, bounds.Min, draw.Src)
+		}
+		draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+
+		rendered := image.NewNRGBA(bounds)
+		draw.Draw(rendered, bounds, canvas, bounds.Min, draw.Src)
+		output = append(output, rendered)
+
+		switch disposal {
+		case gif.DisposalBackground:
+			draw.Draw(canvas, frame.Bounds(), image.Transparent, image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			canvas = previous
+		}
+	}
+	return output
+}
+
+// scaleIcon fits a given image into an icon sized canvas, preserving the aspect ratio.
+//
+// Larger images are downsampled by averaging the source pixels that
+// cover each icon pixel, smaller images use nearest neighbor sampling.
+func scaleIcon(img image.Image) *image.NRGBA {
+	src := img.Bounds()
+	output := image.NewNRGBA(image.Rect(0, 0, IconSize, IconSize))
+
+	width, height := IconSize, IconSize
+	if src.Dx() > src.Dy() {
+		height = maxInt(1, (src.Dy()*IconSize+src.Dx()/2)/src.Dx())
+	} else if src.Dy() > src.Dx() {
+		width = maxInt(1, (src.Dx()*IconSize+src.Dy()/2)/src.Dy())
+	}
+	offsetX, offsetY := (IconSize-width)/2, (IconSize-height)/2
+
+	for y := 0; y < height; y++ {
+		y0 := src.Min.Y + y*src.Dy()/height
+		y1 := maxInt(y0+1, src.Min.Y+(y+1)*src.Dy()/height)
+		for x := 0; x < width; x++ {
+			x0 := src.Min.X + x*src.Dx()/width
+			x1 := maxInt(x0+1, src.Min.X+(x+1)*src.Dx()/width)
+			output.SetNRGBA(offsetX+x, offsetY+y, averageColor(img, image.Rect(x0, y0, x1, y1)))
+		}
+	}
+	return output
+}
+
+// averageColor returns the alpha weighted average color of a region.
+func averageColor(img image.Image, region image.Rectangle) color.NRGBA {
+	var r, g, b, a, count uint64
+	for y := region.Min.Y; y < region.Max.Y; y++ {
+		for x := region.Min.X; x < region.Max.X; x++ {
+			pr, pg, pb, pa := img.At(x, y).RGBA()
+			r += uint64(pr)
+			g += uint64(pg)
+			b += uint64(pb)
+			a += uint64(pa)
+			count++
+		}
+	}
+	if count == 0 || a == 0 {
+		return color.NRGBA{}
+	}
+	// the channels returned by RGBA() are alpha premultiplied
+	return color.NRGBA{
+		R: uint8((r * 0xff) / a),
+		G: uint8((g * 0xff) / a),
+		B: uint8((b * 0xff) / a),
+		A: uint8((a / count) >> 8),
+	}
+}
+
+// quantizeIcon reduces each color channel to a given number of levels in place.
+//
+// The device does not blend partial transparency, so alpha is reduced to
+// either fully opaque or fully transparent.
+func quantizeIcon(img *image.NRGBA, levels int) {
+	step := 255 / (levels - 1)
+	quantize := func(v uint8) uint8 {
+		q := ((int(v) + step/2) / step) * step
+		if q > 255 {
+			q = 255
+		}
+		return uint8(q)
+	}
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := img.NRGBAAt(x, y)
+			if c.A < 0x80 {
+				img.SetNRGBA(x, y, color.NRGBA{})
+				continue
+			}
+			img.SetNRGBA(x, y, color.NRGBA{R: quantize(c.R), G: quantize(c.G), B: quantize(c.B), A: 0xff})
+		}
+	}
+}
+
+// iconPalette returns the distinct colors used by a given set of images.
+func iconPalette(images ...*image.NRGBA) color.Palette {
+	seen := make(map[color.NRGBA]bool)
+	var palette color.Palette
+	for _, img := range images {
+		bounds := img.Bounds()
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				c := img.NRGBAAt(x, y)
+				if !seen[c] {
+					seen[c] = true
+					palette = append(palette, c)
+				}
+			}
+		}
+	}
+	return palette
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
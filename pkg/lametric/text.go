@@ -0,0 +1,287 @@
+package lametric
+
+import (
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Display dimensions in pixels.
+const (
+	DisplayWidth  = 37
+	DisplayHeight = 8
+)
+
+// DefaultTextMetrics are the text metrics of the device font.
+var DefaultTextMetrics = TextMetrics{
+	GlyphWidths: map[rune]int{
+		' ': 2, '!': 1, '.': 1, ',': 1, ':': 1, ';': 1, '\'': 1, '|': 1, 'i': 1,
+		'l': 2, '(': 2, ')': 2, '[': 2, ']': 2, '`': 2, 'I': 3,
+		'M': 5, 'W': 5, 'm': 5, 'w': 5, '#': 5, '%': 5, '&': 5, '@': 5,
+		'N': 4, 'Q': 4, 'T': 3, '~': 4,
+	},
+	DefaultGlyphWidth: 3,
+	WideGlyphWidth:    5,
+	LetterSpacing:     1,
+	ScrollSpeed:       18,
+	StaticDuration:    4 * time.Second,
+	ScrollPause:       time.Second,
+}
+
+// TextMetrics describe how text is rendered and scrolled on the device.
+type TextMetrics struct {
+	// GlyphWidths are the widths in pixels of glyphs that differ from the default.
+	GlyphWidths map[rune]int
+	// DefaultGlyphWidth is the width of ascii glyphs not in GlyphWidths.
+	DefaultGlyphWidth int
+	// WideGlyphWidth is the width of non-ascii glyphs not in GlyphWidths.
+	WideGlyphWidth int
+	// LetterSpacing is the spacing in pixels between glyphs.
+	LetterSpacing int
+	// ScrollSpeed is the scroll speed in pixels per second.
+	ScrollSpeed int
+	// StaticDuration is how long a frame that doesn't scroll is shown.
+	StaticDuration time.Duration
+	// ScrollPause is how long a scrolling frame pauses at its start and end.
+	ScrollPause time.Duration
+}
+
+// TextWidth returns the rendered width in pixels of a given text.
+func (tm TextMetrics) TextWidth(text string) (width int) {
+	var glyphs int
+	for _, r := range text {
+		if w, ok := tm.GlyphWidths[r]; ok {
+			width += w
+		} else if r > unicode.MaxASCII {
+			width += tm.WideGlyphWidth
+		} else {
+			width += tm.DefaultGlyphWidth
+		}
+		glyphs++
+	}
+	if glyphs > 1 {
+		width += (glyphs - 1) * tm.LetterSpacing
+	}
+	return
+}
+
+// TextArea returns the width in pixels available to the text of a given frame.
+func (tm TextMetrics) TextArea(f Frame) int {
+	if f.Icon != "" {
+		return DisplayWidth - IconSize - 1
+	}
+	return DisplayWidth
+}
+
+// Fits returns if the text of a given frame fits without scrolling.
+func (tm TextMetrics) Fits(f Frame) bool {
+	return tm.TextWidth(f.Text) <= tm.TextArea(f)
+}
+
+// FrameDuration estimates how long a given frame is shown for.
+//
+// Frames that fit are shown for the static duration; frames that scroll
+// are shown until the end of the text is visible, plus a pause at either end.
+func (tm TextMetrics) FrameDuration(f Frame) time.Duration {
+	if f.Text == "" || tm.Fits(f) || tm.ScrollSpeed <= 0 {
+		return tm.StaticDuration
+	}
+	overflow := tm.TextWidth(f.Text) - tm.TextArea(f)
+	return 2*tm.ScrollPause + (time.Duration(overflow)*time.Second)/time.Duration(tm.ScrollSpeed)
+}
+
+// NotificationDuration estimates how long a given notification occupies the device,
+// i.e. the duration of each frame for each cycle.
+func (tm TextMetrics) NotificationDuration(n Notification) (total time.Duration) {
+	for _, frame := range n.Model.Frames {
+		total += tm.FrameDuration(frame)
+	}
+	if n.Model.Cycles > 1 {
+		total = total * time.Duration(n.Model.Cycles)
+	}
+	return
+}
+
+// TextWidth returns the rendered width in pixels of a given text with the default metrics.
+func TextWidth(text string) int {
+	return DefaultTextMetrics.TextWidth(text)
+}
+
+// FrameDuration estimates how long a given frame is shown for with the default metrics.
+func FrameDuration(f Frame) time.Duration {
+	return DefaultTextMetrics.FrameDuration(f)
+}
+
+// NotificationDuration estimates how long a given notification occupies
+// the device with the default metrics.
+func NotificationDuration(n Notification) time.Duration {
+	return DefaultTextMetrics.NotificationDuration(n)
+}
+
+// DefaultAbbreviations are common abbreviations applied to text that doesn't fit.
+var DefaultAbbreviations = map[string]string{
+	"and":         "&",
+	"average":     "avg",
+	"critical":    "crit",
+	"deployment":  "deploy",
+	"development": "dev",
+	"environment": "env",
+	"error":       "err",
+	"errors":      "errs",
+	"hours":       "h",
+	"maximum":     "max",
+	"minimum":     "min",
+	"minutes":     "m",
+	"percent":     "%",
+	"production":  "prod",
+	"request":     "req",
+	"requests":    "reqs",
+	"seconds":     "s",
+	"warning":     "warn",
+}
+
+// TextPolicy describes how to fit text that doesn't fit the display.
+type TextPolicy struct {
+	// Metrics are the text metrics to use; if unset the default metrics are used.
+	Metrics *TextMetrics
+	// Abbreviations replace (case insensitive) whole words when text doesn't fit.
+	Abbreviations map[string]string
+	// Split splits text that doesn't fit into multiple frames at word boundaries.
+	Split bool
+	// MaxFrames limits the number of frames a split frame produces; zero is unlimited.
+	MaxFrames int
+	// Truncate truncates the text (of the last frame if split) that doesn't fit, with an ellipsis.
+	Truncate bool
+}
+
+// Ellipsis is the suffix added to truncated text.
+const Ellipsis = "..."
+
+// FitNotification returns a copy of a given notification with each frame fit to the display.
+func FitNotification(n Notification, policy TextPolicy) Notification {
+	if len(n.Model.Frames) == 0 {
+		return n
+	}
+	var frames []Frame
+	for _, frame := range n.Model.Frames {
+		frames = append(frames, FitFrame(frame, policy)...)
+	}
+	n.Model.Frames = frames
+	return n
+}
+
+// FitFrame fits the text of a given frame to the display by applying the policy
+// abbreviations, then splitting, then truncating.
+func FitFrame(f Frame, policy TextPolicy) []Frame {
+	metrics := DefaultTextMetrics
+	if policy.Metrics != nil {
+		metrics = *policy.Metrics
+	}
+	if f.Text == "" || metrics.Fits(f) {
+		return []Frame{f}
+	}
+	if len(policy.Abbreviations) > 0 {
+		f.Text = abbreviate(f.Text, policy.Abbreviations)
+		if metrics.Fits(f) {
+			return []Frame{f}
+		}
+	}
+	area := metrics.TextArea(f)
+	if !policy.Split {
+		if policy.Truncate {
+			f.Text = truncateText(metrics, f.Text, area)
+		}
+		return []Frame{f}
+	}
+
+	var output []Frame
+	for _, line := range wrapText(metrics, f.Text, area) {
+		frame := f
+		frame.Text = line
+		output = append(output, frame)
+	}
+	if policy.MaxFrames > 0 && len(output) > policy.MaxFrames {
+		remainder := make([]string, 0, len(output)-policy.MaxFrames+1)
+		for _, frame := range output[policy.MaxFrames-1:] {
+			remainder = append(remainder, frame.Text)
+		}
+		output = output[:policy.MaxFrames]
+		last := strings.Join(remainder, " ")
+		if policy.Truncate {
+			last = truncateText(metrics, last, area)
+		}
+		output[len(output)-1].Text = last
+	}
+	return output
+}
+
+// abbreviate replaces whole words found in a given abbreviations map.
+func abbreviate(text string, abbreviations map[string]string) string {
+	words := strings.Fields(text)
+	for index, word := range words {
+		trimmed := strings.TrimRightFunc(word, unicode.IsPunct)
+		if abbreviation, ok := abbreviations[strings.ToLower(trimmed)]; ok && trimmed != "" && abbreviation != "" {
+			if first, _ := utf8.DecodeRuneInString(trimmed); unicode.IsUpper(first) {
+				head, size := utf8.DecodeRuneInString(abbreviation)
+				abbreviation = string(unicode.ToUpper(head)) + abbreviation[size:]
+			}
+			words[index] = abbreviation + word[len(trimmed):]
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// wrapText packs the words of a given text into lines no wider than a given width,
+// breaking words that are wider than a line on their own.
+func wrapText(metrics TextMetrics, text string, width int) (lines []string) {
+	var line string
+	for _, word := range strings.Fields(text) {
+		candidate := word
+		if line != "" {
+			candidate = line + " " + word
+		}
+		if metrics.TextWidth(candidate) <= width {
+			line = candidate
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+			line = ""
+		}
+		for metrics.TextWidth(word) > width {
+			head := fitPrefix(metrics, word, width)
+			lines = append(lines, head)
+			word = word[len(head):]
+		}
+		line = word
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return
+}
+
+// truncateText truncates a given text with an ellipsis so that it's no wider than a given width.
+func truncateText(metrics TextMetrics, text string, width int) string {
+	if metrics.TextWidth(text) <= width {
+		return text
+	}
+	available := width - metrics.TextWidth(Ellipsis) - metrics.LetterSpacing
+	return strings.TrimRightFunc(fitPrefix(metrics, text, available), unicode.IsSpace) + Ellipsis
+}
+
+// fitPrefix returns the longest prefix of a given text (of at least one rune) no wider than a given width.
+func fitPrefix(metrics TextMetrics, text string, width int) string {
+	var end int
+	for index := range text {
+		// invalid utf-8 is one byte per rune, so the size is decoded rather than encoded.
+		_, size := utf8.DecodeRuneInString(text[index:])
+		next := index + size
+		if end > 0 && metrics.TextWidth(text[:next]) > width {
+			break
+		}
+		end = next
+	}
+	return text[:end]
+}
@@ -0,0 +1,135 @@
+package lametric
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestTextWidth(t *testing.T) {
+	testCases := [...]struct {
+		Text   string
+		Expect int
+	}{
+		{Text: "", Expect: 0},
+		{Text: "a", Expect: 3},
+		{Text: "ab", Expect: 7},
+		{Text: "il", Expect: 4},
+		{Text: "MW", Expect: 11},
+		{Text: "a b", Expect: 10},
+		{Text: "é", Expect: 5},
+		{Text: "日本", Expect: 11},
+		{Text: "\xff\xfe", Expect: 11},
+	}
+	for _, tc := range testCases {
+		if actual := TextWidth(tc.Text); actual != tc.Expect {
+			t.Errorf("%q: expected %d, actual %d", tc.Text, tc.Expect, actual)
+		}
+	}
+}
+
+func TestTextArea(t *testing.T) {
+	if actual := DefaultTextMetrics.TextArea(Frame{Text: "hi"}); actual != DisplayWidth {
+		t.Errorf("expected the display width without an icon, actual %d", actual)
+	}
+	if actual := DefaultTextMetrics.TextArea(Frame{Icon: IconAttention, Text: "hi"}); actual != DisplayWidth-IconSize-1 {
+		t.Errorf("expected the display width less the icon, actual %d", actual)
+	}
+	// 9 glyphs of 3 and the spacing between them is 35 pixels.
+	if !DefaultTextMetrics.Fits(Frame{Text: "abcdefghj"}) || DefaultTextMetrics.Fits(Frame{Icon: IconAttention, Text: "abcdefghj"}) {
+		t.Errorf("expected 35 pixels to fit without an icon only")
+	}
+}
+
+func TestFitFrame(t *testing.T) {
+	const deploy = "Deploy of api to production finished"
+	testCases := [...]struct {
+		Name   string
+		Icon   Icon
+		Text   string
+		Policy TextPolicy
+		Expect []string
+	}{
+		{Name: "empty", Text: "", Policy: TextPolicy{Split: true, Truncate: true}, Expect: []string{""}},
+		{Name: "fits", Text: "Deployed", Policy: TextPolicy{Split: true, Truncate: true}, Expect: []string{"Deployed"}},
+		{Name: "no policy", Text: deploy, Expect: []string{deploy}},
+		{Name: "truncate", Text: deploy, Policy: TextPolicy{Truncate: true}, Expect: []string{"Deploy o..."}},
+		{Name: "truncate icon", Icon: IconAttention, Text: deploy, Policy: TextPolicy{Truncate: true}, Expect: []string{"Deploy..."}},
+		{Name: "split", Text: deploy, Policy: TextPolicy{Split: true}, Expect: []string{"Deploy of", "api to", "production", "finished"}},
+		{Name: "split long words", Icon: IconAttention, Text: deploy, Policy: TextPolicy{Split: true}, Expect: []string{"Deploy", "of api", "to", "product", "ion", "finished"}},
+		{Name: "split max frames", Text: deploy, Policy: TextPolicy{Split: true, MaxFrames: 2}, Expect: []string{"Deploy of", "api to production finished"}},
+		{Name: "split max frames truncated", Text: deploy, Policy: TextPolicy{Split: true, MaxFrames: 2, Truncate: true}, Expect: []string{"Deploy of", "api to pr..."}},
+		{Name: "abbreviate", Text: "Production deployment and warning errors", Policy: TextPolicy{Abbreviations: DefaultAbbreviations}, Expect: []string{"Prod deploy & warn errs"}},
+		{Name: "abbreviate then fits", Text: "production errors", Policy: TextPolicy{Abbreviations: DefaultAbbreviations, Truncate: true}, Expect: []string{"prod errs"}},
+		{Name: "abbreviate multibyte", Text: "Overview ready", Policy: TextPolicy{Abbreviations: map[string]string{"overview": "übersicht"}}, Expect: []string{"Übersicht ready"}},
+		{Name: "multibyte truncate", Text: "ééééééééééééé", Policy: TextPolicy{Truncate: true}, Expect: []string{"ééééé..."}},
+		{Name: "multibyte split", Text: "ééééééééééééé", Policy: TextPolicy{Split: true}, Expect: []string{"éééééé", "éééééé", "é"}},
+		{Name: "cjk split", Icon: IconAttention, Text: "日本語のテキストです", Policy: TextPolicy{Split: true}, Expect: []string{"日本語の", "テキスト", "です"}},
+		{Name: "invalid utf-8 split", Icon: IconAttention, Text: "\xff\xfe\xfd\xfc\xfb\xfa\xf9\xf8\xf7\xf6", Policy: TextPolicy{Split: true}, Expect: []string{"\xff\xfe\xfd\xfc", "\xfb\xfa\xf9\xf8", "\xf7\xf6"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			frames := FitFrame(Frame{Icon: tc.Icon, Text: tc.Text}, tc.Policy)
+			var actual []string
+			for _, frame := range frames {
+				if frame.Icon != tc.Icon {
+					t.Errorf("expected each frame to keep the icon, actual %q", frame.Icon)
+				}
+				actual = append(actual, frame.Text)
+			}
+			if strings.Join(actual, "|") != strings.Join(tc.Expect, "|") {
+				t.Fatalf("expected %q, actual %q", tc.Expect, actual)
+			}
+			if !tc.Policy.Split && !tc.Policy.Truncate {
+				return
+			}
+			for index, frame := range frames {
+				// only the last frame may overflow, when the frames are limited and it isn't truncated.
+				overflows := index == len(frames)-1 && tc.Policy.MaxFrames > 0 && !tc.Policy.Truncate
+				if !overflows && !DefaultTextMetrics.Fits(frame) {
+					t.Errorf("expected %q to fit", frame.Text)
+				}
+				if utf8.ValidString(tc.Text) && !utf8.ValidString(frame.Text) {
+					t.Errorf("expected %q not to split a rune", frame.Text)
+				}
+			}
+		})
+	}
+}
+
+func TestFitNotification(t *testing.T) {
+	n := Notification{Model: NotificationModel{Frames: []Frame{
+		{Icon: IconAttention, Text: "ALERT"},
+		{Text: "Deploy of api to production finished"},
+	}}}
+	fitted := FitNotification(n, TextPolicy{Split: true})
+	if len(fitted.Model.Frames) != 5 || fitted.Model.Frames[0].Text != "ALERT" || fitted.Model.Frames[1].Text != "Deploy of" {
+		t.Errorf("expected the second frame to be split, actual %+v", fitted.Model.Frames)
+	}
+	if len(n.Model.Frames) != 2 {
+		t.Errorf("expected the notification not to be changed")
+	}
+	if empty := FitNotification(Notification{}, TextPolicy{Split: true}); len(empty.Model.Frames) != 0 {
+		t.Errorf("expected no frames, actual %+v", empty.Model.Frames)
+	}
+}
+
+func TestNotificationDuration(t *testing.T) {
+	if actual := FrameDuration(Frame{}); actual != DefaultTextMetrics.StaticDuration {
+		t.Errorf("expected an empty frame to be static, actual %v", actual)
+	}
+	if actual := FrameDuration(Frame{Text: "hi"}); actual != DefaultTextMetrics.StaticDuration {
+		t.Errorf("expected a frame that fits to be static, actual %v", actual)
+	}
+	// 129 pixels of text scroll 92 pixels past the display at 18 pixels a second.
+	scrolling := Frame{Text: "Deploy of api to production finished"}
+	expect := 2*time.Second + 92*time.Second/18
+	if actual := FrameDuration(scrolling); actual != expect {
+		t.Errorf("expected %v, actual %v", expect, actual)
+	}
+	n := Notification{Model: NotificationModel{Frames: []Frame{{Text: "hi"}, scrolling}, Cycles: 2}}
+	if actual := NotificationDuration(n); actual != 2*(4*time.Second+expect) {
+		t.Errorf("expected %v, actual %v", 2*(4*time.Second+expect), actual)
+	}
+}
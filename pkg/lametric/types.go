@@ -5,9 +5,9 @@ type NotificationPriority string
 
 // Notification Priorities
 const (
-	NotificationPriorityInfo     = "info"
-	NotificationPriorityWarning  = "warning"
-	NotificationPriorityCritical = "critical"
+	NotificationPriorityInfo     NotificationPriority = "info"
+	NotificationPriorityWarning  NotificationPriority = "warning"
+	NotificationPriorityCritical NotificationPriority = "critical"
 )
 
 // IconType is a type for a icons.
@@ -15,40 +15,40 @@ type IconType string
 
 // Icon Types
 const (
-	IconTypeNone  = "none"
-	IconTypeInfo  = "info"
-	IconTypeAlert = "alert"
+	IconTypeNone  IconType = "none"
+	IconTypeInfo  IconType = "info"
+	IconTypeAlert IconType = "alert"
 )
 
 // Notification is the input for .
 type Notification struct {
-	Priority NotificationPriority `json:"priority,omitempty"`
-	IconType IconType             `json:"iconType,omitempty"`
-	Lifetime int                  `json:"lifetime,omitempty"`
-	Model    NotificationModel    `json:"model"`
+	Priority NotificationPriority `json:"priority,omitempty" yaml:"priority,omitempty"`
+	IconType IconType             `json:"iconType,omitempty" yaml:"iconType,omitempty"`
+	Lifetime int                  `json:"lifetime,omitempty" yaml:"lifetime,omitempty"`
+	Model    NotificationModel    `json:"model" yaml:"model"`
 }
 
 // NotificationModel is a component.
 type NotificationModel struct {
-	Frames []Frame `json:"frames,omitempty"`
-	Sound  *Sound  `json:"sound,omitempty"`
-	Cycles int     `json:"cycles,omitempty"`
+	Frames []Frame `json:"frames,omitempty" yaml:"frames,omitempty"`
+	Sound  *Sound  `json:"sound,omitempty" yaml:"sound,omitempty"`
+	Cycles int     `json:"cycles,omitempty" yaml:"cycles,omitempty"`
 }
 
 // Frame is a component.
 type Frame struct {
-	Icon      string    `json:"icon,omitempty"`
-	Text      string    `json:"text,omitempty"`
-	GoalData  *GoalData `json:"goalData,omitempty"`
-	ChartData []int     `json:"chartData,omitempty"`
+	Icon      Icon      `json:"icon,omitempty" yaml:"icon,omitempty"`
+	Text      string    `json:"text,omitempty" yaml:"text,omitempty"`
+	GoalData  *GoalData `json:"goalData,omitempty" yaml:"goalData,omitempty"`
+	ChartData []int     `json:"chartData,omitempty" yaml:"chartData,omitempty"`
 }
 
 // GoalData is a gauge for a notification.
 type GoalData struct {
-	Start   float64 `json:"start,omitempty"`
-	Current float64 `json:"current,omitempty"`
-	End     float64 `json:"end,omitempty"`
-	Unit    string  `json:"unit,omitempty"`
+	Start   float64 `json:"start,omitempty" yaml:"start,omitempty"`
+	Current float64 `json:"current,omitempty" yaml:"current,omitempty"`
+	End     float64 `json:"end,omitempty" yaml:"end,omitempty"`
+	Unit    string  `json:"unit,omitempty" yaml:"unit,omitempty"`
 }
 
 // SoundCategory is a category for sounds.
@@ -56,15 +56,15 @@ type SoundCategory string
 
 // Sound Categories
 const (
-	SoundCategoryAlarms        = "alarms"
-	SoundCategoryNotifications = "notifications"
+	SoundCategoryAlarms        SoundCategory = "alarms"
+	SoundCategoryNotifications SoundCategory = "notifications"
 )
 
 // Sound is options for sound notifications.
 type Sound struct {
-	Category SoundCategory `json:"category,omitempty"`
-	ID       SoundID       `json:"id,omitempty"`
-	Repeat   int           `json:"repeat,omitempty"`
+	Category SoundCategory `json:"category,omitempty" yaml:"category,omitempty"`
+	ID       SoundID       `json:"id,omitempty" yaml:"id,omitempty"`
+	Repeat   int           `json:"repeat,omitempty" yaml:"repeat,omitempty"`
 }
 
 // SoundID is a sound identifier
@@ -72,65 +72,121 @@ type SoundID string
 
 // Sound IDs
 const (
-	SoundNotificationBicycle     = "bicycle"
-	SoundNotificationCar         = "car"
-	SoundNotificationCash        = "cash"
-	SoundNotificationCat         = "cat"
-	SoundNotificationDog         = "dog"
-	SoundNotificationDog2        = "dog2"
-	SoundNotificationEnergy      = "energy"
-	SoundNotificationKnockKnock  = "knock-knock"
-	SoundNotificationLetterEmail = "letter_email"
-	SoundNotificationLose1       = "lose1"
-	SoundNotificationLose2       = "lose2"
-	SoundNotificationNegative1   = "negative1"
-	SoundNotificationNegative2   = "negative2"
-	SoundNotificationNegative3   = "negative3"
-	SoundNotificationNegative4   = "negative4"
-	SoundNotificationNegative5   = "negative5"
-	SoundNotification            = "notification"
-	SoundNotification2           = "notification2"
-	SoundNotification3           = "notification3"
-	SoundNotification4           = "notification4"
-	SoundNotificationOpenDoor    = "open_door"
-	SoundNotificationPositive1   = "positive1"
-	SoundNotificationPositive2   = "positive2"
-	SoundNotificationPositive3   = "positive3"
-	SoundNotificationPositive4   = "positive4"
-	SoundNotificationPositive5   = "positive5"
-	SoundNotificationPositive6   = "positive6"
-	SoundNotificationStatistic   = "statistic"
-	SoundNotificationThunder     = "thunder"
-	SoundNotificationWater1      = "water1"
-	SoundNotificationWater2      = "water2"
-	SoundNotificationWin         = "win"
-	SoundNotificationWin2        = "win2"
-	SoundNotificationWind        = "wind"
-	SoundNotificationWindShort   = "wind_short"
-
-	SoundAlarm1  = "alarm1"
-	SoundAlarm2  = "alarm2"
-	SoundAlarm3  = "alarm3"
-	SoundAlarm4  = "alarm4"
-	SoundAlarm5  = "alarm5"
-	SoundAlarm6  = "alarm6"
-	SoundAlarm7  = "alarm7"
-	SoundAlarm8  = "alarm8"
-	SoundAlarm9  = "alarm9"
-	SoundAlarm10 = "alarm10"
-	SoundAlarm11 = "alarm11"
-	SoundAlarm12 = "alarm12"
-	SoundAlarm13 = "alarm13"
+	SoundNotificationBicycle     SoundID = "bicycle"
+	SoundNotificationCar         SoundID = "car"
+	SoundNotificationCash        SoundID = "cash"
+	SoundNotificationCat         SoundID = "cat"
+	SoundNotificationDog         SoundID = "dog"
+	SoundNotificationDog2        SoundID = "dog2"
+	SoundNotificationEnergy      SoundID = "energy"
+	SoundNotificationKnockKnock  SoundID = "knock-knock"
+	SoundNotificationLetterEmail SoundID = "letter_email"
+	SoundNotificationLose1       SoundID = "lose1"
+	SoundNotificationLose2       SoundID = "lose2"
+	SoundNotificationNegative1   SoundID = "negative1"
+	SoundNotificationNegative2   SoundID = "negative2"
+	SoundNotificationNegative3   SoundID = "negative3"
+	SoundNotificationNegative4   SoundID = "negative4"
+	SoundNotificationNegative5   SoundID = "negative5"
+	SoundNotification            SoundID = "notification"
+	SoundNotification2           SoundID = "notification2"
+	SoundNotification3           SoundID = "notification3"
+	SoundNotification4           SoundID = "notification4"
+	SoundNotificationOpenDoor    SoundID = "open_door"
+	SoundNotificationPositive1   SoundID = "positive1"
+	SoundNotificationPositive2   SoundID = "positive2"
+	SoundNotificationPositive3   SoundID = "positive3"
+	SoundNotificationPositive4   SoundID = "positive4"
+	SoundNotificationPositive5   SoundID = "positive5"
+	SoundNotificationPositive6   SoundID = "positive6"
+	SoundNotificationStatistic   SoundID = "statistic"
+	SoundNotificationThunder     SoundID = "thunder"
+	SoundNotificationWater1      SoundID = "water1"
+	SoundNotificationWater2      SoundID = "water2"
+	SoundNotificationWin         SoundID = "win"
+	SoundNotificationWin2        SoundID = "win2"
+	SoundNotificationWind        SoundID = "wind"
+	SoundNotificationWindShort   SoundID = "wind_short"
+
+	SoundAlarm1  SoundID = "alarm1"
+	SoundAlarm2  SoundID = "alarm2"
+	SoundAlarm3  SoundID = "alarm3"
+	SoundAlarm4  SoundID = "alarm4"
+	SoundAlarm5  SoundID = "alarm5"
+	SoundAlarm6  SoundID = "alarm6"
+	SoundAlarm7  SoundID = "alarm7"
+	SoundAlarm8  SoundID = "alarm8"
+	SoundAlarm9  SoundID = "alarm9"
+	SoundAlarm10 SoundID = "alarm10"
+	SoundAlarm11 SoundID = "alarm11"
+	SoundAlarm12 SoundID = "alarm12"
+	SoundAlarm13 SoundID = "alarm13"
 )
 
 // CreateNotificationOutput is the output for CreateNotification.
 type CreateNotificationOutput struct {
-	Success Identifier `json:"success"`
+	Success Identifier `json:"success" yaml:"success"`
 }
 
 // Identifier is an identifier.
 type Identifier struct {
-	ID string `json:"id"`
+	ID string `json:"id" yaml:"id"`
+}
+
+// BrightnessMode is how the display brightness is set.
+type BrightnessMode string
+
+// Brightness Modes
+const (
+	BrightnessModeAuto   BrightnessMode = "auto"
+	BrightnessModeManual BrightnessMode = "manual"
+)
+
+// Display is the display state.
+type Display struct {
+	Brightness     int            `json:"brightness" yaml:"brightness"`
+	BrightnessMode BrightnessMode `json:"brightness_mode" yaml:"brightnessMode"`
+	Width          int            `json:"width,omitempty" yaml:"width,omitempty"`
+	Height         int            `json:"height,omitempty" yaml:"height,omitempty"`
+	Type           string         `json:"type,omitempty" yaml:"type,omitempty"`
+}
+
+// UpdateDisplayInput is the input for UpdateDisplay; unset fields are left as is.
+type UpdateDisplayInput struct {
+	Brightness     *int           `json:"brightness,omitempty"`
+	BrightnessMode BrightnessMode `json:"brightness_mode,omitempty"`
+}
+
+// Audio is the audio state.
+type Audio struct {
+	Volume int `json:"volume" yaml:"volume"`
+}
+
+// Device is the device info.
+type Device struct {
+	ID           string  `json:"id" yaml:"id"`
+	Name         string  `json:"name" yaml:"name"`
+	SerialNumber string  `json:"serial_number" yaml:"serialNumber"`
+	OSVersion    string  `json:"os_version" yaml:"osVersion"`
+	Mode         string  `json:"mode" yaml:"mode"`
+	Model        string  `json:"model" yaml:"model"`
+	Audio        Audio   `json:"audio" yaml:"audio"`
+	Display      Display `json:"display" yaml:"display"`
+}
+
+// App is a local push indicator app on a device.
+type App struct {
+	// ID is the app package id, e.g. `com.lametric.<id>`.
+	ID string `json:"id" yaml:"id"`
+	// Version is the app version in its push url; defaults to 1.
+	Version int `json:"version,omitempty" yaml:"version,omitempty"`
+	// Token is the app access token.
+	Token string `json:"token" yaml:"token"`
+}
+
+// AppFrames is the input for PushApp.
+type AppFrames struct {
+	Frames []Frame `json:"frames"`
 }
 
 // Icon is a constant for an icon.
@@ -138,26 +194,26 @@ type Icon string
 
 // Icon constants
 const (
-	IconAppleLogo   = "i37"
-	IconAttention   = "i555"
-	IconBeach       = "i386"
-	IconCalendar    = "i66"
-	IconClock       = "i82"
-	IconDoge        = "i6219"
-	IconDollar      = "i34"
-	IconFacebook    = "i128"
-	IconFacebookAlt = "i28817"
-	IconGmail       = "i43"
-	IconHeart       = "i230"
-	IconInstagram   = "i3741"
-	IconMario       = "i3061"
-	IconMatrix      = "i653"
-	IconPoop        = "i8520"
-	IconRSS         = "i85"
-	IconSmile       = "i87"
-	IconTool        = "i93"
-	IconTwitch      = "i549"
-	IconTwitter     = "i70"
-	IconUSA         = "i413"
-	IconYoutube     = "i974"
+	IconAppleLogo   Icon = "i37"
+	IconAttention   Icon = "i555"
+	IconBeach       Icon = "i386"
+	IconCalendar    Icon = "i66"
+	IconClock       Icon = "i82"
+	IconDoge        Icon = "i6219"
+	IconDollar      Icon = "i34"
+	IconFacebook    Icon = "i128"
+	IconFacebookAlt Icon = "i28817"
+	IconGmail       Icon = "i43"
+	IconHeart       Icon = "i230"
+	IconInstagram   Icon = "i3741"
+	IconMario       Icon = "i3061"
+	IconMatrix      Icon = "i653"
+	IconPoop        Icon = "i8520"
+	IconRSS         Icon = "i85"
+	IconSmile       Icon = "i87"
+	IconTool        Icon = "i93"
+	IconTwitch      Icon = "i549"
+	IconTwitter     Icon = "i70"
+	IconUSA         Icon = "i413"
+	IconYoutube     Icon = "i974"
 )
@@ -0,0 +1,359 @@
+package lametric
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// AllNotificationPriorities returns the valid notification priorities.
+func AllNotificationPriorities() []NotificationPriority {
+	return []NotificationPriority{
+		NotificationPriorityInfo,
+		NotificationPriorityWarning,
+		NotificationPriorityCritical,
+	}
+}
+
+// ParseNotificationPriority parses a notification priority, returning an error if it's unknown.
+func ParseNotificationPriority(value string) (NotificationPriority, error) {
+	for _, priority := range AllNotificationPriorities() {
+		if string(priority) == value {
+			return priority, nil
+		}
+	}
+	return "", fmt.Errorf("%w; %q is not one of %v", ErrNotificationPriorityUnknown, value, AllNotificationPriorities())
+}
+
+// String implements fmt.Stringer.
+func (np NotificationPriority) String() string { return string(np) }
+
+// MarshalText implements encoding.TextMarshaler.
+func (np NotificationPriority) MarshalText() ([]byte, error) { return []byte(np), nil }
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (np *NotificationPriority) UnmarshalText(text []byte) (err error) {
+	*np, err = ParseNotificationPriority(string(text))
+	return
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (np *NotificationPriority) UnmarshalYAML(node *yaml.Node) error {
+	return unmarshalYAMLText(node, np.UnmarshalText)
+}
+
+// AllBrightnessModes returns the valid brightness modes.
+func AllBrightnessModes() []BrightnessMode {
+	return []BrightnessMode{
+		BrightnessModeAuto,
+		BrightnessModeManual,
+	}
+}
+
+// ParseBrightnessMode parses a brightness mode, returning an error if it's unknown.
+func ParseBrightnessMode(value string) (BrightnessMode, error) {
+	for _, mode := range AllBrightnessModes() {
+		if string(mode) == value {
+			return mode, nil
+		}
+	}
+	return "", fmt.Errorf("%w; %q is not one of %v", ErrBrightnessModeUnknown, value, AllBrightnessModes())
+}
+
+// String implements fmt.Stringer.
+func (bm BrightnessMode) String() string { return string(bm) }
+
+// MarshalText implements encoding.TextMarshaler.
+func (bm BrightnessMode) MarshalText() ([]byte, error) { return []byte(bm), nil }
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (bm *BrightnessMode) UnmarshalText(text []byte) (err error) {
+	*bm, err = ParseBrightnessMode(string(text))
+	return
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (bm *BrightnessMode) UnmarshalYAML(node *yaml.Node) error {
+	return unmarshalYAMLText(node, bm.UnmarshalText)
+}
+
+// AllIconTypes returns the valid icon types.
+func AllIconTypes() []IconType {
+	return []IconType{
+		IconTypeNone,
+		IconTypeInfo,
+		IconTypeAlert,
+	}
+}
+
+// ParseIconType parses an icon type, returning an error if it's unknown.
+func ParseIconType(value string) (IconType, error) {
+	for _, iconType := range AllIconTypes() {
+		if string(iconType) == value {
+			return iconType, nil
+		}
+	}
+	return "", fmt.Errorf("%w; %q is not one of %v", ErrIconTypeUnknown, value, AllIconTypes())
+}
+
+// String implements fmt.Stringer.
+func (it IconType) String() string { return string(it) }
+
+// MarshalText implements encoding.TextMarshaler.
+func (it IconType) MarshalText() ([]byte, error) { return []byte(it), nil }
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (it *IconType) UnmarshalText(text []byte) (err error) {
+	*it, err = ParseIconType(string(text))
+	return
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (it *IconType) UnmarshalYAML(node *yaml.Node) error {
+	return unmarshalYAMLText(node, it.UnmarshalText)
+}
+
+// AllSoundCategories returns the valid sound categories.
+func AllSoundCategories() []SoundCategory {
+	return []SoundCategory{
+		SoundCategoryNotifications,
+		SoundCategoryAlarms,
+	}
+}
+
+// ParseSoundCategory parses a sound category, returning an error if it's unknown.
+func ParseSoundCategory(value string) (SoundCategory, error) {
+	for _, category := range AllSoundCategories() {
+		if string(category) == value {
+			return category, nil
+		}
+	}
+	return "", fmt.Errorf("%w; %q is not one of %v", ErrSoundCategoryUnknown, value, AllSoundCategories())
+}
+
+// String implements fmt.Stringer.
+func (sc SoundCategory) String() string { return string(sc) }
+
+// MarshalText implements encoding.TextMarshaler.
+func (sc SoundCategory) MarshalText() ([]byte, error) { return []byte(sc), nil }
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (sc *SoundCategory) UnmarshalText(text []byte) (err error) {
+	*sc, err = ParseSoundCategory(string(text))
+	return
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (sc *SoundCategory) UnmarshalYAML(node *yaml.Node) error {
+	return unmarshalYAMLText(node, sc.UnmarshalText)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+//
+// Unlike text and yaml, unknown categories are accepted so that responses
+// from devices with newer firmware can be read; `Sound.Validate` checks input.
+func (sc *SoundCategory) UnmarshalJSON(contents []byte) error {
+	return json.Unmarshal(contents, (*string)(sc))
+}
+
+// AllSoundIDs returns the valid sound ids, notifications first and then alarms.
+func AllSoundIDs() []SoundID {
+	return append(SoundIDs(SoundCategoryNotifications), SoundIDs(SoundCategoryAlarms)...)
+}
+
+// SoundIDs returns the valid sound ids for a given category.
+func SoundIDs(category SoundCategory) []SoundID {
+	switch category {
+	case SoundCategoryNotifications:
+		return []SoundID{
+			SoundNotificationBicycle,
+			SoundNotificationCar,
+			SoundNotificationCash,
+			SoundNotificationCat,
+			SoundNotificationDog,
+			SoundNotificationDog2,
+			SoundNotificationEnergy,
+			SoundNotificationKnockKnock,
+			SoundNotificationLetterEmail,
+			SoundNotificationLose1,
+			SoundNotificationLose2,
+			SoundNotificationNegative1,
+			SoundNotificationNegative2,
+			SoundNotificationNegative3,
+			SoundNotificationNegative4,
+			SoundNotificationNegative5,
+			SoundNotification,
+			SoundNotification2,
+			SoundNotification3,
+			SoundNotification4,
+			SoundNotificationOpenDoor,
+			SoundNotificationPositive1,
+			SoundNotificationPositive2,
+			SoundNotificationPositive3,
+			SoundNotificationPositive4,
+			SoundNotificationPositive5,
+			SoundNotificationPositive6,
+			SoundNotificationStatistic,
+			SoundNotificationThunder,
+			SoundNotificationWater1,
+			SoundNotificationWater2,
+			SoundNotificationWin,
+			SoundNotificationWin2,
+			SoundNotificationWind,
+			SoundNotificationWindShort,
+		}
+	case SoundCategoryAlarms:
+		return []SoundID{
+			SoundAlarm1,
+			SoundAlarm2,
+			SoundAlarm3,
+			SoundAlarm4,
+			SoundAlarm5,
+			SoundAlarm6,
+			SoundAlarm7,
+			SoundAlarm8,
+			SoundAlarm9,
+			SoundAlarm10,
+			SoundAlarm11,
+			SoundAlarm12,
+			SoundAlarm13,
+		}
+	default:
+		return nil
+	}
+}
+
+// ParseSoundID parses a sound id, returning an error if it's unknown.
+func ParseSoundID(value string) (SoundID, error) {
+	for _, id := range AllSoundIDs() {
+		if string(id) == value {
+			return id, nil
+		}
+	}
+	return "", fmt.Errorf("%w; %q", ErrSoundIDUnknown, value)
+}
+
+// Category returns the category a sound id belongs to.
+func (sid SoundID) Category() SoundCategory {
+	for _, category := range AllSoundCategories() {
+		for _, id := range SoundIDs(category) {
+			if id == sid {
+				return category
+			}
+		}
+	}
+	return ""
+}
+
+// String implements fmt.Stringer.
+func (sid SoundID) String() string { return string(sid) }
+
+// MarshalText implements encoding.TextMarshaler.
+func (sid SoundID) MarshalText() ([]byte, error) { return []byte(sid), nil }
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (sid *SoundID) UnmarshalText(text []byte) (err error) {
+	*sid, err = ParseSoundID(string(text))
+	return
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (sid *SoundID) UnmarshalYAML(node *yaml.Node) error {
+	return unmarshalYAMLText(node, sid.UnmarshalText)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+//
+// Unlike text and yaml, unknown sound ids are accepted so that responses
+// from devices with newer firmware can be read; `Sound.Validate` checks input.
+func (sid *SoundID) UnmarshalJSON(contents []byte) error {
+	return json.Unmarshal(contents, (*string)(sid))
+}
+
+// Validate returns an error if the sound category or id is unknown.
+func (s Sound) Validate() error {
+	if s.Category != "" {
+		if _, err := ParseSoundCategory(string(s.Category)); err != nil {
+			return err
+		}
+	}
+	if s.ID != "" {
+		if _, err := ParseSoundID(string(s.ID)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AllIcons returns the named icon constants.
+func AllIcons() []Icon {
+	return []Icon{
+		IconAppleLogo,
+		IconAttention,
+		IconBeach,
+		IconCalendar,
+		IconClock,
+		IconDoge,
+		IconDollar,
+		IconFacebook,
+		IconFacebookAlt,
+		IconGmail,
+		IconHeart,
+		IconInstagram,
+		IconMario,
+		IconMatrix,
+		IconPoop,
+		IconRSS,
+		IconSmile,
+		IconTool,
+		IconTwitch,
+		IconTwitter,
+		IconUSA,
+		IconYoutube,
+	}
+}
+
+// ParseIcon parses an icon, returning an error if it's unknown.
+//
+// Valid icons are icon ids (e.g. `i555`), inline data uris, names
+// found in the default icon catalog, or templates (containing `{{`) that
+// render to one of those; names are left as is and are resolved to ids at send time.
+func ParseIcon(value string) (Icon, error) {
+	if value == "" || isIconID(value) || strings.HasPrefix(value, "data:image/") || strings.Contains(value, "{{") {
+		return Icon(value), nil
+	}
+	if _, ok := LookupIcon(value); ok {
+		return Icon(value), nil
+	}
+	return "", fmt.Errorf("%w; %q", ErrIconUnknown, value)
+}
+
+// String implements fmt.Stringer.
+func (i Icon) String() string { return string(i) }
+
+// MarshalText implements encoding.TextMarshaler.
+func (i Icon) MarshalText() ([]byte, error) { return []byte(i), nil }
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (i *Icon) UnmarshalText(text []byte) (err error) {
+	*i, err = ParseIcon(string(text))
+	return
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (i *Icon) UnmarshalYAML(node *yaml.Node) error {
+	return unmarshalYAMLText(node, i.UnmarshalText)
+}
+
+func unmarshalYAMLText(node *yaml.Node, unmarshal func([]byte) error) error {
+	var value string
+	if err := node.Decode(&value); err != nil {
+		return err
+	}
+	if err := unmarshal([]byte(value)); err != nil {
+		return fmt.Errorf("line %d: %w", node.Line, err)
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package lametric
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestParseText(t *testing.T) {
+	testCases := [...]struct {
+		Name   string
+		Parse  func(string) (string, error)
+		Value  string
+		Expect error
+	}{
+		{Name: "priority", Parse: parsePriority, Value: "critical"},
+		{Name: "priority unknown", Parse: parsePriority, Value: "urgent", Expect: ErrNotificationPriorityUnknown},
+		{Name: "priority case", Parse: parsePriority, Value: "Info", Expect: ErrNotificationPriorityUnknown},
+		{Name: "brightness mode", Parse: parseBrightnessMode, Value: "auto"},
+		{Name: "brightness mode unknown", Parse: parseBrightnessMode, Value: "dim", Expect: ErrBrightnessModeUnknown},
+		{Name: "icon type", Parse: parseIconType, Value: "alert"},
+		{Name: "icon type unknown", Parse: parseIconType, Value: "", Expect: ErrIconTypeUnknown},
+		{Name: "sound category", Parse: parseSoundCategory, Value: "alarms"},
+		{Name: "sound category unknown", Parse: parseSoundCategory, Value: "music", Expect: ErrSoundCategoryUnknown},
+		{Name: "sound id", Parse: parseSoundID, Value: "alarm10"},
+		{Name: "sound id notification", Parse: parseSoundID, Value: "knock-knock"},
+		{Name: "sound id unknown", Parse: parseSoundID, Value: "alarm99", Expect: ErrSoundIDUnknown},
+		{Name: "icon id", Parse: parseIcon, Value: "i555"},
+		{Name: "icon animated id", Parse: parseIcon, Value: "a256"},
+		{Name: "icon name", Parse: parseIcon, Value: "attention"},
+		{Name: "icon data uri", Parse: parseIcon, Value: "data:image/png;base64,iVBORw0KGgo="},
+		{Name: "icon template", Parse: parseIcon, Value: "{{ .Icon }}"},
+		{Name: "icon empty", Parse: parseIcon, Value: ""},
+		{Name: "icon unknown", Parse: parseIcon, Value: "not-an-icon", Expect: ErrIconUnknown},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			actual, err := tc.Parse(tc.Value)
+			if !errors.Is(err, tc.Expect) {
+				t.Fatalf("expected %v, actual %v", tc.Expect, err)
+			}
+			if tc.Expect == nil && actual != tc.Value {
+				t.Errorf("expected %q, actual %q", tc.Value, actual)
+			}
+		})
+	}
+}
+
+// the parse functions, returning strings so they can share a table.
+var (
+	parsePriority = func(value string) (string, error) {
+		parsed, err := ParseNotificationPriority(value)
+		return string(parsed), err
+	}
+	parseBrightnessMode = func(value string) (string, error) {
+		parsed, err := ParseBrightnessMode(value)
+		return string(parsed), err
+	}
+	parseIconType = func(value string) (string, error) {
+		parsed, err := ParseIconType(value)
+		return string(parsed), err
+	}
+	parseSoundCategory = func(value string) (string, error) {
+		parsed, err := ParseSoundCategory(value)
+		return string(parsed), err
+	}
+	parseSoundID = func(value string) (string, error) {
+		parsed, err := ParseSoundID(value)
+		return string(parsed), err
+	}
+	parseIcon = func(value string) (string, error) {
+		parsed, err := ParseIcon(value)
+		return string(parsed), err
+	}
+)
+
+func TestSoundIDCategory(t *testing.T) {
+	for _, category := range AllSoundCategories() {
+		for _, id := range SoundIDs(category) {
+			if actual := id.Category(); actual != category {
+				t.Errorf("%s: expected %v, actual %v", id, category, actual)
+			}
+		}
+	}
+	if actual := SoundID("alarm99").Category(); actual != "" {
+		t.Errorf("expected unknown sounds to have no category, actual %v", actual)
+	}
+}
+
+func testNotification() Notification {
+	return Notification{
+		Priority: NotificationPriorityWarning,
+		IconType: IconTypeInfo,
+		Model: NotificationModel{
+			Frames: []Frame{{Icon: IconAttention, Text: "ALERT"}, {Icon: "rss", Text: "news"}},
+			Sound:  &Sound{Category: SoundCategoryAlarms, ID: SoundAlarm3, Repeat: 2},
+		},
+	}
+}
+
+func TestNotificationJSON(t *testing.T) {
+	contents, err := json.Marshal(testNotification())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := `{"priority":"warning","iconType":"info","model":{"frames":[{"icon":"i555","text":"ALERT"},{"icon":"rss","text":"news"}],"sound":{"category":"alarms","id":"alarm3","repeat":2}}}`
+	if string(contents) != expect {
+		t.Errorf("expected %s, actual %s", expect, contents)
+	}
+	var actual Notification
+	if err := json.Unmarshal(contents, &actual); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(actual, testNotification()) {
+		t.Errorf("expected %+v, actual %+v", testNotification(), actual)
+	}
+
+	// sounds from newer firmware are read, and rejected as input.
+	var newer Notification
+	if err := json.Unmarshal([]byte(`{"model":{"sound":{"category":"music","id":"fanfare"}}}`), &newer); err != nil {
+		t.Fatal(err)
+	}
+	if newer.Model.Sound.Category != "music" || newer.Model.Sound.ID != "fanfare" {
+		t.Errorf("expected the unknown sound to be kept, actual %+v", newer.Model.Sound)
+	}
+	if err := newer.Model.Sound.Validate(); !errors.Is(err, ErrSoundCategoryUnknown) {
+		t.Errorf("expected %v, actual %v", ErrSoundCategoryUnknown, err)
+	}
+	if err := (Sound{ID: "fanfare"}).Validate(); !errors.Is(err, ErrSoundIDUnknown) {
+		t.Errorf("expected %v, actual %v", ErrSoundIDUnknown, err)
+	}
+	if err := testNotification().Model.Sound.Validate(); err != nil {
+		t.Errorf("expected a known sound to be valid, actual %v", err)
+	}
+
+	// other values are still checked.
+	if err := json.Unmarshal([]byte(`{"priority":"urgent"}`), &newer); !errors.Is(err, ErrNotificationPriorityUnknown) {
+		t.Errorf("expected %v, actual %v", ErrNotificationPriorityUnknown, err)
+	}
+}
+
+func TestNotificationYAML(t *testing.T) {
+	contents, err := yaml.Marshal(testNotification())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var actual Notification
+	if err := yaml.Unmarshal(contents, &actual); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(actual, testNotification()) {
+		t.Errorf("expected %+v, actual %+v", testNotification(), actual)
+	}
+
+	testCases := [...]struct {
+		Name   string
+		YAML   string
+		Expect error
+	}{
+		{Name: "sound id", YAML: "model:\n  sound:\n    id: fanfare\n", Expect: ErrSoundIDUnknown},
+		{Name: "sound category", YAML: "model:\n  sound:\n    category: music\n", Expect: ErrSoundCategoryUnknown},
+		{Name: "priority", YAML: "priority: urgent\n", Expect: ErrNotificationPriorityUnknown},
+		{Name: "icon type", YAML: "iconType: loud\n", Expect: ErrIconTypeUnknown},
+		{Name: "icon", YAML: "model:\n  frames:\n    - icon: not-an-icon\n", Expect: ErrIconUnknown},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			var actual Notification
+			err := yaml.Unmarshal([]byte(tc.YAML), &actual)
+			if !errors.Is(err, tc.Expect) {
+				t.Fatalf("expected %v, actual %v", tc.Expect, err)
+			}
+			if !strings.Contains(err.Error(), "line ") {
+				t.Errorf("expected the error to have a line number, actual %v", err)
+			}
+		})
+	}
+}
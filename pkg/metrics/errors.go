@@ -0,0 +1,12 @@
+package metrics
+
+// Error is an error constant.
+type Error string
+
+// Error implements error.
+func (e Error) Error() string { return string(e) }
+
+// Common Errors
+const (
+	ErrLabelCount Error = "label value count does not match the metric labels"
+)
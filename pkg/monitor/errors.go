@@ -0,0 +1,14 @@
+package monitor
+
+// Error is an error constant.
+type Error string
+
+// Error implements error.
+func (e Error) Error() string { return string(e) }
+
+// Common Errors
+const (
+	ErrStatusUnexpected Error = "unexpected status"
+	ErrBodyMismatch     Error = "body does not contain the expected text"
+	ErrLatencyExceeded  Error = "latency exceeded"
+)
@@ -0,0 +1,210 @@
+package monitor
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Defaults
+const (
+	DefaultInterval = time.Minute
+	DefaultTimeout  = 10 * time.Second
+)
+
+// Check is a target checked on an interval.
+type Check struct {
+	Name  string
+	Probe Probe
+	// Interval is how often the target is checked; defaults to a minute.
+	Interval time.Duration
+	// Timeout is the timeout of each check; defaults to ten seconds.
+	Timeout time.Duration
+	// FailureThreshold is the number of consecutive failed checks before
+	// the target is considered down; defaults to one.
+	FailureThreshold int
+	// CertExpiry is how long before its certificate expires an https target
+	// is warned about, if set.
+	CertExpiry time.Duration
+}
+
+// Status is the state of a check.
+type Status struct {
+	Name   string
+	Target string
+	// Up is if the last probe succeeded.
+	Up bool
+	// Down is if the target is considered down, i.e. it failed the threshold
+	// of consecutive checks and hasn't recovered.
+	Down        bool
+	CheckedAt   time.Time
+	Latency     time.Duration
+	CertExpires time.Time
+	// Failures is the number of consecutive failed checks.
+	Failures int
+	// FailingSince is when the first of the consecutive failed checks was.
+	FailingSince time.Time
+	Err          error
+}
+
+// EventKind is a kind of monitor event.
+type EventKind string
+
+// Event kinds.
+const (
+	EventDown         EventKind = "down"
+	EventUp           EventKind = "up"
+	EventCertExpiring EventKind = "cert expiring"
+)
+
+// Event is a change in the state of a check.
+type Event struct {
+	Kind   EventKind
+	Status Status
+	// Downtime is how long the target was failing, for up events.
+	Downtime time.Duration
+}
+
+// Monitor runs checks on their intervals and reports state changes.
+type Monitor struct {
+	Checks []Check
+	// OnStatus is called with the status of each check after it's run.
+	OnStatus func(Status)
+	// OnEvent is called when a target goes down or comes back up, or its
+	// certificate is about to expire, with the context the monitor runs with.
+	OnEvent func(context.Context, Event)
+
+	mu       sync.Mutex
+	statuses map[string]Status
+	// certWarned are the checks that were warned about their certificate,
+	// until it's renewed.
+	certWarned map[string]bool
+}
+
+// Run runs each check immediately, then on its interval, until the context is done.
+func (m *Monitor) Run(ctx context.Context) error {
+	wg := sync.WaitGroup{}
+	wg.Add(len(m.Checks))
+	for _, check := range m.Checks {
+		go func(check Check) {
+			defer wg.Done()
+			interval := check.Interval
+			if interval <= 0 {
+				interval = DefaultInterval
+			}
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				m.check(ctx, check)
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+			}
+		}(check)
+	}
+	wg.Wait()
+	return ctx.Err()
+}
+
+// Check runs each check once concurrently and returns their statuses.
+func (m *Monitor) Check(ctx context.Context) []Status {
+	statuses := make([]Status, len(m.Checks))
+	wg := sync.WaitGroup{}
+	wg.Add(len(m.Checks))
+	for x := 0; x < len(m.Checks); x++ {
+		go func(index int) {
+			defer wg.Done()
+			statuses[index] = m.check(ctx, m.Checks[index])
+		}(x)
+	}
+	wg.Wait()
+	return statuses
+}
+
+// Statuses returns the last status of each check, sorted by name.
+func (m *Monitor) Statuses() []Status {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	output := make([]Status, 0, len(m.statuses))
+	for _, status := range m.statuses {
+		output = append(output, status)
+	}
+	sort.Slice(output, func(i, j int) bool { return output[i].Name < output[j].Name })
+	return output
+}
+
+func (m *Monitor) check(ctx context.Context, check Check) Status {
+	timeout := check.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	probeCtx, cancel := context.WithTimeout(ctx, timeout)
+	result := check.Probe.Probe(probeCtx)
+	cancel()
+
+	m.mu.Lock()
+	if m.statuses == nil {
+		m.statuses = make(map[string]Status)
+		m.certWarned = make(map[string]bool)
+	}
+	previous := m.statuses[check.Name]
+	status := Status{
+		Name:        check.Name,
+		Target:      check.Probe.Target(),
+		Up:          result.Up,
+		Down:        previous.Down,
+		CheckedAt:   time.Now().UTC(),
+		Latency:     result.Latency,
+		CertExpires: result.CertExpires,
+		Err:         result.Err,
+	}
+	if !result.Up {
+		status.Failures = previous.Failures + 1
+		status.FailingSince = previous.FailingSince
+		if status.FailingSince.IsZero() {
+			status.FailingSince = status.CheckedAt
+		}
+	}
+	events := m.transitions(check, &status, previous)
+	m.statuses[check.Name] = status
+	m.mu.Unlock()
+
+	if m.OnStatus != nil {
+		m.OnStatus(status)
+	}
+	if m.OnEvent != nil {
+		for _, event := range events {
+			m.OnEvent(ctx, event)
+		}
+	}
+	return status
+}
+
+// transitions updates a status from the previous one and returns the events
+// for the changes; it must be called with the lock held.
+func (m *Monitor) transitions(check Check, status *Status, previous Status) (events []Event) {
+	threshold := check.FailureThreshold
+	if threshold <= 0 {
+		threshold = 1
+	}
+	switch {
+	case !status.Up && !status.Down && status.Failures >= threshold:
+		status.Down = true
+		events = append(events, Event{Kind: EventDown, Status: *status})
+	case status.Up && status.Down:
+		status.Down = false
+		events = append(events, Event{Kind: EventUp, Status: *status, Downtime: status.CheckedAt.Sub(previous.FailingSince)})
+	}
+	if check.CertExpiry > 0 && !status.CertExpires.IsZero() {
+		expiring := status.CertExpires.Sub(status.CheckedAt) <= check.CertExpiry
+		if expiring && !m.certWarned[check.Name] {
+			events = append(events, Event{Kind: EventCertExpiring, Status: *status})
+		}
+		// the warning is re-armed once the certificate is renewed.
+		m.certWarned[check.Name] = expiring
+	}
+	return
+}
@@ -0,0 +1,132 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// scriptedProbe returns the results it's given in order.
+type scriptedProbe struct {
+	results []Result
+}
+
+func (sp *scriptedProbe) Target() string { return "scripted" }
+
+func (sp *scriptedProbe) Probe(context.Context) Result {
+	result := sp.results[0]
+	sp.results = sp.results[1:]
+	return result
+}
+
+var (
+	up   = Result{Up: true, Latency: time.Millisecond}
+	down = Result{Err: errors.New("connection refused")}
+)
+
+func TestMonitorTransitions(t *testing.T) {
+	testCases := [...]struct {
+		Name      string
+		Threshold int
+		Results   []Result
+		// Expect are the events after each check, comma separated.
+		Expect []string
+	}{
+		{
+			Name:    "fires once and recovers",
+			Results: []Result{up, down, down, down, up, up},
+			Expect:  []string{"", "down", "", "", "up", ""},
+		},
+		{
+			Name:      "threshold",
+			Threshold: 3,
+			Results:   []Result{down, down, up, down, down, down, down, up},
+			Expect:    []string{"", "", "", "", "", "down", "", "up"},
+		},
+		{
+			Name:      "flapping under the threshold",
+			Threshold: 2,
+			Results:   []Result{down, up, down, up, down, up},
+			Expect:    []string{"", "", "", "", "", ""},
+		},
+		{
+			Name:    "down from the start",
+			Results: []Result{down, down, up, down},
+			Expect:  []string{"down", "", "up", "down"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			var events []Event
+			m := &Monitor{
+				Checks:  []Check{{Name: "api", Probe: &scriptedProbe{results: tc.Results}, FailureThreshold: tc.Threshold}},
+				OnEvent: func(_ context.Context, event Event) { events = append(events, event) },
+			}
+			for index, expect := range tc.Expect {
+				events = nil
+				status := m.check(context.Background(), m.Checks[0])
+				var kinds []string
+				for _, event := range events {
+					kinds = append(kinds, string(event.Kind))
+					if event.Kind == EventUp && event.Downtime <= 0 {
+						t.Errorf("check %d: expected the up event to have the downtime", index)
+					}
+				}
+				if actual := strings.Join(kinds, ","); actual != expect {
+					t.Errorf("check %d: expected %q, actual %q", index, expect, actual)
+				}
+				if status.Down != (m.Statuses()[0].Down) {
+					t.Errorf("check %d: expected the returned status to be the stored one", index)
+				}
+			}
+		})
+	}
+}
+
+func TestMonitorStatus(t *testing.T) {
+	m := &Monitor{Checks: []Check{{Name: "api", Probe: &scriptedProbe{results: []Result{down, down, up}}, FailureThreshold: 2}}}
+
+	first := m.Check(context.Background())[0]
+	if first.Up || first.Down || first.Failures != 1 || first.FailingSince.IsZero() || first.Err == nil {
+		t.Errorf("expected a failing status under the threshold, actual %+v", first)
+	}
+	second := m.Check(context.Background())[0]
+	if !second.Down || second.Failures != 2 || !second.FailingSince.Equal(first.FailingSince) {
+		t.Errorf("expected a down status failing since the first check, actual %+v", second)
+	}
+	third := m.Check(context.Background())[0]
+	if !third.Up || third.Down || third.Failures != 0 || !third.FailingSince.IsZero() || third.Target != "scripted" {
+		t.Errorf("expected an up status, actual %+v", third)
+	}
+}
+
+func TestMonitorCertExpiry(t *testing.T) {
+	expiring := func(in time.Duration) Result {
+		return Result{Up: true, CertExpires: time.Now().Add(in)}
+	}
+	probe := &scriptedProbe{results: []Result{
+		expiring(30 * 24 * time.Hour),
+		expiring(6 * 24 * time.Hour),
+		expiring(5 * 24 * time.Hour),
+		expiring(90 * 24 * time.Hour),
+		expiring(time.Hour),
+	}}
+	var events []string
+	m := &Monitor{
+		Checks: []Check{{Name: "api", Probe: probe, CertExpiry: 7 * 24 * time.Hour}},
+		OnEvent: func(_ context.Context, event Event) {
+			events = append(events, string(event.Kind))
+		},
+	}
+	for index := 0; index < 5; index++ {
+		m.check(context.Background(), m.Checks[0])
+		events = append(events, "|")
+	}
+	// warned once while expiring, and again after a renewed certificate expires.
+	if actual, expect := strings.Join(events, ""), fmt.Sprintf("|%s|||%s|", EventCertExpiring, EventCertExpiring); actual != expect {
+		t.Errorf("expected %q, actual %q", expect, actual)
+	}
+}
@@ -0,0 +1,130 @@
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	"github.com/wcharczuk/lametric/pkg/apiutil"
+)
+
+// maxBody is the most of a response body searched for the expected text.
+const maxBody = 1 << 20
+
+// Probe checks a target once.
+type Probe interface {
+	// Target returns what's checked, e.g. a url or an address.
+	Target() string
+	Probe(ctx context.Context) Result
+}
+
+// Result is the result of a probe.
+type Result struct {
+	Up      bool
+	Latency time.Duration
+	// CertExpires is when the leaf certificate expires, for https checks.
+	CertExpires time.Time
+	Err         error
+}
+
+// HTTPProbe checks an http(s) endpoint.
+type HTTPProbe struct {
+	// Client is the client requests are sent with; its response filter should
+	// be unset so that the probe can check the status itself.
+	Client apiutil.Client
+	// Method defaults to `GET`.
+	Method string
+	// Statuses are the expected status codes; defaults to any 2xx.
+	Statuses []int
+	// Body is text the response body must contain, if set.
+	Body string
+	// MaxLatency is the longest the response may take, if set.
+	MaxLatency time.Duration
+}
+
+// Target implements Probe.
+func (hp HTTPProbe) Target() string {
+	return hp.Client.URL.Redacted()
+}
+
+// Probe implements Probe.
+func (hp HTTPProbe) Probe(ctx context.Context) (result Result) {
+	opts := []apiutil.RequestOption{}
+	if hp.Method != "" {
+		opts = append(opts, apiutil.OptMethod(hp.Method))
+	}
+	started := time.Now()
+	res, err := hp.Client.Do(ctx, opts...)
+	if err != nil {
+		result.Latency, result.Err = time.Since(started), err
+		return
+	}
+	defer res.Body.Close()
+	// the latency is to the response headers, as the body may be large.
+	result.Latency = time.Since(started)
+	if res.TLS != nil && len(res.TLS.PeerCertificates) > 0 {
+		result.CertExpires = res.TLS.PeerCertificates[0].NotAfter
+	}
+	if !hp.statusExpected(res.StatusCode) {
+		result.Err = fmt.Errorf("%w; %s", ErrStatusUnexpected, res.Status)
+		return
+	}
+	if hp.Body != "" {
+		contents, err := io.ReadAll(io.LimitReader(res.Body, maxBody))
+		if err != nil {
+			result.Err = err
+			return
+		}
+		if !bytes.Contains(contents, []byte(hp.Body)) {
+			result.Err = fmt.Errorf("%w; %q", ErrBodyMismatch, hp.Body)
+			return
+		}
+	}
+	if hp.MaxLatency > 0 && result.Latency > hp.MaxLatency {
+		result.Err = fmt.Errorf("%w; %v > %v", ErrLatencyExceeded, result.Latency.Round(time.Millisecond), hp.MaxLatency)
+		return
+	}
+	result.Up = true
+	return
+}
+
+func (hp HTTPProbe) statusExpected(statusCode int) bool {
+	if len(hp.Statuses) == 0 {
+		return statusCode >= 200 && statusCode <= 299
+	}
+	for _, expected := range hp.Statuses {
+		if statusCode == expected {
+			return true
+		}
+	}
+	return false
+}
+
+// TCPProbe checks that a tcp port accepts connections.
+type TCPProbe struct {
+	// Addr is the `host:port` to connect to.
+	Addr string
+}
+
+// Target implements Probe.
+func (tp TCPProbe) Target() string {
+	return tp.Addr
+}
+
+// Probe implements Probe.
+func (tp TCPProbe) Probe(ctx context.Context) (result Result) {
+	dialer := net.Dialer{}
+	started := time.Now()
+	conn, err := dialer.DialContext(ctx, "tcp", tp.Addr)
+	result.Latency = time.Since(started)
+	if err != nil {
+		result.Err = err
+		return
+	}
+	_ = conn.Close()
+	result.Up = true
+	return
+}
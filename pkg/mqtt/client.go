@@ -0,0 +1,565 @@
+package mqtt
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Defaults
+const (
+	DefaultKeepAlive      = 30 * time.Second
+	DefaultConnectTimeout = 10 * time.Second
+	DefaultReconnectMin   = time.Second
+	DefaultReconnectMax   = time.Minute
+	DefaultMaxHandlers    = 8
+	DefaultQueueSize      = 256
+)
+
+// Options are the client connection options.
+type Options struct {
+	// Broker is the broker url, e.g. `tcp://localhost:1883`; the `ssl`, `tls`
+	// and `mqtts` schemes connect with tls (on port 8883 by default).
+	Broker       string
+	ClientID     string
+	Username     string
+	Password     string
+	CleanSession bool
+	// Will is an optional message the broker publishes if the client disconnects uncleanly.
+	Will *Message
+	// TLSConfig is the tls config for tls brokers.
+	TLSConfig *tls.Config
+
+	KeepAlive      time.Duration
+	ConnectTimeout time.Duration
+	ReconnectMin   time.Duration
+	ReconnectMax   time.Duration
+
+	// OnConnect is called each time the client connects (or reconnects).
+	OnConnect func(*Client)
+	// OnConnectionLost is called with the error each time the connection is lost.
+	OnConnectionLost func(error)
+
+	// MaxHandlers is the most received messages that are handled at once.
+	MaxHandlers int
+	// QueueSize is the most received messages that wait for a handler; messages
+	// received when the queue is full are dropped.
+	QueueSize int
+	// OnDropped, if set, is called with each message dropped because the queue is full.
+	OnDropped func(Message)
+}
+
+// Message is a published message.
+type Message struct {
+	Topic     string
+	Payload   []byte
+	QoS       byte
+	Retained  bool
+	Duplicate bool
+}
+
+// Handler handles a received message.
+type Handler func(Message)
+
+// dispatch is a received message queued for a handler.
+type dispatch struct {
+	handler Handler
+	message Message
+}
+
+type subscription struct {
+	filter  string
+	qos     byte
+	handler Handler
+}
+
+// New returns a new client; call `Run` to connect.
+func New(opts Options) *Client {
+	if opts.KeepAlive == 0 {
+		opts.KeepAlive = DefaultKeepAlive
+	}
+	if opts.ConnectTimeout == 0 {
+		opts.ConnectTimeout = DefaultConnectTimeout
+	}
+	if opts.ReconnectMin == 0 {
+		opts.ReconnectMin = DefaultReconnectMin
+	}
+	if opts.ReconnectMax == 0 {
+		opts.ReconnectMax = DefaultReconnectMax
+	}
+	if opts.MaxHandlers <= 0 {
+		opts.MaxHandlers = DefaultMaxHandlers
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = DefaultQueueSize
+	}
+	return &Client{
+		opts:      opts,
+		queue:     make(chan dispatch, opts.QueueSize),
+		inflight:  make(map[uint16]chan *packet),
+		received:  make(map[uint16]bool),
+		connected: make(chan struct{}),
+	}
+}
+
+// Client is an mqtt 3.1.1 client that reconnects and resubscribes when its
+// connection is lost.
+//
+// Received messages are queued and handled by `Options.MaxHandlers` goroutines
+// while the client runs, so handlers may publish without blocking the connection.
+type Client struct {
+	opts  Options
+	queue chan dispatch
+
+	mu            sync.Mutex
+	conn          net.Conn
+	subscriptions []subscription
+	nextID        uint16
+	inflight      map[uint16]chan *packet
+	received      map[uint16]bool
+	connected     chan struct{}
+
+	writeMu sync.Mutex
+}
+
+// Subscribe registers a handler for a topic filter at a given maximum qos.
+//
+// Subscriptions are (re)sent each time the client connects; if the client is
+// already connected the subscription is sent immediately.
+func (c *Client) Subscribe(ctx context.Context, filter string, qos byte, handler Handler) error {
+	if err := ValidateFilter(filter); err != nil {
+		return err
+	}
+	if qos > 2 {
+		return fmt.Errorf("%w; %d", ErrInvalidQoS, qos)
+	}
+	c.mu.Lock()
+	c.subscriptions = append(c.subscriptions, subscription{filter: filter, qos: qos, handler: handler})
+	isConnected := c.conn != nil
+	c.mu.Unlock()
+	if !isConnected {
+		return nil
+	}
+	return c.subscribe(ctx, []subscription{{filter: filter, qos: qos}})
+}
+
+// Publish publishes a message, waiting for the acknowledgements for its qos.
+//
+// If the client isn't connected it waits until it is, or the context is done.
+func (c *Client) Publish(ctx context.Context, topic string, qos byte, retain bool, payload []byte) error {
+	if err := ValidateTopic(topic); err != nil {
+		return err
+	}
+	if qos > 2 {
+		return fmt.Errorf("%w; %d", ErrInvalidQoS, qos)
+	}
+	if err := c.waitConnected(ctx); err != nil {
+		return err
+	}
+	m := Message{Topic: topic, Payload: payload, QoS: qos, Retained: retain}
+	if qos == 0 {
+		return c.write(publishPacket(0, m, false))
+	}
+
+	id, acks := c.track()
+	defer c.untrack(id)
+	if err := c.write(publishPacket(id, m, false)); err != nil {
+		return err
+	}
+	ack, err := c.await(ctx, acks)
+	if err != nil {
+		return err
+	}
+	if qos == 1 {
+		if ack.Type != packetPuback {
+			return fmt.Errorf("%w; expected puback, got %d", ErrUnexpectedPacket, ack.Type)
+		}
+		return nil
+	}
+	if ack.Type != packetPubrec {
+		return fmt.Errorf("%w; expected pubrec, got %d", ErrUnexpectedPacket, ack.Type)
+	}
+	if err := c.write(ackPacket(packetPubrel, id)); err != nil {
+		return err
+	}
+	if ack, err = c.await(ctx, acks); err != nil {
+		return err
+	}
+	if ack.Type != packetPubcomp {
+		return fmt.Errorf("%w; expected pubcomp, got %d", ErrUnexpectedPacket, ack.Type)
+	}
+	return nil
+}
+
+// Connected returns if the client is currently connected.
+func (c *Client) Connected() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.conn != nil
+}
+
+// Run connects to the broker and keeps the connection open, reconnecting
+// with exponential backoff when it's lost, until the context is done.
+func (c *Client) Run(ctx context.Context) error {
+	var handlers sync.WaitGroup
+	defer handlers.Wait()
+	for index := 0; index < c.opts.MaxHandlers; index++ {
+		handlers.Add(1)
+		go func() {
+			defer handlers.Done()
+			c.handle(ctx)
+		}()
+	}
+
+	backoff := c.opts.ReconnectMin
+	for {
+		started := time.Now()
+		err := c.session(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if c.opts.OnConnectionLost != nil {
+			c.opts.OnConnectionLost(err)
+		}
+		// reset the backoff if the session was up for a while
+		if time.Since(started) > c.opts.ReconnectMax {
+			backoff = c.opts.ReconnectMin
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > c.opts.ReconnectMax {
+			backoff = c.opts.ReconnectMax
+		}
+	}
+}
+
+// session runs a single connection until it fails or the context is done.
+func (c *Client) session(ctx context.Context) error {
+	conn, reader, err := c.connect(ctx)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.conn = conn
+	close(c.connected)
+	subscriptions := make([]subscription, len(c.subscriptions))
+	copy(subscriptions, c.subscriptions)
+	c.mu.Unlock()
+
+	errs := make(chan error, 2)
+	readDone := make(chan struct{})
+	go func() {
+		defer close(readDone)
+		errs <- c.readLoop(conn, reader)
+	}()
+	defer func() {
+		// the read loop must stop before pending acknowledgements are failed
+		conn.Close()
+		<-readDone
+		c.disconnected()
+	}()
+	go func() {
+		if len(subscriptions) > 0 {
+			if err := c.subscribe(ctx, subscriptions); err != nil {
+				errs <- err
+				return
+			}
+		}
+		if c.opts.OnConnect != nil {
+			c.opts.OnConnect(c)
+		}
+	}()
+
+	pings := time.NewTicker(c.opts.KeepAlive / 2)
+	defer pings.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			_ = c.write(packet{Type: packetDisconnect})
+			return ctx.Err()
+		case err := <-errs:
+			return err
+		case <-pings.C:
+			if err := c.write(packet{Type: packetPingreq}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// connect dials the broker and performs the connect handshake.
+func (c *Client) connect(ctx context.Context) (net.Conn, *bufio.Reader, error) {
+	broker, err := url.Parse(c.opts.Broker)
+	if err != nil {
+		return nil, nil, err
+	}
+	useTLS := false
+	defaultPort := "1883"
+	switch broker.Scheme {
+	case "tcp", "mqtt":
+	case "ssl", "tls", "mqtts":
+		useTLS, defaultPort = true, "8883"
+	default:
+		return nil, nil, fmt.Errorf("%w; %q", ErrInvalidBroker, c.opts.Broker)
+	}
+	addr := broker.Host
+	if broker.Port() == "" {
+		addr = net.JoinHostPort(broker.Hostname(), defaultPort)
+	}
+
+	dialCtx, cancel := context.WithTimeout(ctx, c.opts.ConnectTimeout)
+	defer cancel()
+	var conn net.Conn
+	if useTLS {
+		tlsConfig := c.opts.TLSConfig
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{ServerName: broker.Hostname()}
+		}
+		conn, err = (&tls.Dialer{Config: tlsConfig}).DialContext(dialCtx, "tcp", addr)
+	} else {
+		conn, err = (&net.Dialer{}).DialContext(dialCtx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	deadline, _ := dialCtx.Deadline()
+	_ = conn.SetDeadline(deadline)
+	if err = writePacket(conn, connectPacket(c.opts)); err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	reader := bufio.NewReader(conn)
+	connack, err := readPacket(reader)
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	if connack.Type != packetConnack || len(connack.Body) != 2 {
+		conn.Close()
+		return nil, nil, fmt.Errorf("%w; expected connack, got %d", ErrUnexpectedPacket, connack.Type)
+	}
+	if code := connack.Body[1]; code != 0 {
+		conn.Close()
+		return nil, nil, ConnectError(code)
+	}
+	_ = conn.SetDeadline(time.Time{})
+	return conn, reader, nil
+}
+
+// readLoop reads packets until the connection fails.
+func (c *Client) readLoop(conn net.Conn, reader *bufio.Reader) error {
+	for {
+		// the broker must answer pings within the keep alive
+		_ = conn.SetReadDeadline(time.Now().Add(c.opts.KeepAlive + c.opts.KeepAlive/2))
+		p, err := readPacket(reader)
+		if err != nil {
+			return err
+		}
+		switch p.Type {
+		case packetPublish:
+			if err := c.handlePublish(p); err != nil {
+				return err
+			}
+		case packetPubrel:
+			id, err := packetID(p)
+			if err != nil {
+				return err
+			}
+			c.mu.Lock()
+			delete(c.received, id)
+			c.mu.Unlock()
+			if err := c.write(ackPacket(packetPubcomp, id)); err != nil {
+				return err
+			}
+		case packetPuback, packetPubrec, packetPubcomp, packetSuback, packetUnsuback:
+			id, err := packetID(p)
+			if err != nil {
+				return err
+			}
+			c.mu.Lock()
+			acks, ok := c.inflight[id]
+			c.mu.Unlock()
+			if ok {
+				select {
+				case acks <- p:
+				default:
+				}
+			}
+		case packetPingresp:
+		default:
+			return fmt.Errorf("%w; %d", ErrUnexpectedPacket, p.Type)
+		}
+	}
+}
+
+// handlePublish acknowledges a received message and dispatches it to the
+// matching subscriptions.
+//
+// Qos 2 messages are dispatched once; redeliveries of a packet id that
+// hasn't been released are acknowledged but not dispatched again.
+func (c *Client) handlePublish(p *packet) error {
+	id, m, err := parsePublish(p)
+	if err != nil {
+		return err
+	}
+	switch m.QoS {
+	case 1:
+		if err := c.write(ackPacket(packetPuback, id)); err != nil {
+			return err
+		}
+	case 2:
+		c.mu.Lock()
+		seen := c.received[id]
+		c.received[id] = true
+		c.mu.Unlock()
+		if err := c.write(ackPacket(packetPubrec, id)); err != nil {
+			return err
+		}
+		if seen {
+			return nil
+		}
+	}
+
+	c.mu.Lock()
+	var handlers []Handler
+	for _, sub := range c.subscriptions {
+		if Match(sub.filter, m.Topic) {
+			handlers = append(handlers, sub.handler)
+		}
+	}
+	c.mu.Unlock()
+	for _, handler := range handlers {
+		select {
+		case c.queue <- dispatch{handler: handler, message: m}:
+		default:
+			if c.opts.OnDropped != nil {
+				c.opts.OnDropped(m)
+			}
+		}
+	}
+	return nil
+}
+
+// handle calls the handlers of queued messages until the context is done.
+func (c *Client) handle(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case d := <-c.queue:
+			d.handler(d.message)
+		}
+	}
+}
+
+// subscribe sends a subscribe packet and waits for the suback.
+func (c *Client) subscribe(ctx context.Context, subscriptions []subscription) error {
+	id, acks := c.track()
+	defer c.untrack(id)
+	e := new(encoder).uint16(id)
+	for _, sub := range subscriptions {
+		e.string(sub.filter).byte(sub.qos)
+	}
+	if err := c.write(packet{Type: packetSubscribe, Flags: 0x02, Body: e.buf}); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.opts.ConnectTimeout)
+	defer cancel()
+	ack, err := c.await(ctx, acks)
+	if err != nil {
+		return err
+	}
+	if ack.Type != packetSuback {
+		return fmt.Errorf("%w; expected suback, got %d", ErrUnexpectedPacket, ack.Type)
+	}
+	for index, code := range ack.Body[2:] {
+		if code == 0x80 && index < len(subscriptions) {
+			return fmt.Errorf("%w; %s", ErrSubscriptionRefused, subscriptions[index].filter)
+		}
+	}
+	return nil
+}
+
+func (c *Client) write(p packet) error {
+	c.mu.Lock()
+	conn := c.conn
+	c.mu.Unlock()
+	if conn == nil {
+		return ErrNotConnected
+	}
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	_ = conn.SetWriteDeadline(time.Now().Add(c.opts.ConnectTimeout))
+	return writePacket(conn, p)
+}
+
+// track allocates a packet id and a channel its acknowledgements are sent to.
+func (c *Client) track() (uint16, chan *packet) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for {
+		c.nextID++
+		if c.nextID == 0 {
+			continue
+		}
+		if _, ok := c.inflight[c.nextID]; !ok {
+			break
+		}
+	}
+	acks := make(chan *packet, 2)
+	c.inflight[c.nextID] = acks
+	return c.nextID, acks
+}
+
+func (c *Client) untrack(id uint16) {
+	c.mu.Lock()
+	delete(c.inflight, id)
+	c.mu.Unlock()
+}
+
+// await waits for an acknowledgement, the connection to be lost, or the context to be done.
+func (c *Client) await(ctx context.Context, acks chan *packet) (*packet, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case ack, ok := <-acks:
+		if !ok {
+			return nil, ErrConnectionLost
+		}
+		return ack, nil
+	}
+}
+
+func (c *Client) waitConnected(ctx context.Context) error {
+	c.mu.Lock()
+	connected := c.connected
+	c.mu.Unlock()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-connected:
+		return nil
+	}
+}
+
+// disconnected resets the connection state and fails anything waiting on acknowledgements.
+func (c *Client) disconnected() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.conn = nil
+	c.connected = make(chan struct{})
+	for id, acks := range c.inflight {
+		close(acks)
+		delete(c.inflight, id)
+	}
+}
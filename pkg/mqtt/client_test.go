@@ -0,0 +1,320 @@
+package mqtt
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// broker is a fake broker the test drives packet by packet.
+type broker struct {
+	t        *testing.T
+	listener net.Listener
+}
+
+func newBroker(t *testing.T) *broker {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	return &broker{t: t, listener: listener}
+}
+
+func (b *broker) url() string { return "tcp://" + b.listener.Addr().String() }
+
+// accept accepts a connection and answers its connect packet.
+func (b *broker) accept() (*brokerConn, *packet) {
+	b.t.Helper()
+	_ = b.listener.(*net.TCPListener).SetDeadline(time.Now().Add(5 * time.Second))
+	conn, err := b.listener.Accept()
+	if err != nil {
+		b.t.Fatal(err)
+	}
+	b.t.Cleanup(func() { conn.Close() })
+	bc := &brokerConn{t: b.t, conn: conn, reader: bufio.NewReader(conn)}
+	connect := bc.expect(packetConnect)
+	bc.write(packet{Type: packetConnack, Body: []byte{0, 0}})
+	return bc, connect
+}
+
+type brokerConn struct {
+	t      *testing.T
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+func (bc *brokerConn) read() *packet {
+	bc.t.Helper()
+	_ = bc.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	p, err := readPacket(bc.reader)
+	if err != nil {
+		bc.t.Fatal(err)
+	}
+	return p
+}
+
+// expect reads packets, answering pings, until one of a given type.
+func (bc *brokerConn) expect(packetType byte) *packet {
+	bc.t.Helper()
+	for {
+		p := bc.read()
+		if p.Type == packetPingreq {
+			bc.write(packet{Type: packetPingresp})
+			continue
+		}
+		if p.Type != packetType {
+			bc.t.Fatalf("expected packet type %d, got %d", packetType, p.Type)
+		}
+		return p
+	}
+}
+
+func (bc *brokerConn) write(p packet) {
+	bc.t.Helper()
+	if err := writePacket(bc.conn, p); err != nil {
+		bc.t.Fatal(err)
+	}
+}
+
+// suback answers a subscribe packet, granting the requested qos.
+func (bc *brokerConn) suback(subscribe *packet) []string {
+	bc.t.Helper()
+	d := &decoder{buf: subscribe.Body}
+	id := d.uint16()
+	var filters []string
+	e := new(encoder).uint16(id)
+	for len(d.buf) > 0 && d.err == nil {
+		filters = append(filters, d.string())
+		qos := d.buf[0]
+		d.buf = d.buf[1:]
+		e.byte(qos)
+	}
+	bc.write(packet{Type: packetSuback, Body: e.buf})
+	return filters
+}
+
+func run(t *testing.T, client *Client) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = client.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func TestClient(t *testing.T) {
+	b := newBroker(t)
+	received := make(chan Message, 1)
+	client := New(Options{Broker: b.url(), ClientID: "notifier-test", Username: "user", Password: "secret", CleanSession: true})
+	if err := client.Subscribe(context.Background(), "alerts/+", 1, func(m Message) { received <- m }); err != nil {
+		t.Fatal(err)
+	}
+	run(t, client)
+
+	conn, connect := b.accept()
+	d := &decoder{buf: connect.Body}
+	if protocol := d.string(); protocol != "MQTT" {
+		t.Errorf("expected protocol MQTT, got %q", protocol)
+	}
+	d.buf = d.buf[4:] // level, flags and keep alive
+	if clientID, username, password := d.string(), d.string(), d.string(); clientID != "notifier-test" || username != "user" || password != "secret" {
+		t.Errorf("unexpected credentials %q %q %q", clientID, username, password)
+	}
+	if filters := conn.suback(conn.expect(packetSubscribe)); len(filters) != 1 || filters[0] != "alerts/+" {
+		t.Errorf("unexpected subscription %v", filters)
+	}
+
+	// publish at qos 1 waits for the puback.
+	published := make(chan error, 1)
+	go func() {
+		published <- client.Publish(context.Background(), "notifier/status", 1, true, []byte("online"))
+	}()
+	publish := conn.expect(packetPublish)
+	id, m, err := parsePublish(publish)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Topic != "notifier/status" || string(m.Payload) != "online" || !m.Retained || m.QoS != 1 {
+		t.Errorf("unexpected message %+v", m)
+	}
+	conn.write(ackPacket(packetPuback, id))
+	if err := <-published; err != nil {
+		t.Fatal(err)
+	}
+
+	// received messages are acknowledged and handled.
+	conn.write(publishPacket(7, Message{Topic: "alerts/api", Payload: []byte("down"), QoS: 1}, false))
+	if ack := conn.expect(packetPuback); ack.Body[1] != 7 {
+		t.Errorf("expected puback 7, got %v", ack.Body)
+	}
+	select {
+	case m := <-received:
+		if m.Topic != "alerts/api" || string(m.Payload) != "down" {
+			t.Errorf("unexpected message %+v", m)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("message not handled")
+	}
+}
+
+func TestClientQoS2Once(t *testing.T) {
+	b := newBroker(t)
+	var mu sync.Mutex
+	var handled int
+	client := New(Options{Broker: b.url()})
+	_ = client.Subscribe(context.Background(), "#", 2, func(Message) {
+		mu.Lock()
+		handled++
+		mu.Unlock()
+	})
+	run(t, client)
+	conn, _ := b.accept()
+	conn.suback(conn.expect(packetSubscribe))
+
+	m := Message{Topic: "alerts/api", Payload: []byte("down"), QoS: 2}
+	conn.write(publishPacket(3, m, false))
+	conn.expect(packetPubrec)
+	// a redelivery before the release is acknowledged but not handled again.
+	conn.write(publishPacket(3, m, true))
+	conn.expect(packetPubrec)
+	conn.write(ackPacket(packetPubrel, 3))
+	conn.expect(packetPubcomp)
+
+	time.Sleep(50 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	if handled != 1 {
+		t.Errorf("expected the message to be handled once, got %d", handled)
+	}
+}
+
+func TestClientKeepAlive(t *testing.T) {
+	b := newBroker(t)
+	lost := make(chan error, 1)
+	client := New(Options{
+		Broker:           b.url(),
+		KeepAlive:        200 * time.Millisecond,
+		ReconnectMin:     time.Hour,
+		OnConnectionLost: func(err error) { lost <- err },
+	})
+	run(t, client)
+	conn, _ := b.accept()
+
+	// pings are sent at half the keep alive.
+	started := time.Now()
+	if p := conn.read(); p.Type != packetPingreq {
+		t.Fatalf("expected a ping, got %d", p.Type)
+	}
+	if elapsed := time.Since(started); elapsed > 200*time.Millisecond {
+		t.Errorf("expected a ping within the keep alive, took %v", elapsed)
+	}
+	conn.write(packet{Type: packetPingresp})
+
+	// a broker that stops answering is dropped.
+	select {
+	case err := <-lost:
+		if err == nil {
+			t.Error("expected an error")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the connection to be lost")
+	}
+	if client.Connected() {
+		t.Error("expected the client to be disconnected")
+	}
+}
+
+func TestClientReconnect(t *testing.T) {
+	b := newBroker(t)
+	connects := make(chan struct{}, 2)
+	client := New(Options{
+		Broker:       b.url(),
+		ReconnectMin: 10 * time.Millisecond,
+		OnConnect:    func(*Client) { connects <- struct{}{} },
+	})
+	_ = client.Subscribe(context.Background(), "alerts/#", 0, func(Message) {})
+	run(t, client)
+
+	first, _ := b.accept()
+	first.suback(first.expect(packetSubscribe))
+	<-connects
+	first.conn.Close()
+
+	// subscriptions are sent again on the new connection.
+	second, _ := b.accept()
+	if filters := second.suback(second.expect(packetSubscribe)); len(filters) != 1 || filters[0] != "alerts/#" {
+		t.Errorf("unexpected subscription %v", filters)
+	}
+	<-connects
+
+	published := make(chan error, 1)
+	go func() {
+		published <- client.Publish(context.Background(), "notifier/status", 0, false, []byte("online"))
+	}()
+	second.expect(packetPublish)
+	if err := <-published; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClientHandlersBounded(t *testing.T) {
+	b := newBroker(t)
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var running, maxRunning int
+	dropped := make(chan Message, 8)
+	client := New(Options{
+		Broker:      b.url(),
+		MaxHandlers: 2,
+		QueueSize:   2,
+		OnDropped:   func(m Message) { dropped <- m },
+	})
+	_ = client.Subscribe(context.Background(), "alerts/#", 0, func(Message) {
+		mu.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		mu.Unlock()
+		<-release
+		mu.Lock()
+		running--
+		mu.Unlock()
+	})
+	run(t, client)
+	conn, _ := b.accept()
+	conn.suback(conn.expect(packetSubscribe))
+
+	// two are handled, two are queued and the rest are dropped.
+	for index := 0; index < 6; index++ {
+		conn.write(publishPacket(0, Message{Topic: "alerts/api", Payload: []byte{byte(index)}}, false))
+		time.Sleep(10 * time.Millisecond)
+	}
+	for index := 0; index < 2; index++ {
+		select {
+		case <-dropped:
+		case <-time.After(5 * time.Second):
+			t.Fatal("expected messages to be dropped")
+		}
+	}
+	close(release)
+	time.Sleep(50 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	if maxRunning != 2 {
+		t.Errorf("expected at most 2 handlers at once, got %d", maxRunning)
+	}
+	if len(dropped) != 0 {
+		t.Errorf("expected 2 messages to be dropped, got %d more", len(dropped))
+	}
+}
@@ -0,0 +1,43 @@
+package mqtt
+
+import "fmt"
+
+// Error is an error constant.
+type Error string
+
+// Error implements error.
+func (e Error) Error() string { return string(e) }
+
+// Common Errors
+const (
+	ErrMalformedPacket     Error = "malformed packet"
+	ErrPacketTooLarge      Error = "packet too large"
+	ErrUnexpectedPacket    Error = "unexpected packet"
+	ErrInvalidBroker       Error = "invalid broker url"
+	ErrInvalidTopic        Error = "invalid topic"
+	ErrInvalidQoS          Error = "invalid qos"
+	ErrNotConnected        Error = "not connected"
+	ErrConnectionLost      Error = "connection lost"
+	ErrSubscriptionRefused Error = "subscription refused"
+)
+
+// ConnectError is a connect return code other than accepted.
+type ConnectError byte
+
+// Error implements error.
+func (ce ConnectError) Error() string {
+	switch ce {
+	case 1:
+		return "connection refused; unacceptable protocol version"
+	case 2:
+		return "connection refused; identifier rejected"
+	case 3:
+		return "connection refused; server unavailable"
+	case 4:
+		return "connection refused; bad user name or password"
+	case 5:
+		return "connection refused; not authorized"
+	default:
+		return fmt.Sprintf("connection refused; return code %d", byte(ce))
+	}
+}
@@ -0,0 +1,239 @@
+package mqtt
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Control packet types (mqtt 3.1.1 section 2.2.1).
+const (
+	packetConnect     byte = 1
+	packetConnack     byte = 2
+	packetPublish     byte = 3
+	packetPuback      byte = 4
+	packetPubrec      byte = 5
+	packetPubrel      byte = 6
+	packetPubcomp     byte = 7
+	packetSubscribe   byte = 8
+	packetSuback      byte = 9
+	packetUnsubscribe byte = 10
+	packetUnsuback    byte = 11
+	packetPingreq     byte = 12
+	packetPingresp    byte = 13
+	packetDisconnect  byte = 14
+)
+
+// maxRemainingLength is the largest remaining length the variable length encoding allows.
+const maxRemainingLength = 268435455
+
+// packet is a raw control packet.
+type packet struct {
+	Type  byte
+	Flags byte
+	Body  []byte
+}
+
+// readPacket reads a control packet.
+func readPacket(r *bufio.Reader) (*packet, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	var length, multiplier int = 0, 1
+	for index := 0; ; index++ {
+		if index == 4 {
+			return nil, ErrMalformedPacket
+		}
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		length += int(b&0x7f) * multiplier
+		if b&0x80 == 0 {
+			break
+		}
+		multiplier *= 128
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+	return &packet{Type: header >> 4, Flags: header & 0x0f, Body: body}, nil
+}
+
+// writePacket writes a control packet.
+func writePacket(w io.Writer, p packet) error {
+	if len(p.Body) > maxRemainingLength {
+		return ErrPacketTooLarge
+	}
+	header := []byte{p.Type<<4 | p.Flags}
+	length := len(p.Body)
+	for {
+		b := byte(length % 128)
+		length /= 128
+		if length > 0 {
+			b |= 0x80
+		}
+		header = append(header, b)
+		if length == 0 {
+			break
+		}
+	}
+	if _, err := w.Write(append(header, p.Body...)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// encoder builds a packet body.
+type encoder struct {
+	buf []byte
+}
+
+func (e *encoder) byte(b byte) *encoder {
+	e.buf = append(e.buf, b)
+	return e
+}
+
+func (e *encoder) uint16(v uint16) *encoder {
+	e.buf = append(e.buf, byte(v>>8), byte(v))
+	return e
+}
+
+func (e *encoder) string(s string) *encoder {
+	return e.bytes([]byte(s))
+}
+
+func (e *encoder) bytes(b []byte) *encoder {
+	e.uint16(uint16(len(b)))
+	e.buf = append(e.buf, b...)
+	return e
+}
+
+func (e *encoder) raw(b []byte) *encoder {
+	e.buf = append(e.buf, b...)
+	return e
+}
+
+// decoder reads a packet body.
+type decoder struct {
+	buf []byte
+	err error
+}
+
+func (d *decoder) uint16() uint16 {
+	if d.err != nil || len(d.buf) < 2 {
+		d.err = ErrMalformedPacket
+		return 0
+	}
+	v := binary.BigEndian.Uint16(d.buf)
+	d.buf = d.buf[2:]
+	return v
+}
+
+func (d *decoder) string() string {
+	length := int(d.uint16())
+	if d.err != nil || len(d.buf) < length {
+		d.err = ErrMalformedPacket
+		return ""
+	}
+	s := string(d.buf[:length])
+	d.buf = d.buf[length:]
+	return s
+}
+
+func (d *decoder) rest() []byte {
+	rest := d.buf
+	d.buf = nil
+	return rest
+}
+
+// connectPacket returns the connect packet for a given set of options.
+func connectPacket(opts Options) packet {
+	var flags byte
+	if opts.CleanSession {
+		flags |= 0x02
+	}
+	if opts.Will != nil {
+		flags |= 0x04 | (opts.Will.QoS&0x03)<<3
+		if opts.Will.Retained {
+			flags |= 0x20
+		}
+	}
+	if opts.Password != "" {
+		flags |= 0x40
+	}
+	if opts.Username != "" {
+		flags |= 0x80
+	}
+	e := new(encoder).string("MQTT").byte(4).byte(flags).uint16(uint16(opts.KeepAlive.Seconds()))
+	e.string(opts.ClientID)
+	if opts.Will != nil {
+		e.string(opts.Will.Topic).bytes(opts.Will.Payload)
+	}
+	if opts.Username != "" {
+		e.string(opts.Username)
+	}
+	if opts.Password != "" {
+		e.string(opts.Password)
+	}
+	return packet{Type: packetConnect, Body: e.buf}
+}
+
+// publishPacket returns a publish packet for a given message.
+func publishPacket(id uint16, m Message, duplicate bool) packet {
+	flags := (m.QoS & 0x03) << 1
+	if m.Retained {
+		flags |= 0x01
+	}
+	if duplicate {
+		flags |= 0x08
+	}
+	e := new(encoder).string(m.Topic)
+	if m.QoS > 0 {
+		e.uint16(id)
+	}
+	e.raw(m.Payload)
+	return packet{Type: packetPublish, Flags: flags, Body: e.buf}
+}
+
+// parsePublish parses a publish packet into its packet id and message.
+func parsePublish(p *packet) (uint16, Message, error) {
+	d := &decoder{buf: p.Body}
+	m := Message{
+		QoS:       (p.Flags >> 1) & 0x03,
+		Retained:  p.Flags&0x01 != 0,
+		Duplicate: p.Flags&0x08 != 0,
+	}
+	m.Topic = d.string()
+	var id uint16
+	if m.QoS > 0 {
+		id = d.uint16()
+	}
+	m.Payload = d.rest()
+	if d.err != nil {
+		return 0, m, d.err
+	}
+	if m.QoS > 2 {
+		return 0, m, fmt.Errorf("%w; invalid qos %d", ErrMalformedPacket, m.QoS)
+	}
+	return id, m, nil
+}
+
+// ackPacket returns a packet that only holds a packet id (puback, pubrec, pubrel, pubcomp, unsuback).
+func ackPacket(packetType byte, id uint16) packet {
+	var flags byte
+	if packetType == packetPubrel {
+		flags = 0x02
+	}
+	return packet{Type: packetType, Flags: flags, Body: new(encoder).uint16(id).buf}
+}
+
+// packetID returns the packet id of an ack packet.
+func packetID(p *packet) (uint16, error) {
+	d := &decoder{buf: p.Body}
+	id := d.uint16()
+	return id, d.err
+}
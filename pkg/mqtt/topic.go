@@ -0,0 +1,58 @@
+package mqtt
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Match returns if a topic matches a topic filter with `+` (single level)
+// and `#` (multi level) wildcards.
+//
+// Topics starting with `$` are not matched by filters starting with a wildcard.
+func Match(filter, topic string) bool {
+	if strings.HasPrefix(topic, "$") && (strings.HasPrefix(filter, "+") || strings.HasPrefix(filter, "#")) {
+		return false
+	}
+	filterLevels := strings.Split(filter, "/")
+	topicLevels := strings.Split(topic, "/")
+	for index, level := range filterLevels {
+		if level == "#" {
+			return true
+		}
+		if index >= len(topicLevels) {
+			return false
+		}
+		if level != "+" && level != topicLevels[index] {
+			return false
+		}
+	}
+	return len(filterLevels) == len(topicLevels)
+}
+
+// ValidateFilter returns an error if a topic filter is invalid.
+func ValidateFilter(filter string) error {
+	if filter == "" {
+		return fmt.Errorf("%w; empty filter", ErrInvalidTopic)
+	}
+	levels := strings.Split(filter, "/")
+	for index, level := range levels {
+		if strings.Contains(level, "#") && (level != "#" || index != len(levels)-1) {
+			return fmt.Errorf("%w; `#` must be the last level in %q", ErrInvalidTopic, filter)
+		}
+		if strings.Contains(level, "+") && level != "+" {
+			return fmt.Errorf("%w; `+` must occupy a whole level in %q", ErrInvalidTopic, filter)
+		}
+	}
+	return nil
+}
+
+// ValidateTopic returns an error if a topic name is invalid for publishing.
+func ValidateTopic(topic string) error {
+	if topic == "" {
+		return fmt.Errorf("%w; empty topic", ErrInvalidTopic)
+	}
+	if strings.ContainsAny(topic, "+#") {
+		return fmt.Errorf("%w; wildcards are not allowed in %q", ErrInvalidTopic, topic)
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/wcharczuk/lametric/pkg/lametric"
+)
+
+// Email sends notifications as plain text email over smtp.
+//
+// The first line is the subject, prefixed with the priority for warnings
+// and critical notifications, and all lines are the body.
+type Email struct {
+	// Addr is the smtp server `host:port`.
+	Addr     string
+	From     string
+	To       []string
+	Username string
+	Password string
+	// DialTimeout is the timeout to connect to the smtp server.
+	DialTimeout time.Duration
+	// Timeout is the timeout for the whole smtp exchange.
+	Timeout time.Duration
+}
+
+// Notify implements Notifier.
+//
+// The message is sent like `smtp.SendMail`, upgrading to tls if the server
+// supports it, but the connection is closed if the context is done.
+func (e Email) Notify(ctx context.Context, n lametric.Notification) error {
+	host, _, err := net.SplitHostPort(e.Addr)
+	if err != nil {
+		return err
+	}
+	if e.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.Timeout)
+		defer cancel()
+	}
+	dialer := net.Dialer{Timeout: e.DialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", e.Addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = conn.Close()
+		case <-done:
+		}
+	}()
+
+	err = e.send(conn, host, e.Message(n, time.Now()))
+	if ctxErr := ctx.Err(); err != nil && ctxErr != nil {
+		return ctxErr
+	}
+	return err
+}
+
+// send runs the smtp exchange on a given connection.
+func (e Email) send(conn net.Conn, host string, message []byte) error {
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if e.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", e.Username, e.Password, host)); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(e.From); err != nil {
+		return err
+	}
+	for _, to := range e.To {
+		if err := client.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(message); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// Message renders a notification as an rfc 5322 message.
+func (e Email) Message(n lametric.Notification, now time.Time) []byte {
+	subject := Title(n)
+	if n.Priority == lametric.NotificationPriorityWarning || n.Priority == lametric.NotificationPriorityCritical {
+		subject = fmt.Sprintf("[%s] %s", n.Priority, subject)
+	}
+	buffer := new(bytes.Buffer)
+	fmt.Fprintf(buffer, "From: %s\r\n", e.From)
+	fmt.Fprintf(buffer, "To: %s\r\n", strings.Join(e.To, ", "))
+	fmt.Fprintf(buffer, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(buffer, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	if UrgencyOf(n.Priority) == UrgencyUrgent {
+		buffer.WriteString("X-Priority: 1\r\nImportance: high\r\n")
+	}
+	buffer.WriteString("MIME-Version: 1.0\r\n")
+	buffer.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buffer.WriteString("\r\n")
+	buffer.WriteString(strings.Join(Lines(n), "\r\n"))
+	buffer.WriteString("\r\n")
+	return buffer.Bytes()
+}
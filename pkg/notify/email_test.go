@@ -0,0 +1,155 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wcharczuk/lametric/pkg/lametric"
+)
+
+// smtpSession is what a fake smtp server received.
+type smtpSession struct {
+	Auth string
+	From string
+	To   []string
+	Data string
+}
+
+// fakeSMTP accepts a single smtp session on a local listener; it advertises
+// plain auth but not starttls.
+func fakeSMTP(t *testing.T) (addr string, sessions <-chan smtpSession) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	output := make(chan smtpSession, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		tp := textproto.NewConn(conn)
+		var session smtpSession
+		_ = tp.PrintfLine("220 localhost ESMTP")
+		for {
+			line, err := tp.ReadLine()
+			if err != nil {
+				return
+			}
+			verb := strings.ToUpper(strings.Fields(line)[0])
+			switch verb {
+			case "EHLO":
+				_ = tp.PrintfLine("250-localhost")
+				_ = tp.PrintfLine("250 AUTH PLAIN")
+			case "AUTH":
+				session.Auth = strings.Fields(line)[2]
+				_ = tp.PrintfLine("235 ok")
+			case "MAIL":
+				session.From = line
+				_ = tp.PrintfLine("250 ok")
+			case "RCPT":
+				session.To = append(session.To, line)
+				_ = tp.PrintfLine("250 ok")
+			case "DATA":
+				_ = tp.PrintfLine("354 go ahead")
+				data, err := tp.ReadDotBytes()
+				if err != nil {
+					return
+				}
+				session.Data = string(data)
+				_ = tp.PrintfLine("250 queued")
+			case "QUIT":
+				_ = tp.PrintfLine("221 bye")
+				output <- session
+				return
+			default:
+				_ = tp.PrintfLine("502 unknown")
+			}
+		}
+	}()
+	return listener.Addr().String(), output
+}
+
+func TestEmail(t *testing.T) {
+	addr, sessions := fakeSMTP(t)
+	email := Email{
+		Addr:     addr,
+		From:     "notifier@example.com",
+		To:       []string{"ops@example.com", "oncall@example.com"},
+		Username: "notifier",
+		Password: "secret",
+		Timeout:  5 * time.Second,
+	}
+	if err := email.Notify(context.Background(), testNotification(lametric.NotificationPriorityCritical)); err != nil {
+		t.Fatal(err)
+	}
+	session := <-sessions
+	if expect := base64.StdEncoding.EncodeToString([]byte("\x00notifier\x00secret")); session.Auth != expect {
+		t.Errorf("expected plain auth %q, got %q", expect, session.Auth)
+	}
+	if session.From != "MAIL FROM:<notifier@example.com>" {
+		t.Errorf("unexpected sender %q", session.From)
+	}
+	if len(session.To) != 2 || session.To[1] != "RCPT TO:<oncall@example.com>" {
+		t.Errorf("unexpected recipients %q", session.To)
+	}
+	for _, line := range []string{
+		"Subject: [critical] api down",
+		"To: ops@example.com, oncall@example.com",
+		"X-Priority: 1",
+		"\n\napi down\nconnection refused\n",
+	} {
+		if !strings.Contains(session.Data, line) {
+			t.Errorf("expected the message to contain %q, got %q", line, session.Data)
+		}
+	}
+}
+
+func TestEmailCanceled(t *testing.T) {
+	// a server that accepts the connection but never greets.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			_, _ = bufio.NewReader(conn).ReadString('\n')
+			conn.Close()
+		}
+	}()
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	err = Email{Addr: listener.Addr().String(), From: "a@example.com", To: []string{"b@example.com"}}.Notify(ctx, testNotification(""))
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected %v, got %v", context.Canceled, err)
+	}
+}
+
+func ExampleEmail_Message() {
+	email := Email{From: "notifier@example.com", To: []string{"ops@example.com"}}
+	message := email.Message(testNotification(lametric.NotificationPriorityWarning), time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC))
+	fmt.Print(strings.ReplaceAll(string(message), "\r\n", "\n"))
+	// Output:
+	// From: notifier@example.com
+	// To: ops@example.com
+	// Subject: [warning] api down
+	// Date: Fri, 01 Mar 2024 09:30:00 +0000
+	// MIME-Version: 1.0
+	// Content-Type: text/plain; charset=utf-8
+	//
+	// api down
+	// connection refused
+}
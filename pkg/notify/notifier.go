@@ -0,0 +1,23 @@
+package notify
+
+import (
+	"context"
+
+	"github.com/wcharczuk/lametric/pkg/lametric"
+)
+
+// Notifier sends notifications to a backend.
+type Notifier interface {
+	Notify(context.Context, lametric.Notification) error
+}
+
+// Assert that the lametric client is a notifier.
+var _ Notifier = (*lametric.HTTPClient)(nil)
+
+// NotifierFunc is a function that implements Notifier.
+type NotifierFunc func(context.Context, lametric.Notification) error
+
+// Notify implements Notifier.
+func (nf NotifierFunc) Notify(ctx context.Context, n lametric.Notification) error {
+	return nf(ctx, n)
+}
@@ -0,0 +1,43 @@
+package notify
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/wcharczuk/lametric/pkg/lametric"
+)
+
+// captured is a request received by a capture server.
+type captured struct {
+	Method string
+	Header http.Header
+	Body   []byte
+}
+
+// capture returns a server that records the requests it receives and
+// responds with a given status.
+func capture(t *testing.T, statusCode int) (*httptest.Server, <-chan captured) {
+	t.Helper()
+	requests := make(chan captured, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		requests <- captured{Method: req.Method, Header: req.Header, Body: body}
+		rw.WriteHeader(statusCode)
+	}))
+	t.Cleanup(server.Close)
+	return server, requests
+}
+
+func testNotification(priority lametric.NotificationPriority) lametric.Notification {
+	return lametric.Notification{
+		Priority: priority,
+		Model: lametric.NotificationModel{
+			Frames: []lametric.Frame{
+				{Icon: lametric.IconAttention, Text: "api down"},
+				{Text: "connection refused"},
+			},
+		},
+	}
+}
@@ -0,0 +1,68 @@
+package notify
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/wcharczuk/lametric/pkg/apiutil"
+	"github.com/wcharczuk/lametric/pkg/lametric"
+)
+
+// NewNtfy returns a notifier that publishes to an ntfy style topic url
+// (e.g. `https://ntfy.sh/my-topic`), authorized with a bearer token if it's set.
+func NewNtfy(topicURL, token string, opts ...apiutil.Option) *Ntfy {
+	if token != "" {
+		opts = append(opts, apiutil.OptDefaults(apiutil.OptHeader("Authorization", "Bearer "+token)))
+	}
+	return &Ntfy{
+		Client: apiutil.New(topicURL, opts...),
+	}
+}
+
+// Ntfy publishes notifications to an ntfy style http push topic.
+//
+// The first line is sent as the title and the remaining lines as the message.
+type Ntfy struct {
+	Client apiutil.Client
+}
+
+// Notify implements Notifier.
+func (nf Ntfy) Notify(ctx context.Context, n lametric.Notification) error {
+	lines := Lines(n)
+	var title, message string
+	switch len(lines) {
+	case 0:
+	case 1:
+		message = lines[0]
+	default:
+		title, message = lines[0], strings.Join(lines[1:], "\n")
+	}
+	opts := []apiutil.RequestOption{
+		apiutil.OptMethod(http.MethodPost),
+		apiutil.OptHeader("Priority", strconv.Itoa(NtfyPriority(UrgencyOf(n.Priority)))),
+		apiutil.OptHeader("Content-Type", "text/plain; charset=utf-8"),
+		apiutil.OptBody([]byte(message)),
+	}
+	if title != "" {
+		opts = append(opts, apiutil.OptHeader("Title", title))
+	}
+	if n.Priority != "" {
+		opts = append(opts, apiutil.OptHeader("Tags", string(n.Priority)))
+	}
+	_, err := nf.Client.Discard(ctx, opts...)
+	return err
+}
+
+// NtfyPriority maps an urgency to an ntfy priority (1-5, 3 is the default).
+func NtfyPriority(u Urgency) int {
+	switch u {
+	case UrgencyHigh:
+		return 4
+	case UrgencyUrgent:
+		return 5
+	default:
+		return 3
+	}
+}
@@ -0,0 +1,50 @@
+package notify
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/wcharczuk/lametric/pkg/lametric"
+)
+
+func TestNtfy(t *testing.T) {
+	server, requests := capture(t, http.StatusOK)
+	if err := NewNtfy(server.URL+"/alerts", "tk_secret").Notify(context.Background(), testNotification(lametric.NotificationPriorityCritical)); err != nil {
+		t.Fatal(err)
+	}
+	req := <-requests
+	expect := map[string]string{
+		"Authorization": "Bearer tk_secret",
+		"Title":         "api down",
+		"Priority":      "5",
+		"Tags":          "critical",
+		"Content-Type":  "text/plain; charset=utf-8",
+	}
+	for name, value := range expect {
+		if actual := req.Header.Get(name); actual != value {
+			t.Errorf("%s; expected %q, got %q", name, value, actual)
+		}
+	}
+	if string(req.Body) != "connection refused" {
+		t.Errorf("expected the remaining lines as the message, got %q", req.Body)
+	}
+}
+
+func TestNtfySingleLine(t *testing.T) {
+	server, requests := capture(t, http.StatusOK)
+	n := lametric.Notification{Model: lametric.NotificationModel{Frames: []lametric.Frame{{Text: "backup done"}}}}
+	if err := NewNtfy(server.URL+"/alerts", "").Notify(context.Background(), n); err != nil {
+		t.Fatal(err)
+	}
+	req := <-requests
+	if title := req.Header.Get("Title"); title != "" {
+		t.Errorf("expected no title, got %q", title)
+	}
+	if priority := req.Header.Get("Priority"); priority != "3" {
+		t.Errorf("expected the default priority, got %q", priority)
+	}
+	if string(req.Body) != "backup done" {
+		t.Errorf("expected the line as the message, got %q", req.Body)
+	}
+}
@@ -0,0 +1,114 @@
+package notify
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/wcharczuk/lametric/pkg/lametric"
+)
+
+// Urgency is a backend neutral notification urgency.
+type Urgency int
+
+// Urgencies
+const (
+	UrgencyNormal Urgency = iota
+	UrgencyHigh
+	UrgencyUrgent
+)
+
+// String implements fmt.Stringer.
+func (u Urgency) String() string {
+	switch u {
+	case UrgencyHigh:
+		return "high"
+	case UrgencyUrgent:
+		return "urgent"
+	default:
+		return "normal"
+	}
+}
+
+// UrgencyOf returns the urgency for a notification priority.
+func UrgencyOf(priority lametric.NotificationPriority) Urgency {
+	switch priority {
+	case lametric.NotificationPriorityWarning:
+		return UrgencyHigh
+	case lametric.NotificationPriorityCritical:
+		return UrgencyUrgent
+	default:
+		return UrgencyNormal
+	}
+}
+
+// Lines renders each frame of a notification as a line of text.
+//
+// Goal frames are rendered as `current/end unit` and chart frames as a sparkline.
+func Lines(n lametric.Notification) (output []string) {
+	for _, frame := range n.Model.Frames {
+		if line := FrameLine(frame); line != "" {
+			output = append(output, line)
+		}
+	}
+	return
+}
+
+// FrameLine renders a frame as a line of text.
+func FrameLine(frame lametric.Frame) string {
+	var parts []string
+	if frame.Text != "" {
+		parts = append(parts, frame.Text)
+	}
+	if goal := frame.GoalData; goal != nil {
+		parts = append(parts, strings.TrimSpace(fmt.Sprintf("%s/%s %s", formatFloat(goal.Current), formatFloat(goal.End), goal.Unit)))
+	}
+	if len(frame.ChartData) > 0 {
+		parts = append(parts, Sparkline(frame.ChartData))
+	}
+	return strings.Join(parts, " ")
+}
+
+// Title returns the first line of a notification.
+func Title(n lametric.Notification) string {
+	if lines := Lines(n); len(lines) > 0 {
+		return lines[0]
+	}
+	return ""
+}
+
+// Body returns the lines of a notification joined by newlines.
+func Body(n lametric.Notification) string {
+	return strings.Join(Lines(n), "\n")
+}
+
+var sparks = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline renders chart data as a line of block characters.
+func Sparkline(values []int) string {
+	if len(values) == 0 {
+		return ""
+	}
+	min, max := values[0], values[0]
+	for _, value := range values[1:] {
+		if value < min {
+			min = value
+		}
+		if value > max {
+			max = value
+		}
+	}
+	output := make([]rune, len(values))
+	for index, value := range values {
+		if max == min {
+			output[index] = sparks[len(sparks)/2]
+			continue
+		}
+		output[index] = sparks[(value-min)*(len(sparks)-1)/(max-min)]
+	}
+	return string(output)
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
@@ -0,0 +1,49 @@
+package notify
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/wcharczuk/lametric/pkg/apiutil"
+	"github.com/wcharczuk/lametric/pkg/lametric"
+)
+
+// NewSlack returns a notifier for a slack compatible incoming webhook url.
+func NewSlack(webhookURL string, opts ...apiutil.Option) *Slack {
+	return &Slack{
+		Client: apiutil.New(webhookURL, opts...),
+	}
+}
+
+// Slack posts notifications to a slack compatible incoming webhook.
+type Slack struct {
+	Client apiutil.Client
+}
+
+// Notify implements Notifier.
+func (s Slack) Notify(ctx context.Context, n lametric.Notification) error {
+	_, err := s.Client.Discard(ctx,
+		apiutil.OptMethod(http.MethodPost),
+		apiutil.OptJSONBody(SlackMessage(n)),
+	)
+	return err
+}
+
+// SlackMessage renders a notification as a slack message; the first line is
+// bold and prefixed with an emoji for the urgency.
+func SlackMessage(n lametric.Notification) map[string]interface{} {
+	lines := Lines(n)
+	if len(lines) > 0 {
+		lines[0] = "*" + lines[0] + "*"
+		switch UrgencyOf(n.Priority) {
+		case UrgencyHigh:
+			lines[0] = ":warning: " + lines[0]
+		case UrgencyUrgent:
+			lines[0] = ":rotating_light: " + lines[0]
+		}
+	}
+	return map[string]interface{}{
+		"text": strings.Join(lines, "\n"),
+	}
+}
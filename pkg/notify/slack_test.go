@@ -0,0 +1,41 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/wcharczuk/lametric/pkg/apiutil"
+	"github.com/wcharczuk/lametric/pkg/lametric"
+)
+
+func TestSlack(t *testing.T) {
+	server, requests := capture(t, http.StatusOK)
+	if err := NewSlack(server.URL+"/services/T0/B0/x").Notify(context.Background(), testNotification(lametric.NotificationPriorityCritical)); err != nil {
+		t.Fatal(err)
+	}
+	req := <-requests
+	if req.Method != http.MethodPost {
+		t.Errorf("expected a post, got %s", req.Method)
+	}
+	var message struct {
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(req.Body, &message); err != nil {
+		t.Fatal(err)
+	}
+	if expect := ":rotating_light: *api down*\nconnection refused"; message.Text != expect {
+		t.Errorf("expected %q, got %q", expect, message.Text)
+	}
+}
+
+func TestSlackStatusError(t *testing.T) {
+	server, _ := capture(t, http.StatusNotFound)
+	err := NewSlack(server.URL).Notify(context.Background(), testNotification(""))
+	var statusErr *apiutil.StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+		t.Errorf("expected a 404 status error, got %v", err)
+	}
+}
@@ -0,0 +1,60 @@
+package notify
+
+import (
+	"bytes"
+	"strings"
+	"text/template"
+
+	"github.com/wcharczuk/lametric/pkg/lametric"
+)
+
+// TemplateFuncs are the functions available to notification templates.
+var TemplateFuncs = template.FuncMap{
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"trim":  strings.TrimSpace,
+	"default": func(fallback, value interface{}) interface{} {
+		if value == nil || value == "" {
+			return fallback
+		}
+		return value
+	},
+}
+
+// Render returns a copy of a notification with the text and icon of each frame
+// executed as a text/template with given data.
+func Render(n lametric.Notification, data interface{}) (lametric.Notification, error) {
+	if len(n.Model.Frames) == 0 {
+		return n, nil
+	}
+	frames := make([]lametric.Frame, len(n.Model.Frames))
+	for index, frame := range n.Model.Frames {
+		text, err := renderString(frame.Text, data)
+		if err != nil {
+			return n, err
+		}
+		icon, err := renderString(string(frame.Icon), data)
+		if err != nil {
+			return n, err
+		}
+		frame.Text, frame.Icon = text, lametric.Icon(icon)
+		frames[index] = frame
+	}
+	n.Model.Frames = frames
+	return n, nil
+}
+
+func renderString(value string, data interface{}) (string, error) {
+	if !strings.Contains(value, "{{") {
+		return value, nil
+	}
+	tmpl, err := template.New("").Funcs(TemplateFuncs).Option("missingkey=zero").Parse(value)
+	if err != nil {
+		return "", err
+	}
+	buffer := new(bytes.Buffer)
+	if err := tmpl.Execute(buffer, data); err != nil {
+		return "", err
+	}
+	return buffer.String(), nil
+}
@@ -0,0 +1,49 @@
+package notify
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/wcharczuk/lametric/pkg/apiutil"
+	"github.com/wcharczuk/lametric/pkg/lametric"
+)
+
+// NewWebhook returns a notifier that posts json to a given url, authorized
+// with a bearer token if it's set.
+func NewWebhook(url, token string, opts ...apiutil.Option) *Webhook {
+	if token != "" {
+		opts = append(opts, apiutil.OptDefaults(apiutil.OptHeader("Authorization", "Bearer "+token)))
+	}
+	return &Webhook{
+		Client: apiutil.New(url, opts...),
+	}
+}
+
+// Webhook posts notifications as json to a url.
+type Webhook struct {
+	Client apiutil.Client
+}
+
+// WebhookPayload is the json body posted by the webhook notifier.
+type WebhookPayload struct {
+	Title        string                `json:"title"`
+	Lines        []string              `json:"lines"`
+	Priority     string                `json:"priority,omitempty"`
+	Urgency      string                `json:"urgency"`
+	Notification lametric.Notification `json:"notification"`
+}
+
+// Notify implements Notifier.
+func (w Webhook) Notify(ctx context.Context, n lametric.Notification) error {
+	_, err := w.Client.Discard(ctx,
+		apiutil.OptMethod(http.MethodPost),
+		apiutil.OptJSONBody(WebhookPayload{
+			Title:        Title(n),
+			Lines:        Lines(n),
+			Priority:     string(n.Priority),
+			Urgency:      UrgencyOf(n.Priority).String(),
+			Notification: n,
+		}),
+	)
+	return err
+}
@@ -0,0 +1,51 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/wcharczuk/lametric/pkg/lametric"
+)
+
+func TestWebhook(t *testing.T) {
+	server, requests := capture(t, http.StatusAccepted)
+	n := testNotification(lametric.NotificationPriorityWarning)
+	if err := NewWebhook(server.URL, "secret").Notify(context.Background(), n); err != nil {
+		t.Fatal(err)
+	}
+	req := <-requests
+	if auth := req.Header.Get("Authorization"); auth != "Bearer secret" {
+		t.Errorf("expected a bearer token, got %q", auth)
+	}
+	if contentType := req.Header.Get("Content-Type"); !strings.HasPrefix(contentType, "application/json") {
+		t.Errorf("expected json, got %q", contentType)
+	}
+	var payload WebhookPayload
+	if err := json.Unmarshal(req.Body, &payload); err != nil {
+		t.Fatal(err)
+	}
+	expect := WebhookPayload{
+		Title:        "api down",
+		Lines:        []string{"api down", "connection refused"},
+		Priority:     "warning",
+		Urgency:      UrgencyHigh.String(),
+		Notification: n,
+	}
+	if !reflect.DeepEqual(payload, expect) {
+		t.Errorf("expected %+v, got %+v", expect, payload)
+	}
+}
+
+func TestWebhookWithoutToken(t *testing.T) {
+	server, requests := capture(t, http.StatusOK)
+	if err := NewWebhook(server.URL, "").Notify(context.Background(), testNotification("")); err != nil {
+		t.Fatal(err)
+	}
+	if auth := (<-requests).Header.Get("Authorization"); auth != "" {
+		t.Errorf("expected no authorization, got %q", auth)
+	}
+}
@@ -0,0 +1,220 @@
+package prometheus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wcharczuk/lametric/pkg/apiutil"
+	"github.com/wcharczuk/lametric/pkg/lametric"
+)
+
+// Result types.
+const (
+	ResultTypeVector = "vector"
+	ResultTypeScalar = "scalar"
+	ResultTypeMatrix = "matrix"
+)
+
+// New returns a client for the prometheus compatible http api at a given base url,
+// e.g. `http://prometheus:9090`.
+func New(baseURL string, opts ...apiutil.Option) *Client {
+	return &Client{
+		// errors are read from the response body rather than the status code.
+		Client: apiutil.New(baseURL, append(opts, apiutil.OptResponseFilter(nil))...),
+	}
+}
+
+// Client queries a prometheus compatible http api.
+type Client struct {
+	Client apiutil.Client
+}
+
+// Sample is the value of a series at a point in time.
+type Sample struct {
+	Metric map[string]string
+	Point  lametric.Point
+}
+
+// Series is the values of a series over a time range.
+type Series struct {
+	Metric map[string]string
+	Points []lametric.Point
+}
+
+// Query runs an instant query at a given time, or now if it's zero.
+//
+// Scalar results are returned as a single sample without labels.
+func (c *Client) Query(ctx context.Context, query string, at time.Time) ([]Sample, error) {
+	params := url.Values{"query": []string{query}}
+	if !at.IsZero() {
+		params.Set("time", formatTime(at))
+	}
+	resultType, result, err := c.get(ctx, "/api/v1/query", params)
+	if err != nil {
+		return nil, err
+	}
+	switch resultType {
+	case ResultTypeScalar:
+		var value samplePair
+		if err := json.Unmarshal(result, &value); err != nil {
+			return nil, err
+		}
+		return []Sample{{Point: lametric.Point(value)}}, nil
+	case ResultTypeVector:
+		var vector []struct {
+			Metric map[string]string `json:"metric"`
+			Value  samplePair        `json:"value"`
+		}
+		if err := json.Unmarshal(result, &vector); err != nil {
+			return nil, err
+		}
+		output := make([]Sample, len(vector))
+		for index, sample := range vector {
+			output[index] = Sample{Metric: sample.Metric, Point: lametric.Point(sample.Value)}
+		}
+		sort.Slice(output, func(i, j int) bool { return labels(output[i].Metric) < labels(output[j].Metric) })
+		return output, nil
+	default:
+		return nil, fmt.Errorf("%w; %q", ErrResultType, resultType)
+	}
+}
+
+// QueryRange runs a range query between given times with a given step.
+func (c *Client) QueryRange(ctx context.Context, query string, start, end time.Time, step time.Duration) ([]Series, error) {
+	params := url.Values{
+		"query": []string{query},
+		"start": []string{formatTime(start)},
+		"end":   []string{formatTime(end)},
+		"step":  []string{strconv.FormatFloat(step.Seconds(), 'f', -1, 64)},
+	}
+	resultType, result, err := c.get(ctx, "/api/v1/query_range", params)
+	if err != nil {
+		return nil, err
+	}
+	if resultType != ResultTypeMatrix {
+		return nil, fmt.Errorf("%w; %q", ErrResultType, resultType)
+	}
+	var matrix []struct {
+		Metric map[string]string `json:"metric"`
+		Values []samplePair      `json:"values"`
+	}
+	if err := json.Unmarshal(result, &matrix); err != nil {
+		return nil, err
+	}
+	output := make([]Series, len(matrix))
+	for index, series := range matrix {
+		output[index].Metric = series.Metric
+		for _, value := range series.Values {
+			output[index].Points = append(output[index].Points, lametric.Point(value))
+		}
+	}
+	sort.Slice(output, func(i, j int) bool { return labels(output[i].Metric) < labels(output[j].Metric) })
+	return output, nil
+}
+
+// One returns the only sample of an instant query result.
+func One(samples []Sample) (Sample, error) {
+	switch len(samples) {
+	case 0:
+		return Sample{}, ErrResultEmpty
+	case 1:
+		return samples[0], nil
+	default:
+		return Sample{}, fmt.Errorf("%w; got %d", ErrResultAmbiguous, len(samples))
+	}
+}
+
+// OneSeries returns the only series of a range query result.
+func OneSeries(series []Series) (Series, error) {
+	switch len(series) {
+	case 0:
+		return Series{}, ErrResultEmpty
+	case 1:
+		return series[0], nil
+	default:
+		return Series{}, fmt.Errorf("%w; got %d", ErrResultAmbiguous, len(series))
+	}
+}
+
+// response is the envelope of api responses.
+type response struct {
+	Status string `json:"status"`
+	Data   struct {
+		ResultType string          `json:"resultType"`
+		Result     json.RawMessage `json:"result"`
+	} `json:"data"`
+	ErrorType string `json:"errorType"`
+	Error     string `json:"error"`
+}
+
+func (c *Client) get(ctx context.Context, path string, params url.Values) (string, json.RawMessage, error) {
+	res, contents, err := c.Client.Bytes(ctx,
+		apiutil.OptPath(strings.TrimSuffix(c.Client.URL.Path, "/")+path),
+		apiutil.OptQuery(params),
+		apiutil.OptHeader("Accept", "application/json"),
+	)
+	if err != nil {
+		return "", nil, err
+	}
+	var output response
+	if err := json.Unmarshal(contents, &output); err != nil {
+		return "", nil, fmt.Errorf("%w; %s", ErrQueryFailed, res.Status)
+	}
+	if output.Status != "success" {
+		return "", nil, fmt.Errorf("%w; %s; %s", ErrQueryFailed, output.ErrorType, output.Error)
+	}
+	return output.Data.ResultType, output.Data.Result, nil
+}
+
+// samplePair is a `[<unix time>, "<value>"]` pair.
+type samplePair lametric.Point
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (sp *samplePair) UnmarshalJSON(contents []byte) error {
+	var pair [2]interface{}
+	if err := json.Unmarshal(contents, &pair); err != nil {
+		return err
+	}
+	seconds, ok := pair[0].(float64)
+	if !ok {
+		return fmt.Errorf("%w; invalid sample time %v", ErrResultType, pair[0])
+	}
+	text, ok := pair[1].(string)
+	if !ok {
+		return fmt.Errorf("%w; invalid sample value %v", ErrResultType, pair[1])
+	}
+	// values may be `NaN`, `+Inf` or `-Inf`, which parse as is.
+	value, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return fmt.Errorf("%w; invalid sample value %q", ErrResultType, text)
+	}
+	whole, fraction := math.Modf(seconds)
+	sp.Time = time.Unix(int64(whole), int64(math.Round(fraction*1e3))*int64(time.Millisecond)).UTC()
+	sp.Value = value
+	return nil
+}
+
+func formatTime(t time.Time) string {
+	return strconv.FormatFloat(float64(t.UnixNano())/1e9, 'f', 3, 64)
+}
+
+// labels returns the labels of a series in the prometheus text format, e.g. `{job="api"}`.
+func labels(metric map[string]string) string {
+	names := make([]string, 0, len(metric))
+	for name := range metric {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	pairs := make([]string, len(names))
+	for index, name := range names {
+		pairs[index] = fmt.Sprintf("%s=%q", name, metric[name])
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
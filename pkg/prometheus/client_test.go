@@ -0,0 +1,180 @@
+package prometheus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// stubAPI serves canned responses for the query api paths under a prefix,
+// recording the query parameters of the last request.
+type stubAPI struct {
+	Prefix    string
+	Responses map[string]string
+	Status    int
+
+	params url.Values
+}
+
+func (sa *stubAPI) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	response, ok := sa.Responses[req.URL.Path]
+	if !ok {
+		http.NotFound(rw, req)
+		return
+	}
+	sa.params = req.URL.Query()
+	rw.Header().Set("Content-Type", "application/json")
+	if sa.Status != 0 {
+		rw.WriteHeader(sa.Status)
+	}
+	fmt.Fprint(rw, response)
+}
+
+func TestClientQuery(t *testing.T) {
+	testCases := [...]struct {
+		Name     string
+		Response string
+		Status   int
+		// Expected are the sample values by labels, in order.
+		Expected []string
+		Err      error
+	}{
+		{
+			Name:     "vector",
+			Response: `{"status":"success","data":{"resultType":"vector","result":[{"metric":{"job":"web"},"value":[1792418400.5,"0.25"]},{"metric":{"job":"api"},"value":[1792418400.5,"12"]}]}}`,
+			Expected: []string{`{job="api"} 12`, `{job="web"} 0.25`},
+		},
+		{
+			Name:     "scalar",
+			Response: `{"status":"success","data":{"resultType":"scalar","result":[1792418400,"42"]}}`,
+			Expected: []string{"{} 42"},
+		},
+		{
+			Name:     "non finite",
+			Response: `{"status":"success","data":{"resultType":"vector","result":[{"metric":{"a":"1"},"value":[1792418400,"NaN"]},{"metric":{"a":"2"},"value":[1792418400,"+Inf"]}]}}`,
+			Expected: []string{`{a="1"} NaN`, `{a="2"} +Inf`},
+		},
+		{
+			Name:     "empty",
+			Response: `{"status":"success","data":{"resultType":"vector","result":[]}}`,
+			Expected: []string{},
+		},
+		{
+			Name:     "error",
+			Status:   http.StatusBadRequest,
+			Response: `{"status":"error","errorType":"bad_data","error":"parse error at char 5"}`,
+			Err:      ErrQueryFailed,
+		},
+		{
+			Name:     "not json",
+			Status:   http.StatusBadGateway,
+			Response: `<html>bad gateway</html>`,
+			Err:      ErrQueryFailed,
+		},
+		{
+			Name:     "matrix",
+			Response: `{"status":"success","data":{"resultType":"matrix","result":[]}}`,
+			Err:      ErrResultType,
+		},
+		{
+			Name:     "invalid value",
+			Response: `{"status":"success","data":{"resultType":"scalar","result":[1792418400,"high"]}}`,
+			Err:      ErrResultType,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			api := &stubAPI{Responses: map[string]string{"/prometheus/api/v1/query": tc.Response}, Status: tc.Status}
+			server := httptest.NewServer(api)
+			defer server.Close()
+
+			at := time.Date(2026, 10, 19, 14, 0, 0, 500*int(time.Millisecond), time.UTC)
+			samples, err := New(server.URL+"/prometheus/").Query(context.Background(), `sum(up) by (job)`, at)
+			if tc.Err != nil {
+				if !errors.Is(err, tc.Err) {
+					t.Fatalf("expected %v, actual %v", tc.Err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if api.params.Get("query") != `sum(up) by (job)` || api.params.Get("time") != "1792418400.500" {
+				t.Errorf("unexpected query parameters %v", api.params)
+			}
+			actual := make([]string, len(samples))
+			for index, sample := range samples {
+				actual[index] = fmt.Sprintf("%s %v", labels(sample.Metric), formatValue(sample.Point.Value))
+				if sample.Point.Time.Unix() != 1792418400 {
+					t.Errorf("unexpected sample time %v", sample.Point.Time)
+				}
+			}
+			if fmt.Sprint(actual) != fmt.Sprint(tc.Expected) {
+				t.Errorf("expected %q, actual %q", tc.Expected, actual)
+			}
+		})
+	}
+}
+
+func TestClientQueryRange(t *testing.T) {
+	api := &stubAPI{Responses: map[string]string{
+		"/api/v1/query_range": `{"status":"success","data":{"resultType":"matrix","result":[{"metric":{},"values":[[1792414800,"1"],[1792415100,"NaN"],[1792415400,"3.5"]]}]}}`,
+	}}
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	end := time.Date(2026, 10, 19, 13, 0, 0, 0, time.UTC)
+	matrix, err := New(server.URL).QueryRange(context.Background(), "rate(requests_total[5m])", end.Add(-time.Hour), end, 5*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := url.Values{
+		"query": []string{"rate(requests_total[5m])"},
+		"start": []string{"1792411200.000"},
+		"end":   []string{"1792414800.000"},
+		"step":  []string{"300"},
+	}
+	if api.params.Encode() != expected.Encode() {
+		t.Errorf("expected parameters %v, actual %v", expected, api.params)
+	}
+	series, err := OneSeries(matrix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(series.Points) != 3 || series.Points[0].Value != 1 || !math.IsNaN(series.Points[1].Value) || series.Points[2].Value != 3.5 {
+		t.Errorf("unexpected points %+v", series.Points)
+	}
+	if !series.Points[2].Time.Equal(time.Unix(1792415400, 0)) {
+		t.Errorf("unexpected point time %v", series.Points[2].Time)
+	}
+
+	api.Responses["/api/v1/query_range"] = `{"status":"success","data":{"resultType":"vector","result":[]}}`
+	if _, err := New(server.URL).QueryRange(context.Background(), "up", end.Add(-time.Hour), end, time.Minute); !errors.Is(err, ErrResultType) {
+		t.Errorf("expected a result type error, actual %v", err)
+	}
+}
+
+func TestOne(t *testing.T) {
+	if _, err := One(nil); !errors.Is(err, ErrResultEmpty) {
+		t.Errorf("expected an empty result error, actual %v", err)
+	}
+	if _, err := One(make([]Sample, 2)); !errors.Is(err, ErrResultAmbiguous) {
+		t.Errorf("expected an ambiguous result error, actual %v", err)
+	}
+	if _, err := OneSeries(make([]Series, 2)); !errors.Is(err, ErrResultAmbiguous) {
+		t.Errorf("expected an ambiguous result error, actual %v", err)
+	}
+}
+
+func formatValue(value float64) string {
+	if math.IsInf(value, 1) {
+		return "+Inf"
+	}
+	return fmt.Sprint(value)
+}
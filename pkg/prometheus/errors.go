@@ -0,0 +1,15 @@
+package prometheus
+
+// Error is an error constant.
+type Error string
+
+// Error implements error.
+func (e Error) Error() string { return string(e) }
+
+// Common Errors
+const (
+	ErrQueryFailed     Error = "prometheus query failed"
+	ErrResultType      Error = "unexpected prometheus result type"
+	ErrResultEmpty     Error = "prometheus query returned no series"
+	ErrResultAmbiguous Error = "prometheus query returned more than one series; aggregate it to one, e.g. with sum()"
+)
@@ -0,0 +1,106 @@
+package remind
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Day and week durations.
+const (
+	Day  = 24 * time.Hour
+	Week = 7 * Day
+)
+
+// Duration is a duration that's parsed and formatted with day and week units.
+type Duration time.Duration
+
+// ParseDuration parses a duration like `time.ParseDuration` that may also
+// have `d` (day) and `w` (week) units, e.g. `1w`, `2d12h` or `25m`.
+func ParseDuration(value string) (time.Duration, error) {
+	var days float64
+	var rest strings.Builder
+	remaining := strings.TrimSpace(value)
+	if remaining == "" || strings.HasPrefix(remaining, "-") {
+		return 0, fmt.Errorf("%w; %q", ErrDurationInvalid, value)
+	}
+	for remaining != "" {
+		number := strings.IndexFunc(remaining, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+		if number <= 0 {
+			return 0, fmt.Errorf("%w; %q", ErrDurationInvalid, value)
+		}
+		unit := strings.IndexFunc(remaining[number:], func(r rune) bool { return (r >= '0' && r <= '9') || r == '.' })
+		if unit < 0 {
+			unit = len(remaining) - number
+		}
+		amount, name := remaining[:number], remaining[number:number+unit]
+		remaining = remaining[number+unit:]
+		switch name {
+		case "d", "w":
+			parsed, err := strconv.ParseFloat(amount, 64)
+			if err != nil {
+				return 0, fmt.Errorf("%w; %q", ErrDurationInvalid, value)
+			}
+			if name == "w" {
+				parsed *= 7
+			}
+			days += parsed
+		default:
+			rest.WriteString(amount + name)
+		}
+	}
+	var output time.Duration
+	if rest.Len() > 0 {
+		parsed, err := time.ParseDuration(rest.String())
+		if err != nil {
+			return 0, fmt.Errorf("%w; %q", ErrDurationInvalid, value)
+		}
+		output = parsed
+	}
+	return output + time.Duration(days*float64(Day)), nil
+}
+
+// FormatDuration formats a duration with week and day units, e.g. `1w`, `2d12h` or `25m`.
+func FormatDuration(d time.Duration) string {
+	if d <= 0 {
+		return d.String()
+	}
+	var output strings.Builder
+	if weeks := d / Week; weeks > 0 {
+		fmt.Fprintf(&output, "%dw", weeks)
+		d -= weeks * Week
+	}
+	if days := d / Day; days > 0 {
+		fmt.Fprintf(&output, "%dd", days)
+		d -= days * Day
+	}
+	if d > 0 {
+		// `time.Duration.String` has trailing zero units, e.g. `12h0m0s`.
+		rest := d.String()
+		if strings.HasSuffix(rest, "m0s") {
+			rest = strings.TrimSuffix(rest, "0s")
+		}
+		if strings.HasSuffix(rest, "h0m") {
+			rest = strings.TrimSuffix(rest, "0m")
+		}
+		output.WriteString(rest)
+	}
+	return output.String()
+}
+
+// String implements fmt.Stringer.
+func (d Duration) String() string { return FormatDuration(time.Duration(d)) }
+
+// MarshalText implements encoding.TextMarshaler.
+func (d Duration) MarshalText() ([]byte, error) { return []byte(d.String()), nil }
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
@@ -0,0 +1,14 @@
+package remind
+
+// Error is an error constant.
+type Error string
+
+// Error implements error.
+func (e Error) Error() string { return string(e) }
+
+// Common Errors
+const (
+	ErrDurationInvalid  Error = "invalid duration"
+	ErrReminderInvalid  Error = "invalid reminder"
+	ErrReminderNotFound Error = "reminder not found"
+)
@@ -0,0 +1,84 @@
+package remind
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/wcharczuk/lametric/pkg/lametric"
+)
+
+// Reminder is a notification sent at a given time, and optionally repeated.
+type Reminder struct {
+	ID string `json:"id"`
+	// At is when the reminder is next sent.
+	At time.Time `json:"at"`
+	// Every, if set, is how often the reminder repeats after it's sent.
+	Every Duration `json:"every,omitempty"`
+	// Devices are the names of the devices to send to; defaults to all of them.
+	Devices      []string              `json:"devices,omitempty"`
+	Notification lametric.Notification `json:"notification"`
+	Created      time.Time             `json:"created"`
+	// Attempts is how many times sending the reminder has failed.
+	Attempts int `json:"attempts,omitempty"`
+}
+
+// Text returns the text of the notification frames.
+func (r Reminder) Text() string {
+	var texts []string
+	for _, frame := range r.Notification.Model.Frames {
+		if frame.Text != "" {
+			texts = append(texts, frame.Text)
+		}
+	}
+	return strings.Join(texts, " / ")
+}
+
+// Validate returns an error if the reminder is invalid.
+func (r Reminder) Validate() error {
+	if r.At.IsZero() {
+		return fmt.Errorf("%w; a time is required", ErrReminderInvalid)
+	}
+	if len(r.Notification.Model.Frames) == 0 {
+		return fmt.Errorf("%w; at least one frame is required", ErrReminderInvalid)
+	}
+	if r.Every < 0 || (r.Every > 0 && time.Duration(r.Every) < time.Minute) {
+		return fmt.Errorf("%w; repeats must be at least a minute apart", ErrReminderInvalid)
+	}
+	if sound := r.Notification.Model.Sound; sound != nil {
+		if err := sound.Validate(); err != nil {
+			return fmt.Errorf("%w; %v", ErrReminderInvalid, err)
+		}
+	}
+	return nil
+}
+
+// Next returns the first time the reminder repeats after a given time,
+// or the zero time if it doesn't repeat.
+//
+// Repeats of whole days are added in local time, so reminders keep their
+// wall clock time across daylight saving changes.
+func (r Reminder) Next(after time.Time) time.Time {
+	every := time.Duration(r.Every)
+	if every <= 0 {
+		return time.Time{}
+	}
+	next := r.At.Local()
+	for !next.After(after) {
+		if every%Day == 0 {
+			next = next.AddDate(0, 0, int(every/Day))
+		} else {
+			next = next.Add(every)
+		}
+	}
+	return next
+}
+
+// newID returns a random reminder id.
+func newID() string {
+	id := make([]byte, 4)
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
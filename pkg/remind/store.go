@@ -0,0 +1,286 @@
+package remind
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/wcharczuk/lametric/pkg/fileutil"
+)
+
+// DefaultPollInterval is the default of how often the store file is re-read
+// for reminders added by other processes while running.
+const DefaultPollInterval = time.Minute
+
+// Retries of reminders that fail to send.
+const (
+	// RetryBackoff is the delay before the first retry, doubled for each one after.
+	RetryBackoff = time.Minute
+	// MaxRetryBackoff is the longest delay between retries.
+	MaxRetryBackoff = time.Hour
+	// MaxAttempts is how many times sending a reminder is tried before it's dropped.
+	MaxAttempts = 8
+)
+
+// NewStore returns a store backed by a json file at a given path.
+//
+// The file is read for each operation, so it can be shared with other processes
+// (e.g. `remind` when the server isn't running); changes are made under an os
+// file lock on `<path>.lock`.
+func NewStore(path string) *Store {
+	return &Store{Path: path, changed: make(chan struct{}, 1)}
+}
+
+// Store is a set of reminders persisted in a json file.
+type Store struct {
+	Path string
+	// PollInterval is how often the file is re-read while running; defaults to a minute.
+	PollInterval time.Duration
+	// Now returns the current time; defaults to `time.Now`.
+	Now func() time.Time
+
+	mu      sync.Mutex
+	changed chan struct{}
+}
+
+// lock takes the store lock and the file lock shared with other processes,
+// returning a function that releases them.
+func (s *Store) lock() (func(), error) {
+	s.mu.Lock()
+	fileLock, err := fileutil.Lock(s.Path + ".lock")
+	if err != nil {
+		s.mu.Unlock()
+		return nil, err
+	}
+	return func() {
+		_ = fileLock.Unlock()
+		s.mu.Unlock()
+	}, nil
+}
+
+// List returns the reminders, soonest first.
+func (s *Store) List() ([]Reminder, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.read()
+}
+
+// Add validates and adds a reminder, returning it with its id set.
+func (s *Store) Add(r Reminder) (Reminder, error) {
+	if err := r.Validate(); err != nil {
+		return Reminder{}, err
+	}
+	unlock, err := s.lock()
+	if err != nil {
+		return Reminder{}, err
+	}
+	defer unlock()
+	reminders, err := s.read()
+	if err != nil {
+		return Reminder{}, err
+	}
+	r.ID = newID()
+	if r.Created.IsZero() {
+		r.Created = s.now().UTC()
+	}
+	if err := s.write(append(reminders, r)); err != nil {
+		return Reminder{}, err
+	}
+	s.notify()
+	return r, nil
+}
+
+// Cancel removes the reminder with a given id, returning it.
+func (s *Store) Cancel(id string) (Reminder, error) {
+	unlock, err := s.lock()
+	if err != nil {
+		return Reminder{}, err
+	}
+	defer unlock()
+	reminders, err := s.read()
+	if err != nil {
+		return Reminder{}, err
+	}
+	for index, r := range reminders {
+		if r.ID == id {
+			if err := s.write(append(reminders[:index], reminders[index+1:]...)); err != nil {
+				return Reminder{}, err
+			}
+			s.notify()
+			return r, nil
+		}
+	}
+	return Reminder{}, fmt.Errorf("%w; %q", ErrReminderNotFound, id)
+}
+
+// Run sends reminders as they're due until the context is done.
+//
+// Reminders that were due while it wasn't running are sent once when it starts;
+// repeating reminders are then moved to their next time, and others are removed.
+// Errors sending reminders are passed to `onError`; one-off reminders that
+// failed are put back to be retried with a backoff, up to `MaxAttempts` times,
+// and repeating reminders are sent again at their next time.
+func (s *Store) Run(ctx context.Context, send func(context.Context, Reminder) error, onError func(Reminder, error)) error {
+	poll := s.PollInterval
+	if poll <= 0 {
+		poll = DefaultPollInterval
+	}
+	for {
+		due, next, err := s.takeDue()
+		if err != nil {
+			onError(Reminder{}, err)
+		}
+		for _, r := range due {
+			if err := send(ctx, r); err != nil {
+				retryAt, retryErr := s.retry(r)
+				switch {
+				case retryErr != nil:
+					onError(r, fmt.Errorf("%v; not retried; %w", err, retryErr))
+				case retryAt.IsZero():
+					onError(r, fmt.Errorf("%w; not retried", err))
+				default:
+					onError(r, fmt.Errorf("%w; retrying at %s", err, retryAt.Format(time.RFC3339)))
+				}
+			}
+		}
+		wait := poll
+		if !next.IsZero() {
+			if untilNext := next.Sub(s.now()); untilNext < wait {
+				wait = untilNext
+			}
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-s.changed:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// takeDue returns the reminders that are due, updating the file, and when the next one is due.
+func (s *Store) takeDue() (due []Reminder, next time.Time, err error) {
+	unlock, err := s.lock()
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	defer unlock()
+	reminders, err := s.read()
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	now := s.now()
+	var pending []Reminder
+	for _, r := range reminders {
+		if r.At.After(now) {
+			pending = append(pending, r)
+			continue
+		}
+		due = append(due, r)
+		if at := r.Next(now); !at.IsZero() {
+			r.At = at
+			pending = append(pending, r)
+		}
+	}
+	if len(due) > 0 {
+		// the file is written before sending so a crash doesn't send twice.
+		if err = s.write(pending); err != nil {
+			return nil, time.Time{}, err
+		}
+	}
+	for _, r := range pending {
+		if next.IsZero() || r.At.Before(next) {
+			next = r.At
+		}
+	}
+	return due, next, nil
+}
+
+// retry puts back a one-off reminder that failed to send, to be sent again
+// after a backoff, returning when; the zero time is returned if it repeats or
+// has failed too many times.
+func (s *Store) retry(r Reminder) (time.Time, error) {
+	if r.Every > 0 {
+		return time.Time{}, nil
+	}
+	r.Attempts++
+	if r.Attempts >= MaxAttempts {
+		return time.Time{}, nil
+	}
+	backoff := RetryBackoff << uint(r.Attempts-1)
+	if backoff > MaxRetryBackoff || backoff <= 0 {
+		backoff = MaxRetryBackoff
+	}
+	r.At = s.now().Add(backoff)
+
+	unlock, err := s.lock()
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer unlock()
+	reminders, err := s.read()
+	if err != nil {
+		return time.Time{}, err
+	}
+	if err := s.write(append(reminders, r)); err != nil {
+		return time.Time{}, err
+	}
+	s.notify()
+	return r.At, nil
+}
+
+func (s *Store) read() ([]Reminder, error) {
+	contents, err := os.ReadFile(s.Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var reminders []Reminder
+	if len(contents) > 0 {
+		if err := json.Unmarshal(contents, &reminders); err != nil {
+			return nil, fmt.Errorf("%s; %w", s.Path, err)
+		}
+	}
+	sort.SliceStable(reminders, func(i, j int) bool { return reminders[i].At.Before(reminders[j].At) })
+	return reminders, nil
+}
+
+func (s *Store) write(reminders []Reminder) error {
+	if reminders == nil {
+		reminders = []Reminder{}
+	}
+	contents, err := json.MarshalIndent(reminders, "", "  ")
+	if err != nil {
+		return err
+	}
+	return fileutil.WriteFileAtomic(s.Path, append(contents, '\n'), 0644)
+}
+
+// notify wakes `Run` so it sees a change.
+func (s *Store) notify() {
+	if s.changed == nil {
+		return
+	}
+	select {
+	case s.changed <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Store) now() time.Time {
+	if s.Now != nil {
+		return s.Now()
+	}
+	return time.Now()
+}
@@ -0,0 +1,218 @@
+package remind
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/wcharczuk/lametric/pkg/lametric"
+)
+
+func testReminder(at time.Time) Reminder {
+	return Reminder{
+		At:           at,
+		Notification: lametric.Notification{Model: lametric.NotificationModel{Frames: []lametric.Frame{{Text: "Stretch"}}}},
+	}
+}
+
+// TestStoreShared checks that stores sharing a file, like the server and the
+// `remind` command, don't lose each other's changes.
+func TestStoreShared(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reminders.json")
+	now := time.Date(2026, 11, 1, 9, 0, 0, 0, time.UTC)
+	server, cli := NewStore(path), NewStore(path)
+	server.Now = func() time.Time { return now }
+	cli.Now = server.Now
+
+	const adds = 20
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var taken []Reminder
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for index := 0; index < adds; index++ {
+			// half are due, half are later.
+			at := now.Add(-time.Minute)
+			if index%2 == 1 {
+				at = now.Add(time.Hour)
+			}
+			if _, err := cli.Add(testReminder(at)); err != nil {
+				t.Error(err)
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for index := 0; index < adds; index++ {
+			due, _, err := server.takeDue()
+			if err != nil {
+				t.Error(err)
+			}
+			mu.Lock()
+			taken = append(taken, due...)
+			mu.Unlock()
+		}
+	}()
+	wg.Wait()
+	due, next, err := server.takeDue()
+	if err != nil {
+		t.Fatal(err)
+	}
+	taken = append(taken, due...)
+
+	pending, err := cli.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(taken) != adds/2 || len(pending) != adds/2 {
+		t.Errorf("expected %d due and %d pending, got %d and %d", adds/2, adds/2, len(taken), len(pending))
+	}
+	if !next.Equal(now.Add(time.Hour)) {
+		t.Errorf("expected the next reminder at %v, got %v", now.Add(time.Hour), next)
+	}
+	seen := make(map[string]bool)
+	for _, r := range append(taken, pending...) {
+		if seen[r.ID] {
+			t.Errorf("reminder %s taken more than once", r.ID)
+		}
+		seen[r.ID] = true
+	}
+}
+
+func TestStoreRepeats(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "reminders.json"))
+	now := time.Date(2026, 11, 1, 9, 0, 0, 0, time.UTC)
+	store.Now = func() time.Time { return now }
+	r := testReminder(now.Add(-time.Minute))
+	r.Every = Duration(time.Hour)
+	added, err := store.Add(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	due, next, err := store.takeDue()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(due) != 1 || due[0].ID != added.ID {
+		t.Fatalf("expected the reminder to be due, got %+v", due)
+	}
+	if expect := now.Add(59 * time.Minute); !next.Equal(expect) {
+		t.Errorf("expected it to repeat at %v, got %v", expect, next)
+	}
+	if _, err := store.Cancel(added.ID); err != nil {
+		t.Fatal(err)
+	}
+	if reminders, _ := store.List(); len(reminders) != 0 {
+		t.Errorf("expected the reminder to be cancelled, got %+v", reminders)
+	}
+}
+
+func TestStoreRetries(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "reminders.json"))
+	store.PollInterval = time.Hour
+	var mu sync.Mutex
+	now := time.Date(2026, 11, 1, 9, 0, 0, 0, time.UTC)
+	store.Now = func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+	added, err := store.Add(testReminder(now.Add(-time.Minute)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sends := make(chan error, 1)
+	failures := make(chan error, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- store.Run(ctx, func(context.Context, Reminder) error {
+			return <-sends
+		}, func(_ Reminder, err error) {
+			failures <- err
+		})
+	}()
+
+	sends <- errors.New("connection refused")
+	if err := <-failures; err == nil {
+		t.Fatal("expected the failed send to be passed to onError")
+	}
+	reminders, err := store.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reminders) != 1 || reminders[0].ID != added.ID || reminders[0].Attempts != 1 || !reminders[0].At.Equal(now.Add(RetryBackoff)) {
+		t.Fatalf("expected the reminder to be retried at %v, got %+v", now.Add(RetryBackoff), reminders)
+	}
+
+	mu.Lock()
+	now = now.Add(RetryBackoff)
+	mu.Unlock()
+	sends <- nil
+	store.notify()
+	deadline := time.After(5 * time.Second)
+	for {
+		if reminders, _ := store.List(); len(reminders) == 0 {
+			break
+		}
+		select {
+		case <-deadline:
+			t.Fatal("expected the retried reminder to be sent and removed")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("expected run to stop when cancelled, got %v", err)
+	}
+}
+
+func TestStoreRetryLimits(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "reminders.json"))
+	now := time.Date(2026, 11, 1, 9, 0, 0, 0, time.UTC)
+	store.Now = func() time.Time { return now }
+
+	testCases := [...]struct {
+		Name     string
+		Every    Duration
+		Attempts int
+		Expect   time.Duration
+	}{
+		{Name: "first", Expect: RetryBackoff},
+		{Name: "third", Attempts: 2, Expect: 4 * RetryBackoff},
+		{Name: "capped", Attempts: MaxAttempts - 2, Expect: MaxRetryBackoff},
+		{Name: "too many", Attempts: MaxAttempts - 1},
+		{Name: "repeats", Every: Duration(time.Hour)},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			r := testReminder(now)
+			r.ID, r.Every, r.Attempts = tc.Name, tc.Every, tc.Attempts
+			retryAt, err := store.retry(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var expect time.Time
+			if tc.Expect > 0 {
+				expect = now.Add(tc.Expect)
+			}
+			if !retryAt.Equal(expect) {
+				t.Errorf("expected %v, actual %v", expect, retryAt)
+			}
+			reminders, _ := store.List()
+			var found bool
+			for _, stored := range reminders {
+				found = found || stored.ID == tc.Name
+			}
+			if found != (tc.Expect > 0) {
+				t.Errorf("expected stored %v, actual %v", tc.Expect > 0, found)
+			}
+		})
+	}
+}
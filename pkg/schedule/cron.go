@@ -0,0 +1,208 @@
+package schedule
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cron is a parsed cron expression.
+type Cron struct {
+	expr     string
+	location *time.Location
+
+	second, minute, hour, dom, month, dow uint64
+	// domStar and dowStar are if the day fields are unrestricted; if both
+	// are restricted a day matches either, as in standard cron.
+	domStar, dowStar bool
+}
+
+// Macros are the supported `@` expressions.
+var Macros = map[string]string{
+	"@yearly":   "0 0 0 1 1 *",
+	"@annually": "0 0 0 1 1 *",
+	"@monthly":  "0 0 0 1 * *",
+	"@weekly":   "0 0 0 * * 0",
+	"@daily":    "0 0 0 * * *",
+	"@midnight": "0 0 0 * * *",
+	"@hourly":   "0 0 * * * *",
+}
+
+var (
+	monthNames = map[string]int{"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6, "jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12}
+	dayNames   = map[string]int{"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6}
+)
+
+// ParseCron parses a cron expression in a given location (utc if nil).
+//
+// Expressions have six fields, `second minute hour day-of-month month day-of-week`,
+// or five without the seconds (which are then zero), or are one of the `Macros`.
+// Fields may be `*` (or `?` for the day fields), values, `a-b` ranges, `*/n` or `a-b/n`
+// steps and comma separated lists; months and days of the week may be names, and
+// sunday is either 0 or 7.
+func ParseCron(expr string, location *time.Location) (*Cron, error) {
+	if location == nil {
+		location = time.UTC
+	}
+	fields := strings.Fields(expr)
+	if len(fields) == 1 {
+		if macro, ok := Macros[strings.ToLower(fields[0])]; ok {
+			fields = strings.Fields(macro)
+		}
+	}
+	if len(fields) == 5 {
+		fields = append([]string{"0"}, fields...)
+	}
+	if len(fields) != 6 {
+		return nil, fmt.Errorf("%w; %q must have 5 or 6 fields", ErrCronInvalid, expr)
+	}
+	c := &Cron{expr: expr, location: location}
+	var err error
+	if c.second, err = parseField(fields[0], 0, 59, nil); err != nil {
+		return nil, fmt.Errorf("%w; %q seconds; %v", ErrCronInvalid, expr, err)
+	}
+	if c.minute, err = parseField(fields[1], 0, 59, nil); err != nil {
+		return nil, fmt.Errorf("%w; %q minutes; %v", ErrCronInvalid, expr, err)
+	}
+	if c.hour, err = parseField(fields[2], 0, 23, nil); err != nil {
+		return nil, fmt.Errorf("%w; %q hours; %v", ErrCronInvalid, expr, err)
+	}
+	if c.dom, err = parseField(fields[3], 1, 31, nil); err != nil {
+		return nil, fmt.Errorf("%w; %q day of month; %v", ErrCronInvalid, expr, err)
+	}
+	if c.month, err = parseField(fields[4], 1, 12, monthNames); err != nil {
+		return nil, fmt.Errorf("%w; %q month; %v", ErrCronInvalid, expr, err)
+	}
+	if c.dow, err = parseField(fields[5], 0, 7, dayNames); err != nil {
+		return nil, fmt.Errorf("%w; %q day of week; %v", ErrCronInvalid, expr, err)
+	}
+	// sunday is 0 or 7
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+	c.domStar = isStar(fields[3])
+	c.dowStar = isStar(fields[5])
+	return c, nil
+}
+
+// String returns the cron expression.
+func (c *Cron) String() string { return c.expr }
+
+// Location returns the location the expression is evaluated in.
+func (c *Cron) Location() *time.Location { return c.location }
+
+// Next returns the first time after a given time that matches the expression,
+// or the zero time if there isn't one within five years.
+//
+// Times that don't exist because of a daylight saving change are skipped, and
+// times that happen twice match twice.
+func (c *Cron) Next(after time.Time) time.Time {
+	t := after.In(c.location).Truncate(time.Second).Add(time.Second)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		year, month, day := t.Date()
+		hour, minute, second := t.Clock()
+		// the steps are from the wall clock fields rather than truncating the
+		// absolute time, as offsets aren't always whole hours; a step across a
+		// daylight saving change lands wherever the clock went.
+		switch {
+		case c.month&(1<<uint(month)) == 0:
+			t = startOfDay(year, month+1, 1, c.location)
+		case !c.dayMatches(t):
+			t = startOfDay(year, month, day+1, c.location)
+		case c.hour&(1<<uint(hour)) == 0:
+			t = t.Add(time.Duration(60-minute)*time.Minute - time.Duration(second)*time.Second)
+		case c.minute&(1<<uint(minute)) == 0:
+			t = t.Add(time.Duration(60-second) * time.Second)
+		case c.second&(1<<uint(second)) == 0:
+			t = t.Add(time.Second)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// startOfDay returns the first time on a given day in a location, which is
+// later than midnight if a daylight saving change skips it.
+func startOfDay(year int, month time.Month, day int, location *time.Location) time.Time {
+	midnight := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	// offsets are between -12 and +14 hours, so the day starts within that of midnight utc.
+	from := midnight.Add(-14 * time.Hour)
+	seconds := sort.Search(int(26*time.Hour/time.Second), func(index int) bool {
+		year, month, day := from.Add(time.Duration(index) * time.Second).In(location).Date()
+		return !time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Before(midnight)
+	})
+	return from.Add(time.Duration(seconds) * time.Second).In(location)
+}
+
+func (c *Cron) dayMatches(t time.Time) bool {
+	domMatch := c.dom&(1<<uint(t.Day())) != 0
+	dowMatch := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.domStar || c.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+func isStar(field string) bool {
+	return field == "*" || field == "?" || strings.HasPrefix(field, "*/")
+}
+
+// parseField parses a cron field into a bit set of the values it matches.
+func parseField(field string, min, max int, names map[string]int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, step := part, 1
+		if index := strings.IndexByte(part, '/'); index >= 0 {
+			var err error
+			if step, err = strconv.Atoi(part[index+1:]); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step %q", part)
+			}
+			rangePart = part[:index]
+		}
+		start, end := min, max
+		switch {
+		case rangePart == "*" || rangePart == "?":
+		case strings.Contains(rangePart, "-"):
+			index := strings.IndexByte(rangePart, '-')
+			var err error
+			if start, err = parseValue(rangePart[:index], names); err != nil {
+				return 0, err
+			}
+			if end, err = parseValue(rangePart[index+1:], names); err != nil {
+				return 0, err
+			}
+		default:
+			value, err := parseValue(rangePart, names)
+			if err != nil {
+				return 0, err
+			}
+			start, end = value, value
+			if step > 1 {
+				// `n/step` is from n to the max
+				end = max
+			}
+		}
+		if start < min || end > max || start > end {
+			return 0, fmt.Errorf("%q out of range %d-%d", part, min, max)
+		}
+		for value := start; value <= end; value += step {
+			bits |= 1 << uint(value)
+		}
+	}
+	return bits, nil
+}
+
+func parseValue(value string, names map[string]int) (int, error) {
+	if named, ok := names[strings.ToLower(value)]; ok {
+		return named, nil
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", value)
+	}
+	return parsed, nil
+}
@@ -0,0 +1,169 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func mustLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("time zone %s not available; %v", name, err)
+	}
+	return location
+}
+
+func TestCronNext(t *testing.T) {
+	testCases := [...]struct {
+		Name     string
+		Location string
+		Expr     string
+		After    string
+		// Expected are the successive next times, in RFC3339 with the location offset.
+		Expected []string
+	}{
+		{
+			Name:     "hourly half hour offset",
+			Location: "Asia/Kolkata",
+			Expr:     "@hourly",
+			After:    "2026-11-01T10:15:00+05:30",
+			Expected: []string{"2026-11-01T11:00:00+05:30", "2026-11-01T12:00:00+05:30"},
+		},
+		{
+			Name:     "daily half hour offset",
+			Location: "Asia/Kolkata",
+			Expr:     "0 9 * * *",
+			After:    "2026-11-01T10:15:00+05:30",
+			Expected: []string{"2026-11-02T09:00:00+05:30", "2026-11-03T09:00:00+05:30"},
+		},
+		{
+			Name:     "quarter hours quarter hour offset",
+			Location: "Asia/Kathmandu",
+			Expr:     "*/15 * * * *",
+			After:    "2026-11-01T23:50:00+05:45",
+			Expected: []string{"2026-11-02T00:00:00+05:45", "2026-11-02T00:15:00+05:45"},
+		},
+		{
+			Name:     "skipped by the gap",
+			Location: "America/New_York",
+			Expr:     "30 2 * * *",
+			After:    "2026-03-07T12:00:00-05:00",
+			Expected: []string{"2026-03-09T02:30:00-04:00"},
+		},
+		{
+			Name:     "hourly across the gap",
+			Location: "America/New_York",
+			Expr:     "@hourly",
+			After:    "2026-03-08T00:30:00-05:00",
+			Expected: []string{"2026-03-08T01:00:00-05:00", "2026-03-08T03:00:00-04:00", "2026-03-08T04:00:00-04:00"},
+		},
+		{
+			Name:     "twice in the overlap",
+			Location: "America/New_York",
+			Expr:     "30 1 * * *",
+			After:    "2026-10-31T12:00:00-04:00",
+			Expected: []string{"2026-11-01T01:30:00-04:00", "2026-11-01T01:30:00-05:00", "2026-11-02T01:30:00-05:00"},
+		},
+		{
+			Name:     "in order across the overlap",
+			Location: "America/New_York",
+			Expr:     "*/20 1 * * *",
+			After:    "2026-11-01T00:50:00-04:00",
+			Expected: []string{
+				"2026-11-01T01:00:00-04:00", "2026-11-01T01:20:00-04:00", "2026-11-01T01:40:00-04:00",
+				"2026-11-01T01:00:00-05:00", "2026-11-01T01:20:00-05:00", "2026-11-01T01:40:00-05:00",
+				"2026-11-02T01:00:00-05:00",
+			},
+		},
+		{
+			Name:     "midnight skipped by the gap",
+			Location: "America/Havana",
+			Expr:     "@daily",
+			After:    "2026-03-07T12:00:00-05:00",
+			Expected: []string{"2026-03-09T00:00:00-04:00"},
+		},
+		{
+			Name:     "first hour of a day that skips midnight",
+			Location: "America/Havana",
+			Expr:     "0 1 * * *",
+			After:    "2026-03-07T12:00:00-05:00",
+			Expected: []string{"2026-03-08T01:00:00-04:00", "2026-03-09T01:00:00-04:00"},
+		},
+		{
+			Name:     "day of month or day of week",
+			Location: "UTC",
+			Expr:     "0 0 13 * fri",
+			After:    "2026-10-09T00:00:00Z",
+			Expected: []string{"2026-10-13T00:00:00Z", "2026-10-16T00:00:00Z", "2026-10-23T00:00:00Z"},
+		},
+		{
+			Name:     "day of month only",
+			Location: "UTC",
+			Expr:     "0 0 13 * *",
+			After:    "2026-10-09T00:00:00Z",
+			Expected: []string{"2026-10-13T00:00:00Z", "2026-11-13T00:00:00Z"},
+		},
+		{
+			Name:     "day of week only",
+			Location: "UTC",
+			Expr:     "0 0 ? * 5",
+			After:    "2026-10-09T00:00:00Z",
+			Expected: []string{"2026-10-16T00:00:00Z", "2026-10-23T00:00:00Z"},
+		},
+		{
+			Name:     "stepped day of month and day of week",
+			Location: "UTC",
+			Expr:     "0 0 */2 * fri",
+			After:    "2026-10-01T00:00:00Z",
+			Expected: []string{"2026-10-09T00:00:00Z", "2026-10-23T00:00:00Z"},
+		},
+		{
+			Name:     "seconds",
+			Location: "UTC",
+			Expr:     "*/30 * * * * *",
+			After:    "2026-10-01T00:00:10.5Z",
+			Expected: []string{"2026-10-01T00:00:30Z", "2026-10-01T00:01:00Z"},
+		},
+		{
+			Name:     "never",
+			Location: "UTC",
+			Expr:     "0 0 30 2 *",
+			After:    "2026-10-01T00:00:00Z",
+			Expected: []string{"0001-01-01T00:00:00Z"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			cron, err := ParseCron(tc.Expr, mustLocation(t, tc.Location))
+			if err != nil {
+				t.Fatal(err)
+			}
+			after, err := time.Parse(time.RFC3339, tc.After)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, expected := range tc.Expected {
+				next := cron.Next(after)
+				if actual := next.Format(time.RFC3339); actual != expected {
+					t.Fatalf("after %s; expected %s, actual %s", after.In(cron.Location()).Format(time.RFC3339), expected, actual)
+				}
+				after = next
+			}
+		})
+	}
+}
+
+func TestParseCron(t *testing.T) {
+	for _, expr := range []string{"0 0 13 * fri", "0 */5 9-17 * * mon-fri", "@weekly", "0 0 1 jan,jul sun"} {
+		if _, err := ParseCron(expr, nil); err != nil {
+			t.Errorf("%q; %v", expr, err)
+		}
+	}
+	for _, expr := range []string{"", "* * *", "60 * * * *", "0 0 0 32 * *", "0 0 * * mon-sun", "*/0 * * * *", "@fortnightly"} {
+		if _, err := ParseCron(expr, nil); err == nil {
+			t.Errorf("%q; expected an error", expr)
+		}
+	}
+}
@@ -0,0 +1,13 @@
+package schedule
+
+// Error is an error constant.
+type Error string
+
+// Error implements error.
+func (e Error) Error() string { return string(e) }
+
+// Common Errors
+const (
+	ErrCronInvalid    Error = "invalid cron expression"
+	ErrMisfireUnknown Error = "unknown misfire policy"
+)
@@ -0,0 +1,64 @@
+package schedule
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MisfirePolicy is what's done with runs that were missed while the scheduler
+// wasn't running, e.g. after downtime.
+type MisfirePolicy string
+
+// Misfire Policies
+const (
+	// MisfireOnce runs the latest missed run once, immediately.
+	MisfireOnce MisfirePolicy = "once"
+	// MisfireSkip drops missed runs.
+	MisfireSkip MisfirePolicy = "skip"
+	// MisfireAll runs each missed run, oldest first, immediately.
+	MisfireAll MisfirePolicy = "all"
+)
+
+// AllMisfirePolicies returns the valid misfire policies.
+func AllMisfirePolicies() []MisfirePolicy {
+	return []MisfirePolicy{
+		MisfireOnce,
+		MisfireSkip,
+		MisfireAll,
+	}
+}
+
+// ParseMisfirePolicy parses a misfire policy, returning an error if it's unknown.
+func ParseMisfirePolicy(value string) (MisfirePolicy, error) {
+	for _, policy := range AllMisfirePolicies() {
+		if string(policy) == value {
+			return policy, nil
+		}
+	}
+	return "", fmt.Errorf("%w; %q is not one of %v", ErrMisfireUnknown, value, AllMisfirePolicies())
+}
+
+// String implements fmt.Stringer.
+func (mp MisfirePolicy) String() string { return string(mp) }
+
+// MarshalText implements encoding.TextMarshaler.
+func (mp MisfirePolicy) MarshalText() ([]byte, error) { return []byte(mp), nil }
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (mp *MisfirePolicy) UnmarshalText(text []byte) (err error) {
+	*mp, err = ParseMisfirePolicy(string(text))
+	return
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (mp *MisfirePolicy) UnmarshalYAML(node *yaml.Node) error {
+	var value string
+	if err := node.Decode(&value); err != nil {
+		return err
+	}
+	if err := mp.UnmarshalText([]byte(value)); err != nil {
+		return fmt.Errorf("line %d: %w", node.Line, err)
+	}
+	return nil
+}
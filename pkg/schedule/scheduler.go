@@ -0,0 +1,164 @@
+package schedule
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// DefaultMaxMisfires is the default maximum number of missed runs run with `MisfireAll`.
+const DefaultMaxMisfires = 10
+
+// Job is a function run on a cron schedule.
+type Job struct {
+	Name string
+	Cron *Cron
+	// Jitter, if set, delays each run by a random duration up to it, so
+	// jobs on the same schedule don't all run at once.
+	Jitter time.Duration
+	// Misfire is what's done with runs missed while the scheduler wasn't
+	// running; defaults to `MisfireOnce`.
+	Misfire MisfirePolicy
+	// MisfireGrace, if set, is how late a missed run can be and still be run.
+	MisfireGrace time.Duration
+	// Run is called with the time the run was scheduled for.
+	Run func(ctx context.Context, scheduled time.Time) error
+}
+
+// Scheduler runs jobs on their schedules.
+type Scheduler struct {
+	Jobs []Job
+	// Store, if set, persists the last run of each job so missed runs are
+	// found on start; without it missed runs can't be detected.
+	Store Store
+	// MaxMisfires is the maximum number of missed runs run with `MisfireAll`,
+	// keeping the latest; defaults to ten.
+	MaxMisfires int
+	// OnError is called with errors running jobs or saving their last runs.
+	OnError func(job string, err error)
+	// Now returns the current time; defaults to `time.Now`.
+	Now func() time.Time
+
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+// Run runs the jobs until the context is done.
+func (s *Scheduler) Run(ctx context.Context) error {
+	wg := sync.WaitGroup{}
+	wg.Add(len(s.Jobs))
+	for x := 0; x < len(s.Jobs); x++ {
+		go func(job Job) {
+			defer wg.Done()
+			s.runJob(ctx, job)
+		}(s.Jobs[x])
+	}
+	wg.Wait()
+	return ctx.Err()
+}
+
+// Misfires returns the runs of a job missed between its last run and now that
+// should be run given its misfire policy, oldest first.
+func (s *Scheduler) Misfires(job Job, lastRun, now time.Time) []time.Time {
+	if job.Misfire == MisfireSkip {
+		return nil
+	}
+	max := s.MaxMisfires
+	if max <= 0 {
+		max = DefaultMaxMisfires
+	}
+	if job.Misfire != MisfireAll {
+		max = 1
+	}
+	var missed []time.Time
+	for next := job.Cron.Next(lastRun); !next.IsZero() && !next.After(now); next = job.Cron.Next(next) {
+		if job.MisfireGrace > 0 && now.Sub(next) > job.MisfireGrace {
+			continue
+		}
+		missed = append(missed, next)
+		if len(missed) > max {
+			missed = missed[1:]
+		}
+	}
+	return missed
+}
+
+func (s *Scheduler) runJob(ctx context.Context, job Job) {
+	now := s.now()
+	lastRun, ok := s.lastRun(job.Name)
+	if ok && lastRun.Before(now) {
+		for _, missed := range s.Misfires(job, lastRun, now) {
+			if ctx.Err() != nil {
+				return
+			}
+			s.run(ctx, job, missed)
+		}
+	}
+	s.setLastRun(job.Name, now)
+	lastRun = now
+
+	for {
+		next := job.Cron.Next(lastRun)
+		if next.IsZero() {
+			return
+		}
+		timer := time.NewTimer(next.Add(s.jitter(job.Jitter)).Sub(s.now()))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		s.run(ctx, job, next)
+		s.setLastRun(job.Name, next)
+		lastRun = next
+	}
+}
+
+func (s *Scheduler) run(ctx context.Context, job Job, scheduled time.Time) {
+	if err := job.Run(ctx, scheduled); err != nil {
+		s.onError(job.Name, err)
+	}
+}
+
+func (s *Scheduler) lastRun(job string) (time.Time, bool) {
+	if s.Store == nil {
+		return time.Time{}, false
+	}
+	return s.Store.LastRun(job)
+}
+
+func (s *Scheduler) setLastRun(job string, t time.Time) {
+	if s.Store == nil {
+		return
+	}
+	if err := s.Store.SetLastRun(job, t); err != nil {
+		s.onError(job, err)
+	}
+}
+
+func (s *Scheduler) onError(job string, err error) {
+	if s.OnError != nil {
+		s.OnError(job, err)
+	}
+}
+
+func (s *Scheduler) now() time.Time {
+	if s.Now != nil {
+		return s.Now()
+	}
+	return time.Now()
+}
+
+func (s *Scheduler) jitter(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.rnd == nil {
+		s.rnd = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	return time.Duration(s.rnd.Int63n(int64(max)))
+}
@@ -0,0 +1,68 @@
+package schedule
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/wcharczuk/lametric/pkg/fileutil"
+)
+
+// Store persists the time each job's runs are accounted for up to, so runs
+// missed while the scheduler wasn't running can be found on start.
+type Store interface {
+	LastRun(job string) (time.Time, bool)
+	SetLastRun(job string, t time.Time) error
+}
+
+// NewFileStore returns a store backed by a json file, reading it if it exists.
+func NewFileStore(path string) (*FileStore, error) {
+	store := &FileStore{Path: path, runs: make(map[string]time.Time)}
+	contents, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(contents) > 0 {
+		if err := json.Unmarshal(contents, &store.runs); err != nil {
+			return nil, err
+		}
+	}
+	return store, nil
+}
+
+// FileStore is a store backed by a json file of job names to times.
+type FileStore struct {
+	Path string
+
+	mu   sync.Mutex
+	runs map[string]time.Time
+}
+
+// LastRun implements Store.
+func (fs *FileStore) LastRun(job string) (time.Time, bool) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	t, ok := fs.runs[job]
+	return t, ok
+}
+
+// SetLastRun implements Store, writing the file.
+func (fs *FileStore) SetLastRun(job string, t time.Time) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if fs.runs == nil {
+		fs.runs = make(map[string]time.Time)
+	}
+	fs.runs[job] = t.UTC()
+	contents, err := json.MarshalIndent(fs.runs, "", "  ")
+	if err != nil {
+		return err
+	}
+	return fileutil.WriteFileAtomic(fs.Path, append(contents, '\n'), 0644)
+}
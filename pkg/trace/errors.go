@@ -0,0 +1,12 @@
+package trace
+
+// Error is an error constant.
+type Error string
+
+// Error implements error.
+func (e Error) Error() string { return string(e) }
+
+// Common Errors
+const (
+	ErrTraceparentInvalid Error = "invalid traceparent"
+)
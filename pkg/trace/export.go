@@ -0,0 +1,164 @@
+package trace
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// StdoutExporter writes a line per span to a writer, os.Stdout by default.
+type StdoutExporter struct {
+	W io.Writer
+
+	mu sync.Mutex
+}
+
+// Export implements Exporter.
+func (se *StdoutExporter) Export(s *Span) error {
+	w := se.W
+	if w == nil {
+		w = os.Stdout
+	}
+	line := fmt.Sprintf("trace=%s span=%s", s.Context.TraceID, s.Context.SpanID)
+	if !s.ParentID.IsZero() {
+		line += fmt.Sprintf(" parent=%s", s.ParentID)
+	}
+	line += fmt.Sprintf(" name=%q duration=%v", s.Name, s.End.Sub(s.Start).Round(time.Microsecond))
+	for _, key := range sortedKeys(s.Attributes) {
+		line += fmt.Sprintf(" %s=%q", key, s.Attributes[key])
+	}
+	if s.Err != nil {
+		line += fmt.Sprintf(" error=%q", s.Err.Error())
+	}
+	se.mu.Lock()
+	defer se.mu.Unlock()
+	_, err := fmt.Fprintln(w, line)
+	return err
+}
+
+// FileExporter appends each span to a file as a line of otlp json, the
+// format of the opentelemetry collector file exporter.
+type FileExporter struct {
+	Path string
+	// Service is the `service.name` resource attribute.
+	Service string
+
+	mu sync.Mutex
+}
+
+// Export implements Exporter.
+func (fe *FileExporter) Export(s *Span) error {
+	contents, err := json.Marshal(otlpTracesData(fe.Service, s))
+	if err != nil {
+		return err
+	}
+	fe.mu.Lock()
+	defer fe.mu.Unlock()
+	f, err := os.OpenFile(fe.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(contents, '\n')); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// otlp json types, see opentelemetry-proto `trace/v1/trace.proto`.
+type (
+	otlpData struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+	otlpResourceSpans struct {
+		Resource   otlpResource     `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	}
+	otlpResource struct {
+		Attributes []otlpKeyValue `json:"attributes"`
+	}
+	otlpScopeSpans struct {
+		Scope otlpScope  `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+	otlpScope struct {
+		Name string `json:"name"`
+	}
+	otlpSpan struct {
+		TraceID           string         `json:"traceId"`
+		SpanID            string         `json:"spanId"`
+		ParentSpanID      string         `json:"parentSpanId,omitempty"`
+		Name              string         `json:"name"`
+		Kind              SpanKind       `json:"kind"`
+		StartTimeUnixNano string         `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+		Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+		Status            otlpStatus     `json:"status"`
+	}
+	otlpKeyValue struct {
+		Key   string    `json:"key"`
+		Value otlpValue `json:"value"`
+	}
+	otlpValue struct {
+		StringValue string `json:"stringValue"`
+	}
+	otlpStatus struct {
+		Code    int    `json:"code,omitempty"`
+		Message string `json:"message,omitempty"`
+	}
+)
+
+// otlp status codes.
+const (
+	otlpStatusOK    = 1
+	otlpStatusError = 2
+)
+
+func otlpTracesData(service string, s *Span) otlpData {
+	if service == "" {
+		service = "notifier"
+	}
+	span := otlpSpan{
+		TraceID:           s.Context.TraceID.String(),
+		SpanID:            s.Context.SpanID.String(),
+		Name:              s.Name,
+		Kind:              s.Kind,
+		StartTimeUnixNano: strconv.FormatInt(s.Start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(s.End.UnixNano(), 10),
+		Status:            otlpStatus{Code: otlpStatusOK},
+	}
+	if !s.ParentID.IsZero() {
+		span.ParentSpanID = s.ParentID.String()
+	}
+	for _, key := range sortedKeys(s.Attributes) {
+		span.Attributes = append(span.Attributes, otlpKeyValue{Key: key, Value: otlpValue{StringValue: s.Attributes[key]}})
+	}
+	if s.Err != nil {
+		span.Status = otlpStatus{Code: otlpStatusError, Message: s.Err.Error()}
+	}
+	return otlpData{
+		ResourceSpans: []otlpResourceSpans{{
+			Resource: otlpResource{
+				Attributes: []otlpKeyValue{{Key: "service.name", Value: otlpValue{StringValue: service}}},
+			},
+			ScopeSpans: []otlpScopeSpans{{
+				Scope: otlpScope{Name: "github.com/wcharczuk/lametric/pkg/trace"},
+				Spans: []otlpSpan{span},
+			}},
+		}},
+	}
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,80 @@
+package trace
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestFileExporter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traces.jsonl")
+	exporter := &FileExporter{Path: path, Service: "test"}
+	tracer := &Tracer{Exporters: []Exporter{exporter}}
+
+	ctx, root := tracer.Start(context.Background(), "notify", KindInternal)
+	_, child := tracer.Start(ctx, "deliver", KindClient)
+	child.SetAttribute("device", "kitchen")
+	child.SetAttribute("attempt", 2)
+	child.Finish(errors.New("connection refused"))
+	root.Finish(nil)
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var lines []otlpData
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// the generic shape is checked, rather than the types that wrote it.
+		var generic map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &generic); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := generic["resourceSpans"].([]interface{}); !ok {
+			t.Fatalf("expected resourceSpans, actual %s", scanner.Text())
+		}
+		var line otlpData
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatal(err)
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) != 2 {
+		t.Fatalf("expected a line per span, actual %d", len(lines))
+	}
+
+	resource := lines[0].ResourceSpans[0]
+	if kv := resource.Resource.Attributes; len(kv) != 1 || kv[0].Key != "service.name" || kv[0].Value.StringValue != "test" {
+		t.Errorf("expected the service name resource attribute, actual %+v", kv)
+	}
+	if name := resource.ScopeSpans[0].Scope.Name; name != "github.com/wcharczuk/lametric/pkg/trace" {
+		t.Errorf("expected the scope name, actual %q", name)
+	}
+	span := resource.ScopeSpans[0].Spans[0]
+	if span.TraceID != root.Context.TraceID.String() || span.SpanID != child.Context.SpanID.String() || span.ParentSpanID != root.Context.SpanID.String() {
+		t.Errorf("expected the ids as hex, actual %+v", span)
+	}
+	if span.Name != "deliver" || span.Kind != KindClient {
+		t.Errorf("expected the name and kind, actual %+v", span)
+	}
+	if span.StartTimeUnixNano != strconv.FormatInt(child.Start.UnixNano(), 10) || span.EndTimeUnixNano != strconv.FormatInt(child.End.UnixNano(), 10) {
+		t.Errorf("expected the times as unix nano strings, actual %s %s", span.StartTimeUnixNano, span.EndTimeUnixNano)
+	}
+	if len(span.Attributes) != 2 || span.Attributes[0].Key != "attempt" || span.Attributes[0].Value.StringValue != "2" || span.Attributes[1].Key != "device" {
+		t.Errorf("expected the attributes sorted by key, actual %+v", span.Attributes)
+	}
+	if span.Status.Code != otlpStatusError || span.Status.Message != "connection refused" {
+		t.Errorf("expected an error status, actual %+v", span.Status)
+	}
+
+	rootSpan := lines[1].ResourceSpans[0].ScopeSpans[0].Spans[0]
+	if rootSpan.ParentSpanID != "" || rootSpan.Status.Code != otlpStatusOK {
+		t.Errorf("expected a root span with an ok status, actual %+v", rootSpan)
+	}
+}
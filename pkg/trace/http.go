@@ -0,0 +1,56 @@
+package trace
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// Middleware is a round tripper middleware (e.g. for `apiutil.OptMiddleware`) that
+// starts a client span for each request made within a trace, and propagates
+// the trace to the remote with a `traceparent` header.
+//
+// Requests made outside of a trace are passed through as is.
+func Middleware(next http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if _, ok := spanContextFrom(req.Context()); !ok {
+			return next.RoundTrip(req)
+		}
+		ctx, span := Start(req.Context(), spanName(req.Method), KindClient)
+		span.SetAttribute("http.method", req.Method)
+		span.SetAttribute("http.url", req.URL.Redacted())
+		req = req.Clone(ctx)
+		req.Header.Set(HeaderTraceparent, span.Context.Traceparent())
+		res, err := next.RoundTrip(req)
+		if res != nil {
+			span.SetAttribute("http.status_code", res.StatusCode)
+			if err == nil && res.StatusCode >= http.StatusInternalServerError {
+				span.Finish(errors.New(res.Status))
+				return res, err
+			}
+		}
+		span.Finish(err)
+		return res, err
+	})
+}
+
+// Extract returns a request context with the remote parent span from the
+// request `traceparent` header, if it's valid.
+func Extract(req *http.Request) *http.Request {
+	sc, err := ParseTraceparent(req.Header.Get(HeaderTraceparent))
+	if err != nil {
+		return req
+	}
+	return req.WithContext(WithRemote(req.Context(), sc))
+}
+
+// spanName returns a span name for an http method.
+func spanName(method string) string {
+	return "HTTP " + strings.ToUpper(method)
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (rtf roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return rtf(req)
+}
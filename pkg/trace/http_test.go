@@ -0,0 +1,73 @@
+package trace
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	spans := new(recorder)
+	previous := Default()
+	SetDefault(&Tracer{Exporters: []Exporter{spans}})
+	defer SetDefault(previous)
+
+	var received []string
+	var remote SpanContext
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		received = append(received, req.Header.Get(HeaderTraceparent))
+		req = Extract(req)
+		remote, _ = spanContextFrom(req.Context())
+		rw.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+	client := &http.Client{Transport: Middleware(http.DefaultTransport)}
+
+	// outside a trace requests are passed through as is.
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/status", nil)
+	res, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if received[0] != "" || len(spans.spans) != 0 {
+		t.Errorf("expected no traceparent or spans outside a trace, actual %q %d", received[0], len(spans.spans))
+	}
+
+	ctx, root := Start(context.Background(), "notify", KindInternal)
+	req, _ = http.NewRequestWithContext(ctx, http.MethodPost, server.URL+"/notify", nil)
+	res, err = client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	root.Finish(nil)
+	if req.Header.Get(HeaderTraceparent) != "" {
+		t.Errorf("expected the caller's request not to be changed")
+	}
+	if len(spans.spans) != 2 {
+		t.Fatalf("expected a client span and the root span, actual %d", len(spans.spans))
+	}
+	span := spans.spans[0]
+	if span.Name != "HTTP POST" || span.Kind != KindClient || span.ParentID != root.Context.SpanID || span.Context.TraceID != root.Context.TraceID {
+		t.Errorf("expected a client span child of the root, actual %+v", span)
+	}
+	if received[1] != span.Context.Traceparent() || remote != span.Context {
+		t.Errorf("expected the client span to be propagated, actual %q", received[1])
+	}
+	if span.Attributes["http.status_code"] != "502" || span.Err == nil {
+		t.Errorf("expected a server error to fail the span, actual %v %v", span.Attributes, span.Err)
+	}
+	if url := span.Attributes["http.url"]; url != server.URL+"/notify" {
+		t.Errorf("expected the url attribute, actual %q", url)
+	}
+}
+
+func TestExtractInvalid(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(HeaderTraceparent, "00-00000000000000000000000000000000-00f067aa0ba902b7-01")
+	if _, ok := spanContextFrom(Extract(req).Context()); ok {
+		t.Errorf("expected an invalid traceparent to be ignored")
+	}
+}
@@ -0,0 +1,219 @@
+package trace
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HeaderTraceparent is the w3c trace context header.
+const HeaderTraceparent = "traceparent"
+
+// TraceID is a trace id.
+type TraceID [16]byte
+
+// String returns the id as hex.
+func (id TraceID) String() string { return hex.EncodeToString(id[:]) }
+
+// IsZero returns if the id is unset.
+func (id TraceID) IsZero() bool { return id == TraceID{} }
+
+// SpanID is a span id.
+type SpanID [8]byte
+
+// String returns the id as hex.
+func (id SpanID) String() string { return hex.EncodeToString(id[:]) }
+
+// IsZero returns if the id is unset.
+func (id SpanID) IsZero() bool { return id == SpanID{} }
+
+// SpanContext identifies a span within a trace.
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+}
+
+// Traceparent returns the span context as a w3c `traceparent` header value.
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return fmt.Sprintf("00-%s-%s-%s", sc.TraceID, sc.SpanID, flags)
+}
+
+// ParseTraceparent parses a w3c `traceparent` header value.
+func ParseTraceparent(value string) (SpanContext, error) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return SpanContext{}, fmt.Errorf("%w; %q", ErrTraceparentInvalid, value)
+	}
+	var sc SpanContext
+	var flags [1]byte
+	if !decodeHex(sc.TraceID[:], parts[1]) || !decodeHex(sc.SpanID[:], parts[2]) || !decodeHex(flags[:], parts[3]) {
+		return SpanContext{}, fmt.Errorf("%w; %q", ErrTraceparentInvalid, value)
+	}
+	if sc.TraceID.IsZero() || sc.SpanID.IsZero() {
+		return SpanContext{}, fmt.Errorf("%w; %q", ErrTraceparentInvalid, value)
+	}
+	sc.Sampled = flags[0]&0x01 != 0
+	return sc, nil
+}
+
+// decodeHex decodes lowercase hex of exactly the output length.
+func decodeHex(output []byte, value string) bool {
+	if len(value) != hex.EncodedLen(len(output)) || strings.ToLower(value) != value {
+		return false
+	}
+	_, err := hex.Decode(output, []byte(value))
+	return err == nil
+}
+
+// SpanKind is the kind of a span.
+type SpanKind int
+
+// Span kinds, numbered as in otlp.
+const (
+	KindInternal SpanKind = 1
+	KindServer   SpanKind = 2
+	KindClient   SpanKind = 3
+	KindConsumer SpanKind = 5
+)
+
+// Span is a timed stage of a trace.
+type Span struct {
+	Name       string
+	Kind       SpanKind
+	Context    SpanContext
+	ParentID   SpanID
+	Start      time.Time
+	End        time.Time
+	Attributes map[string]string
+	// Err is the error the span ended with, if any.
+	Err error
+
+	tracer *Tracer
+	mu     sync.Mutex
+	ended  bool
+}
+
+// SetAttribute sets an attribute of the span.
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Attributes == nil {
+		s.Attributes = make(map[string]string)
+	}
+	s.Attributes[key] = fmt.Sprint(value)
+}
+
+// Finish ends the span with an error (which may be nil) and exports it.
+//
+// Spans are only exported once; later calls are ignored.
+func (s *Span) Finish(err error) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended, s.End, s.Err = true, time.Now(), err
+	s.mu.Unlock()
+	if s.Context.Sampled {
+		s.tracer.export(s)
+	}
+}
+
+// Exporter exports ended spans.
+type Exporter interface {
+	Export(*Span) error
+}
+
+// Tracer starts spans and exports them when they end.
+type Tracer struct {
+	// Service is the name of the service spans are exported for.
+	Service   string
+	Exporters []Exporter
+	// OnError is called if an exporter fails.
+	OnError func(error)
+}
+
+func (t *Tracer) export(s *Span) {
+	for _, exporter := range t.Exporters {
+		if err := exporter.Export(s); err != nil && t.OnError != nil {
+			t.OnError(err)
+		}
+	}
+}
+
+// Start starts a span as a child of the span in a given context, or
+// as the root of a new trace, and returns a context with the new span.
+func (t *Tracer) Start(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	span := &Span{Name: name, Kind: kind, Start: time.Now(), tracer: t}
+	if parent, ok := spanContextFrom(ctx); ok {
+		span.Context.TraceID, span.ParentID, span.Context.Sampled = parent.TraceID, parent.SpanID, parent.Sampled
+	} else {
+		_, _ = rand.Read(span.Context.TraceID[:])
+		span.Context.Sampled = true
+	}
+	_, _ = rand.Read(span.Context.SpanID[:])
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+var (
+	defaultTracerMu sync.Mutex
+	defaultTracer   = &Tracer{Service: "notifier"}
+)
+
+// SetDefault sets the default tracer.
+func SetDefault(t *Tracer) {
+	defaultTracerMu.Lock()
+	defaultTracer = t
+	defaultTracerMu.Unlock()
+}
+
+// Default returns the default tracer, which has no exporters unless one is set.
+func Default() *Tracer {
+	defaultTracerMu.Lock()
+	defer defaultTracerMu.Unlock()
+	return defaultTracer
+}
+
+// Start starts a span with the default tracer; see `Tracer.Start`.
+func Start(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	return Default().Start(ctx, name, kind)
+}
+
+type spanKey struct{}
+type remoteKey struct{}
+
+// FromContext returns the span in a context, or nil.
+func FromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// WithRemote returns a context with a remote parent span, e.g. from an inbound
+// `traceparent` header, that spans started from the context are children of.
+func WithRemote(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, remoteKey{}, sc)
+}
+
+// spanContextFrom returns the span context of the span in a context, or the remote parent.
+func spanContextFrom(ctx context.Context) (SpanContext, bool) {
+	if span := FromContext(ctx); span != nil {
+		return span.Context, true
+	}
+	sc, ok := ctx.Value(remoteKey{}).(SpanContext)
+	return sc, ok
+}
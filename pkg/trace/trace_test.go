@@ -0,0 +1,103 @@
+package trace
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	testCases := [...]struct {
+		Name    string
+		Value   string
+		Sampled bool
+		Expect  error
+	}{
+		{Name: "sampled", Value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", Sampled: true},
+		{Name: "not sampled", Value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00"},
+		{Name: "future version", Value: "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", Sampled: true},
+		{Name: "whitespace", Value: " 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01 ", Sampled: true},
+		{Name: "empty", Value: "", Expect: ErrTraceparentInvalid},
+		{Name: "version ff", Value: "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", Expect: ErrTraceparentInvalid},
+		{Name: "version 00 extra", Value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", Expect: ErrTraceparentInvalid},
+		{Name: "version length", Value: "000-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", Expect: ErrTraceparentInvalid},
+		{Name: "zero trace id", Value: "00-00000000000000000000000000000000-00f067aa0ba902b7-01", Expect: ErrTraceparentInvalid},
+		{Name: "zero span id", Value: "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", Expect: ErrTraceparentInvalid},
+		{Name: "uppercase", Value: "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", Expect: ErrTraceparentInvalid},
+		{Name: "short trace id", Value: "00-4bf92f3577b34da6-00f067aa0ba902b7-01", Expect: ErrTraceparentInvalid},
+		{Name: "not hex", Value: "00-4bf92f3577b34da6a3ce929d0e0e47zz-00f067aa0ba902b7-01", Expect: ErrTraceparentInvalid},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			sc, err := ParseTraceparent(tc.Value)
+			if !errors.Is(err, tc.Expect) {
+				t.Fatalf("expected %v, actual %v", tc.Expect, err)
+			}
+			if tc.Expect != nil {
+				return
+			}
+			if sc.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" || sc.SpanID.String() != "00f067aa0ba902b7" {
+				t.Errorf("expected the ids to be parsed, actual %s %s", sc.TraceID, sc.SpanID)
+			}
+			if sc.Sampled != tc.Sampled {
+				t.Errorf("expected sampled %v, actual %v", tc.Sampled, sc.Sampled)
+			}
+		})
+	}
+}
+
+func TestTraceparentRoundTrip(t *testing.T) {
+	_, span := (&Tracer{}).Start(context.Background(), "test", KindInternal)
+	sc, err := ParseTraceparent(span.Context.Traceparent())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sc != span.Context {
+		t.Errorf("expected %+v, actual %+v", span.Context, sc)
+	}
+}
+
+// recorder is an exporter that keeps the spans it exports.
+type recorder struct {
+	mu    sync.Mutex
+	spans []*Span
+}
+
+func (r *recorder) Export(s *Span) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spans = append(r.spans, s)
+	return nil
+}
+
+func TestTracerStart(t *testing.T) {
+	spans := new(recorder)
+	tracer := &Tracer{Exporters: []Exporter{spans}}
+
+	ctx, root := tracer.Start(context.Background(), "root", KindInternal)
+	_, child := tracer.Start(ctx, "child", KindClient)
+	child.Finish(errors.New("timeout"))
+	child.Finish(nil)
+	root.Finish(nil)
+
+	if len(spans.spans) != 2 {
+		t.Fatalf("expected each span to be exported once, actual %d", len(spans.spans))
+	}
+	if child.Context.TraceID != root.Context.TraceID || child.ParentID != root.Context.SpanID || !root.ParentID.IsZero() {
+		t.Errorf("expected the child to be in the root trace, actual %+v %+v", root.Context, child)
+	}
+	if child.Err == nil || child.Err.Error() != "timeout" {
+		t.Errorf("expected the first finish to be kept, actual %v", child.Err)
+	}
+
+	remote, _ := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	_, unsampled := tracer.Start(WithRemote(context.Background(), remote), "unsampled", KindServer)
+	unsampled.Finish(nil)
+	if unsampled.Context.TraceID != remote.TraceID || unsampled.ParentID != remote.SpanID {
+		t.Errorf("expected the span to be a child of the remote parent, actual %+v", unsampled)
+	}
+	if len(spans.spans) != 2 {
+		t.Errorf("expected spans of unsampled traces not to be exported")
+	}
+}
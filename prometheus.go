@@ -0,0 +1,314 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/wcharczuk/lametric/pkg/apiutil"
+	"github.com/wcharczuk/lametric/pkg/config"
+	"github.com/wcharczuk/lametric/pkg/lametric"
+	"github.com/wcharczuk/lametric/pkg/notify"
+	"github.com/wcharczuk/lametric/pkg/prometheus"
+	"github.com/wcharczuk/lametric/pkg/trace"
+)
+
+// promAlert is the template data for a query crossing an alert threshold.
+type promAlert struct {
+	Name  string
+	Value float64
+	// Text is the formatted value with its unit.
+	Text string
+	// Threshold is the threshold crossed, unless resolved.
+	Threshold float64
+	// Resolved is if the value is back within the thresholds.
+	Resolved bool
+}
+
+// promResult is the result of a configured query.
+type promResult struct {
+	query config.PrometheusQuery
+	// value is the instant value, or the last value of a range.
+	value  float64
+	points []lametric.Point
+	err    error
+}
+
+// newPrometheusClient returns the client for the configured prometheus api.
+func newPrometheusClient(cfg config.Prometheus) *prometheus.Client {
+	opts := []apiutil.Option{
+		apiutil.OptMiddleware(apiutil.UserAgent("notifier"), trace.Middleware),
+	}
+	if cfg.Token != "" {
+		opts = append(opts, apiutil.OptDefaults(apiutil.OptHeader("Authorization", "Bearer "+cfg.Token)))
+	}
+	return prometheus.New(cfg.URL, opts...)
+}
+
+// runPrometheus runs the configured queries on the interval until the context is
+// done, pushing their frames to the apps and sending threshold crossings.
+func runPrometheus(ctx context.Context, cfg config.Config) error {
+	client := newPrometheusClient(cfg.Prometheus)
+	// breached are the queries over a threshold, by name.
+	breached := make(map[string]bool)
+	ticker := time.NewTicker(cfg.Prometheus.IntervalOrDefault())
+	defer ticker.Stop()
+	for {
+		results := runQueries(ctx, client, cfg.Prometheus.Queries, time.Now())
+		pushQueryApps(ctx, cfg, results)
+		for _, result := range results {
+			if result.err != nil {
+				log.Printf("prometheus; %s; %v", result.query.Name, result.err)
+				continue
+			}
+			checkQueryAlert(ctx, cfg, result, breached)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// runQueries runs the given queries at a given time.
+func runQueries(ctx context.Context, client *prometheus.Client, queries []config.PrometheusQuery, now time.Time) []promResult {
+	output := make([]promResult, len(queries))
+	for index, q := range queries {
+		output[index] = runQuery(ctx, client, q, now)
+	}
+	return output
+}
+
+// runQuery runs an instant query, or a range query over the range before a given time.
+func runQuery(ctx context.Context, client *prometheus.Client, q config.PrometheusQuery, now time.Time) (result promResult) {
+	ctx, span := trace.Start(ctx, "prometheus.query", trace.KindInternal)
+	span.SetAttribute("query", q.Name)
+	defer func() { span.Finish(result.err) }()
+
+	result.query = q
+	if q.Range > 0 {
+		var matrix []prometheus.Series
+		if matrix, result.err = client.QueryRange(ctx, q.Query, now.Add(-q.Range), now, q.StepOrDefault()); result.err != nil {
+			return
+		}
+		var series prometheus.Series
+		if series, result.err = prometheus.OneSeries(matrix); result.err != nil {
+			return
+		}
+		// NaN and infinite values (e.g. from a division by zero) are left out of the chart.
+		for _, point := range series.Points {
+			if isFinite(point.Value) {
+				result.points = append(result.points, point)
+			}
+		}
+		if len(result.points) == 0 {
+			result.err = prometheus.ErrResultEmpty
+			return
+		}
+		result.value = result.points[len(result.points)-1].Value
+		return
+	}
+	var vector []prometheus.Sample
+	if vector, result.err = client.Query(ctx, q.Query, now); result.err != nil {
+		return
+	}
+	var sample prometheus.Sample
+	if sample, result.err = prometheus.One(vector); result.err != nil {
+		return
+	}
+	result.value = sample.Point.Value
+	return
+}
+
+// queryFrame returns the frame for a query result: a chart for range queries,
+// a gauge for queries with a goal, and otherwise the value; errors and values
+// that aren't finite are a dash.
+func queryFrame(result promResult) lametric.Frame {
+	q := result.query
+	switch {
+	case result.err != nil:
+		return lametric.Frame{Icon: q.Icon, Text: "-"}
+	case q.Range == 0 && !isFinite(result.value):
+		// a goal can't be NaN or infinite, nor encoded as json if it is.
+		return lametric.Frame{Icon: q.Icon, Text: "-"}
+	case q.Range > 0:
+		opts := lametric.ChartOptions{}
+		if q.Alert != nil && q.Alert.Above != nil {
+			opts.Threshold = q.Alert.Above
+		}
+		return lametric.ChartFrame(result.points, opts)
+	case q.Goal != nil:
+		return lametric.Frame{
+			Icon: q.Icon,
+			GoalData: &lametric.GoalData{
+				Start:   q.Goal.Start,
+				Current: result.value,
+				End:     q.Goal.End,
+				Unit:    q.Unit,
+			},
+		}
+	default:
+		return lametric.Frame{Icon: q.Icon, Text: formatQueryValue(q, result.value)}
+	}
+}
+
+// formatQueryValue returns a value in the query format with its unit, or a
+// dash if it's NaN or infinite.
+func formatQueryValue(q config.PrometheusQuery, value float64) string {
+	if !isFinite(value) {
+		return "-"
+	}
+	if q.Format != "" {
+		return fmt.Sprintf(q.Format, value) + q.Unit
+	}
+	// fewer decimals for larger values to fit the display.
+	abs := math.Abs(value)
+	switch {
+	case abs >= 100:
+		return fmt.Sprintf("%.0f%s", value, q.Unit)
+	case abs >= 10:
+		return fmt.Sprintf("%.1f%s", value, q.Unit)
+	default:
+		return fmt.Sprintf("%.2f%s", value, q.Unit)
+	}
+}
+
+// pushQueryApps pushes the frames of the query results to the configured apps.
+func pushQueryApps(ctx context.Context, cfg config.Config, results []promResult) {
+	byName := make(map[string]promResult, len(results))
+	for _, result := range results {
+		byName[result.query.Name] = result
+	}
+	for _, app := range cfg.Prometheus.Apps {
+		var frames []lametric.Frame
+		if len(app.Queries) == 0 {
+			for _, result := range results {
+				frames = append(frames, queryFrame(result))
+			}
+		} else {
+			for _, name := range app.Queries {
+				frames = append(frames, queryFrame(byName[name]))
+			}
+		}
+		// the device is checked when the config is validated.
+		device, _ := cfg.Device(app.Device)
+		if err := newClient(device).PushApp(ctx, app.App, frames); err != nil {
+			log.Printf("prometheus; %s; app; %v", device.Label(), err)
+		}
+	}
+}
+
+// checkQueryAlert sends a notification if a query result crossed an alert
+// threshold, or is back within them, since the last result.
+func checkQueryAlert(ctx context.Context, cfg config.Config, result promResult, breached map[string]bool) {
+	q := result.query
+	// a value that isn't finite is neither breached nor resolved.
+	if q.Alert == nil || !isFinite(result.value) {
+		return
+	}
+	threshold, isBreached := q.Alert.Breached(result.value)
+	if isBreached == breached[q.Name] {
+		return
+	}
+	breached[q.Name] = isBreached
+	alert := promAlert{
+		Name:      q.Name,
+		Value:     result.value,
+		Text:      formatQueryValue(q, result.value),
+		Threshold: threshold,
+		Resolved:  !isBreached,
+	}
+	log.Printf("prometheus; %s; %s (resolved: %v)", q.Name, alert.Text, alert.Resolved)
+	notification, err := queryAlertNotification(cfg, q, alert)
+	if err != nil {
+		log.Printf("prometheus; %s; %v", q.Name, err)
+		return
+	}
+	// names are checked when the config is validated.
+	devices, _ := cfg.SelectDevices(q.Alert.Devices)
+	for _, result := range broadcast(ctx, sourcePrometheus, devices, notification) {
+		if result.Err != nil {
+			log.Printf("prometheus; %s; %s; %v", q.Name, result.Device.Label(), result.Err)
+		}
+	}
+}
+
+// queryAlertNotification returns the notification for a threshold crossing,
+// rendered with the alert template if it has one.
+func queryAlertNotification(cfg config.Config, q config.PrometheusQuery, alert promAlert) (lametric.Notification, error) {
+	if q.Alert.Template != "" {
+		return notify.Render(cfg.Templates[q.Alert.Template], alert)
+	}
+	if alert.Resolved {
+		return lametric.Notification{
+			Priority: lametric.NotificationPriorityInfo,
+			Model: lametric.NotificationModel{
+				Frames: []lametric.Frame{{Icon: lametric.IconSmile, Text: fmt.Sprintf("%s back to %s", alert.Name, alert.Text)}},
+			},
+		}, nil
+	}
+	direction := "above"
+	if alert.Value < alert.Threshold {
+		direction = "below"
+	}
+	return lametric.Notification{
+		Priority: lametric.NotificationPriorityCritical,
+		Model: lametric.NotificationModel{
+			Frames: []lametric.Frame{{Icon: lametric.IconAttention, Text: fmt.Sprintf("%s %s %s %s", alert.Name, alert.Text, direction, formatQueryValue(q, alert.Threshold))}},
+		},
+	}, nil
+}
+
+// prometheusQueries runs the configured queries once and prints their values,
+// optionally pushing their frames to the apps.
+func prometheusQueries(cfg config.Config, args []string) error {
+	fs := flag.NewFlagSet("prometheus", flag.ContinueOnError)
+	push := fs.Bool("push", false, "Push the query frames to the configured apps")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if len(cfg.Prometheus.Queries) == 0 {
+		return fmt.Errorf("prometheus; no queries configured")
+	}
+	ctx := context.Background()
+	results := runQueries(ctx, newPrometheusClient(cfg.Prometheus), cfg.Prometheus.Queries, time.Now())
+	if *push {
+		pushQueryApps(ctx, cfg, results)
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tKIND\tVALUE\tALERT\tERROR")
+	for _, result := range results {
+		q := result.query
+		kind := "instant"
+		switch {
+		case q.Range > 0:
+			kind = fmt.Sprintf("range %v", q.Range)
+		case q.Goal != nil:
+			kind = "goal"
+		}
+		value, alert, errText := "-", "-", "-"
+		if result.err != nil {
+			errText = result.err.Error()
+		} else {
+			value = formatQueryValue(q, result.value)
+			if q.Range > 0 {
+				value += fmt.Sprintf(" (%d points)", len(result.points))
+			}
+			if q.Alert != nil && isFinite(result.value) {
+				alert = "ok"
+				if threshold, breached := q.Alert.Breached(result.value); breached {
+					alert = "breached " + strings.TrimSpace(formatQueryValue(q, threshold))
+				}
+			}
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", q.Name, kind, value, alert, errText)
+	}
+	return tw.Flush()
+}
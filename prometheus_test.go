@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/wcharczuk/lametric/pkg/config"
+	"github.com/wcharczuk/lametric/pkg/prometheus"
+)
+
+func TestQueryFrameNonFinite(t *testing.T) {
+	goal := config.PrometheusQuery{Name: "disk", Goal: &config.PrometheusGoal{Start: 0, End: 100}, Unit: "%"}
+	instant := config.PrometheusQuery{Name: "ratio", Format: "%.1f", Unit: "x"}
+	testCases := [...]struct {
+		Query    config.PrometheusQuery
+		Value    float64
+		Expected string
+	}{
+		{Query: goal, Value: 42, Expected: `{"goalData":{"current":42,"end":100,"unit":"%"}}`},
+		{Query: goal, Value: math.NaN(), Expected: `{"text":"-"}`},
+		{Query: goal, Value: math.Inf(1), Expected: `{"text":"-"}`},
+		{Query: instant, Value: 1.25, Expected: `{"text":"1.2x"}`},
+		{Query: instant, Value: math.NaN(), Expected: `{"text":"-"}`},
+		{Query: instant, Value: math.Inf(-1), Expected: `{"text":"-"}`},
+	}
+	for _, tc := range testCases {
+		contents, err := json.Marshal(queryFrame(promResult{query: tc.Query, value: tc.Value}))
+		if err != nil {
+			t.Errorf("%s %v; %v", tc.Query.Name, tc.Value, err)
+			continue
+		}
+		if string(contents) != tc.Expected {
+			t.Errorf("%s %v; expected %s, actual %s", tc.Query.Name, tc.Value, tc.Expected, contents)
+		}
+	}
+}
+
+func TestRunQueryRangeNonFinite(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(rw, `{"status":"success","data":{"resultType":"matrix","result":[{"metric":{},"values":[`+
+			`[1792411200,"1"],[1792411500,"NaN"],[1792411800,"2"],[1792412100,"+Inf"]]}]}}`)
+	}))
+	defer server.Close()
+
+	q := config.PrometheusQuery{Name: "errors", Query: "rate(errors_total[5m]) / rate(requests_total[5m])", Range: time.Hour}
+	result := runQuery(context.Background(), prometheus.New(server.URL), q, time.Date(2026, 10, 19, 13, 0, 0, 0, time.UTC))
+	if result.err != nil {
+		t.Fatal(result.err)
+	}
+	if len(result.points) != 2 || result.value != 2 {
+		t.Fatalf("expected the finite points and the last finite value, actual %+v and %v", result.points, result.value)
+	}
+	if _, err := json.Marshal(queryFrame(result)); err != nil {
+		t.Errorf("expected the chart to encode; %v", err)
+	}
+}
+
+func TestCheckQueryAlertNonFinite(t *testing.T) {
+	above := 0.5
+	q := config.PrometheusQuery{Name: "errors", Alert: &config.PrometheusAlert{Above: &above}}
+	breached := map[string]bool{"errors": true}
+	for _, value := range []float64{math.NaN(), math.Inf(1), math.Inf(-1)} {
+		checkQueryAlert(context.Background(), config.Config{}, promResult{query: q, value: value}, breached)
+		if !breached["errors"] {
+			t.Errorf("expected %v not to resolve the alert", value)
+		}
+	}
+	checkQueryAlert(context.Background(), config.Config{}, promResult{query: q, value: 0.1}, breached)
+	if breached["errors"] {
+		t.Error("expected a finite value within the threshold to resolve the alert")
+	}
+}
@@ -0,0 +1,330 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/wcharczuk/lametric/pkg/apiutil"
+	"github.com/wcharczuk/lametric/pkg/config"
+	"github.com/wcharczuk/lametric/pkg/lametric"
+	"github.com/wcharczuk/lametric/pkg/remind"
+	"github.com/wcharczuk/lametric/pkg/trace"
+)
+
+// reminderSet is a set of reminders, either the running server's or the state file.
+type reminderSet interface {
+	List() ([]remind.Reminder, error)
+	Add(remind.Reminder) (remind.Reminder, error)
+	Cancel(id string) (remind.Reminder, error)
+}
+
+// reminders runs the `remind` subcommands; without one it adds a reminder.
+//
+// Reminders are registered with the running server, or saved in the state file
+// if it isn't reachable (or with `-local`), which the server re-reads while running.
+func reminders(cfg config.Config, args []string) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "list":
+			return remindList(cfg, args[1:])
+		case "cancel":
+			return remindCancel(cfg, args[1:])
+		}
+	}
+	return remindAdd(cfg, args)
+}
+
+// remindAdd adds a reminder.
+func remindAdd(cfg config.Config, args []string) error {
+	fs := flag.NewFlagSet("remind", flag.ContinueOnError)
+	in := fs.String("in", "", "The delay before the reminder, e.g. `25m`, `2h` or `1d`")
+	at := fs.String("at", "", "The local time of the reminder, e.g. `2026-11-01T09:00` or `09:00` (the next one)")
+	every := fs.String("every", "", "How often the reminder repeats, e.g. `1d` or `1w`")
+	icon := lametric.IconClock
+	fs.Var(textFlag{&icon}, "icon", "The frame icon, an icon id or catalog name")
+	var devices stringsFlag
+	fs.Var(&devices, "device", "The name (or address) of a device to send to; defaults to all of them (repeatable)")
+	var sound lametric.SoundID
+	fs.Var(textFlag{&sound}, "sound", "The sound to play")
+	priority := lametric.NotificationPriorityWarning
+	fs.Var(textFlag{&priority}, "priority", fmt.Sprintf("The priority, one of %v", lametric.AllNotificationPriorities()))
+	server, local := remindFlags(cfg, fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	text := strings.Join(fs.Args(), " ")
+	if text == "" || (*in == "") == (*at == "") {
+		return fmt.Errorf("usage: remind (--in <duration> | --at <time>) [--every <duration>] [--device name] <text>")
+	}
+	if _, err := cfg.SelectDevices(devices); err != nil {
+		return err
+	}
+
+	r := remind.Reminder{
+		Devices: devices,
+		Notification: lametric.Notification{
+			Priority: priority,
+			Model: lametric.NotificationModel{
+				Frames: []lametric.Frame{{Icon: icon, Text: text}},
+			},
+		},
+	}
+	if sound != "" {
+		r.Notification.Model.Sound = &lametric.Sound{Category: sound.Category(), ID: sound, Repeat: 1}
+	}
+	var err error
+	if r.At, err = parseReminderTime(*in, *at, time.Now()); err != nil {
+		return err
+	}
+	if *every != "" {
+		parsed, err := remind.ParseDuration(*every)
+		if err != nil {
+			return err
+		}
+		r.Every = remind.Duration(parsed)
+	}
+	return withReminders(cfg, *server, *local, func(rs reminderSet) error {
+		added, err := rs.Add(r)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("reminder %s at %s\n", added.ID, added.At.Local().Format("2006-01-02 15:04 MST"))
+		return nil
+	})
+}
+
+// remindList prints the reminders.
+func remindList(cfg config.Config, args []string) error {
+	fs := flag.NewFlagSet("remind list", flag.ContinueOnError)
+	server, local := remindFlags(cfg, fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	return withReminders(cfg, *server, *local, func(rs reminderSet) error {
+		list, err := rs.List()
+		if err != nil {
+			return err
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tAT\tEVERY\tDEVICES\tTEXT")
+		for _, r := range list {
+			devices := "all"
+			if len(r.Devices) > 0 {
+				devices = strings.Join(r.Devices, ",")
+			}
+			every := "-"
+			if r.Every > 0 {
+				every = r.Every.String()
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", r.ID, r.At.Local().Format("2006-01-02 15:04 MST"), every, devices, r.Text())
+		}
+		return tw.Flush()
+	})
+}
+
+// remindCancel removes reminders by id.
+func remindCancel(cfg config.Config, args []string) error {
+	fs := flag.NewFlagSet("remind cancel", flag.ContinueOnError)
+	server, local := remindFlags(cfg, fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return fmt.Errorf("usage: remind cancel <id> [id...]")
+	}
+	return withReminders(cfg, *server, *local, func(rs reminderSet) error {
+		for _, id := range fs.Args() {
+			r, err := rs.Cancel(id)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("cancelled %s %q\n", r.ID, r.Text())
+		}
+		return nil
+	})
+}
+
+// remindFlags adds the flags for where reminders are registered.
+func remindFlags(cfg config.Config, fs *flag.FlagSet) (server *string, local *bool) {
+	server = fs.String("server", serverURL(cfg.Server.AddrOrDefault()), "The url of the running server")
+	local = fs.Bool("local", false, "Use the state file rather than the running server")
+	return
+}
+
+// withReminders calls a given function with the server reminders, or the
+// state file reminders if the server isn't reachable.
+func withReminders(cfg config.Config, server string, local bool, fn func(reminderSet) error) error {
+	store := remind.NewStore(cfg.ReminderStateOrDefault())
+	if local {
+		return fn(store)
+	}
+	err := fn(remindAPI{client: apiutil.New(server, apiutil.OptResponseFilter(nil)), token: cfg.Server.Token})
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		log.Printf("remind; server not reachable at %s, using %s", server, store.Path)
+		return fn(store)
+	}
+	return err
+}
+
+// serverURL returns the url of the server from its listen address.
+func serverURL(addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "http://" + addr
+	}
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "localhost"
+	}
+	return "http://" + net.JoinHostPort(host, port)
+}
+
+// parseReminderTime returns the time of a reminder from a delay or a local time.
+func parseReminderTime(in, at string, now time.Time) (time.Time, error) {
+	if in != "" {
+		delay, err := remind.ParseDuration(in)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return now.Add(delay), nil
+	}
+	if t, err := time.Parse(time.RFC3339, at); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02 15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, at, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	for _, layout := range []string{"15:04:05", "15:04"} {
+		if t, err := time.ParseInLocation(layout, at, time.Local); err == nil {
+			// the next occurrence of the time of day.
+			local := now.Local()
+			next := time.Date(local.Year(), local.Month(), local.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.Local)
+			if !next.After(now) {
+				next = next.AddDate(0, 0, 1)
+			}
+			return next, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("remind; invalid time %q", at)
+}
+
+// remindAPI is the reminders of the running server.
+type remindAPI struct {
+	client apiutil.Client
+	token  string
+}
+
+// List implements reminderSet.
+func (ra remindAPI) List() (output []remind.Reminder, err error) {
+	err = ra.do(&output, apiutil.OptPath("/reminders"))
+	return
+}
+
+// Add implements reminderSet.
+func (ra remindAPI) Add(r remind.Reminder) (output remind.Reminder, err error) {
+	err = ra.do(&output, apiutil.OptMethod(http.MethodPost), apiutil.OptPath("/reminders"), apiutil.OptJSONBody(r))
+	return
+}
+
+// Cancel implements reminderSet.
+func (ra remindAPI) Cancel(id string) (output remind.Reminder, err error) {
+	err = ra.do(&output, apiutil.OptMethod(http.MethodDelete), apiutil.OptPathf("/reminders/%s", id))
+	return
+}
+
+func (ra remindAPI) do(output interface{}, opts ...apiutil.RequestOption) error {
+	if ra.token != "" {
+		opts = append(opts, apiutil.OptHeader("Authorization", "Bearer "+ra.token))
+	}
+	res, contents, err := ra.client.Bytes(context.Background(), opts...)
+	if err != nil {
+		return err
+	}
+	if res.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("remind; server; %s; %s", res.Status, strings.TrimSpace(string(contents)))
+	}
+	return json.Unmarshal(contents, output)
+}
+
+// remindHandler returns the handler for `/reminders`: `GET` lists the reminders,
+// `POST` adds one and `DELETE /reminders/<id>` cancels one.
+func remindHandler(cfg config.Config, store *remind.Store) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if !authorized(cfg, req) {
+			http.Error(rw, "invalid or missing bearer token", http.StatusUnauthorized)
+			return
+		}
+		id := strings.Trim(strings.TrimPrefix(req.URL.Path, "/reminders"), "/")
+		var output interface{}
+		var err error
+		statusCode := http.StatusOK
+		switch {
+		case id == "" && req.Method == http.MethodGet:
+			output, err = store.List()
+		case id == "" && req.Method == http.MethodPost:
+			var r remind.Reminder
+			if err = json.NewDecoder(io.LimitReader(req.Body, maxWebhookBody)).Decode(&r); err != nil {
+				http.Error(rw, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if _, err = cfg.SelectDevices(r.Devices); err != nil {
+				http.Error(rw, err.Error(), http.StatusBadRequest)
+				return
+			}
+			output, err = store.Add(r)
+			statusCode = http.StatusCreated
+		case id != "" && req.Method == http.MethodDelete:
+			output, err = store.Cancel(id)
+		default:
+			http.Error(rw, fmt.Sprintf("method %s not allowed", req.Method), http.StatusMethodNotAllowed)
+			return
+		}
+		switch {
+		case errors.Is(err, remind.ErrReminderNotFound):
+			http.Error(rw, err.Error(), http.StatusNotFound)
+			return
+		case errors.Is(err, remind.ErrReminderInvalid):
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		case err != nil:
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		rw.WriteHeader(statusCode)
+		_ = json.NewEncoder(rw).Encode(output)
+	})
+}
+
+// sendReminder sends a reminder to its devices.
+func sendReminder(ctx context.Context, cfg config.Config, r remind.Reminder) (err error) {
+	ctx, span := trace.Start(ctx, "remind.send", trace.KindInternal)
+	span.SetAttribute("reminder", r.ID)
+	defer func() { span.Finish(err) }()
+
+	devices, err := cfg.SelectDevices(r.Devices)
+	if err != nil {
+		return err
+	}
+	log.Printf("remind; %s; sending %q", r.ID, r.Text())
+	results := broadcast(ctx, sourceReminder, devices, r.Notification)
+	for _, result := range results {
+		if result.Err != nil {
+			log.Printf("remind; %s; %s; %v", r.ID, result.Device.Label(), result.Err)
+		}
+	}
+	return results.Err()
+}
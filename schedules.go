@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/wcharczuk/lametric/pkg/config"
+	"github.com/wcharczuk/lametric/pkg/lametric"
+	"github.com/wcharczuk/lametric/pkg/notify"
+	"github.com/wcharczuk/lametric/pkg/schedule"
+	"github.com/wcharczuk/lametric/pkg/trace"
+)
+
+// scheduleRun is the template data for a scheduled notification.
+type scheduleRun struct {
+	Name string
+	// Time is when the run was scheduled for, in the schedule time zone.
+	Time time.Time
+}
+
+// newScheduler returns a scheduler that sends the configured schedules.
+func newScheduler(cfg config.Config) (*schedule.Scheduler, error) {
+	store, err := schedule.NewFileStore(cfg.ScheduleStateOrDefault())
+	if err != nil {
+		return nil, fmt.Errorf("schedule state; %w", err)
+	}
+	scheduler := &schedule.Scheduler{
+		Store: store,
+		OnError: func(job string, err error) {
+			log.Printf("schedule; %s; %v", job, err)
+		},
+	}
+	for _, s := range cfg.Schedules {
+		s := s
+		cron, err := s.ParseCron()
+		if err != nil {
+			return nil, fmt.Errorf("schedule %s; %w", s.Name, err)
+		}
+		scheduler.Jobs = append(scheduler.Jobs, schedule.Job{
+			Name:         s.Name,
+			Cron:         cron,
+			Jitter:       s.Jitter,
+			Misfire:      s.Misfire,
+			MisfireGrace: s.MisfireGraceOrDefault(),
+			Run: func(ctx context.Context, scheduled time.Time) error {
+				return runSchedule(ctx, cfg, s, scheduled.In(cron.Location()))
+			},
+		})
+	}
+	return scheduler, nil
+}
+
+// runSchedule sends a schedule's notification to its devices.
+func runSchedule(ctx context.Context, cfg config.Config, s config.Schedule, scheduled time.Time) (err error) {
+	ctx, span := trace.Start(ctx, "schedule.run", trace.KindInternal)
+	span.SetAttribute("schedule", s.Name)
+	span.SetAttribute("scheduled", scheduled.Format(time.RFC3339))
+	defer func() { span.Finish(err) }()
+
+	notification, err := scheduleNotification(cfg, s, scheduled)
+	if err != nil {
+		return err
+	}
+	// names are checked when the config is validated.
+	devices, _ := cfg.SelectDevices(s.Devices)
+	log.Printf("schedule; %s; sending run scheduled for %s", s.Name, scheduled.Format(time.RFC3339))
+	results := broadcast(ctx, sourceSchedule, devices, notification)
+	for _, result := range results {
+		if result.Err != nil {
+			log.Printf("schedule; %s; %s; %v", s.Name, result.Device.Label(), result.Err)
+		}
+	}
+	return results.Err()
+}
+
+// scheduleNotification returns the rendered template or inline notification for a schedule.
+func scheduleNotification(cfg config.Config, s config.Schedule, scheduled time.Time) (lametric.Notification, error) {
+	notification := cfg.Templates[s.Template]
+	if s.Notification != nil {
+		notification = *s.Notification
+	}
+	return notify.Render(notification, scheduleRun{Name: s.Name, Time: scheduled})
+}
+
+// schedules prints the configured schedules and when each next runs.
+func schedules(cfg config.Config, args []string) error {
+	fs := flag.NewFlagSet("schedules", flag.ContinueOnError)
+	count := fs.Int("next", 1, "The number of upcoming runs to print per schedule")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	store, err := schedule.NewFileStore(cfg.ScheduleStateOrDefault())
+	if err != nil {
+		return fmt.Errorf("schedule state; %w", err)
+	}
+	now := time.Now()
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tCRON\tTIME ZONE\tDEVICES\tLAST RUN\tNEXT RUN")
+	for _, s := range cfg.Schedules {
+		cron, err := s.ParseCron()
+		if err != nil {
+			return fmt.Errorf("schedule %s; %w", s.Name, err)
+		}
+		lastRun := "-"
+		if t, ok := store.LastRun(s.Name); ok {
+			lastRun = t.In(cron.Location()).Format(time.RFC3339)
+		}
+		var next []string
+		for t, x := cron.Next(now), 0; !t.IsZero() && x < *count; t, x = cron.Next(t), x+1 {
+			next = append(next, t.Format(time.RFC3339))
+		}
+		devices := "all"
+		if len(s.Devices) > 0 {
+			devices = strings.Join(s.Devices, ",")
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", s.Name, s.Cron, cron.Location(), devices, lastRun, orDash(strings.Join(next, " ")))
+	}
+	return tw.Flush()
+}
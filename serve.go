@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/wcharczuk/lametric/pkg/config"
+	"github.com/wcharczuk/lametric/pkg/remind"
+)
+
+// serve runs the long running services until interrupted: the http server
+// with `/metrics`, the `/notify` webhook and the `/reminders` api (which
+// require a token unless the server only listens on loopback), the device
+// health checker, the scheduled notifications and reminders, the device
+// calendars, the feeds, the monitors, the prometheus queries, and the mqtt
+// bridge if it's configured.
+func serve(cfg config.Config, args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := fs.String("addr", cfg.Server.AddrOrDefault(), "The address to listen on")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	events := newEventQueue()
+	checker := newHealthChecker(cfg, events)
+	scheduler, err := newScheduler(cfg)
+	if err != nil {
+		return err
+	}
+	reminderStore := remind.NewStore(cfg.ReminderStateOrDefault())
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", registry)
+	if cfg.Server.APIAllowed(*addr) {
+		mux.Handle("/notify", webhookHandler(cfg))
+		mux.Handle("/reminders", remindHandler(cfg, reminderStore))
+		mux.Handle("/reminders/", remindHandler(cfg, reminderStore))
+	} else {
+		log.Printf("serve; /notify and /reminders disabled; set server.token to accept them on %s", *addr)
+	}
+	mux.HandleFunc("/healthz", func(rw http.ResponseWriter, _ *http.Request) {
+		fmt.Fprintln(rw, "ok")
+	})
+	server := &http.Server{Addr: *addr, Handler: mux}
+
+	errs := make(chan error, 10)
+	go func() {
+		if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			errs <- err
+		}
+	}()
+	go func() { errs <- events.Run(ctx) }()
+	go func() { errs <- checker.Run(ctx) }()
+	if len(scheduler.Jobs) > 0 {
+		go func() { errs <- scheduler.Run(ctx) }()
+	}
+	go func() {
+		errs <- reminderStore.Run(ctx, func(ctx context.Context, r remind.Reminder) error {
+			return sendReminder(ctx, cfg, r)
+		}, func(r remind.Reminder, err error) {
+			log.Printf("remind; %s; %v", r.ID, err)
+		})
+	}()
+	if devices := calendarDevices(cfg); len(devices) > 0 {
+		go func() { errs <- runCalendars(ctx, devices) }()
+	}
+	if len(cfg.Feeds) > 0 {
+		go func() { errs <- runFeeds(ctx, cfg) }()
+	}
+	if len(cfg.Monitors) > 0 {
+		go func() { errs <- newMonitor(cfg, events).Run(ctx) }()
+	}
+	if len(cfg.Prometheus.Queries) > 0 {
+		go func() { errs <- runPrometheus(ctx, cfg) }()
+	}
+	if cfg.MQTT.Broker != "" {
+		go func() { errs <- runMQTTBridge(ctx, cfg) }()
+	}
+	log.Printf("serve; listening on %s", *addr)
+
+	select {
+	case <-ctx.Done():
+	case err = <-errs:
+	}
+	stop()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if shutdownErr := server.Shutdown(shutdownCtx); err == nil {
+		err = shutdownErr
+	}
+	return err
+}
+
+// eventQueue runs functions in order on its own goroutine, so that sending the
+// notifications for health and monitor events doesn't hold up the checks.
+type eventQueue chan func()
+
+// newEventQueue returns a new event queue; call `Run` to run the queued functions.
+func newEventQueue() eventQueue {
+	return make(eventQueue, 64)
+}
+
+// Run runs the queued functions until the context is done.
+func (eq eventQueue) Run(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case f := <-eq:
+			f()
+		}
+	}
+}
+
+// Push queues a function, waiting for room in the queue until the context is done.
+func (eq eventQueue) Push(ctx context.Context, f func()) {
+	select {
+	case eq <- f:
+	case <-ctx.Done():
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEventQueue(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := newEventQueue()
+	done := make(chan error, 1)
+	go func() { done <- events.Run(ctx) }()
+
+	ran := make(chan int, 3)
+	for index := 0; index < 3; index++ {
+		index := index
+		events.Push(ctx, func() {
+			// a slow event doesn't let later events run first.
+			if index == 0 {
+				time.Sleep(20 * time.Millisecond)
+			}
+			ran <- index
+		})
+	}
+	var order []int
+	for len(order) < 3 {
+		order = append(order, <-ran)
+	}
+	if expect := []int{0, 1, 2}; !reflect.DeepEqual(order, expect) {
+		t.Errorf("expected %v, got %v", expect, order)
+	}
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("expected %v, got %v", context.Canceled, err)
+	}
+	// pushing after the queue stopped doesn't block once the context is done.
+	for index := 0; index < cap(events)+1; index++ {
+		events.Push(ctx, func() {})
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/wcharczuk/lametric/pkg/config"
+	"github.com/wcharczuk/lametric/pkg/lametric"
+)
+
+// sounds runs the `sounds` subcommands.
+func sounds(cfg config.Config, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: sounds <list|preview> [args]")
+	}
+	switch args[0] {
+	case "list":
+		return soundsList(args[1:])
+	case "preview":
+		return soundsPreview(cfg, args[1:])
+	default:
+		return fmt.Errorf("sounds; invalid subcommand %q", args[0])
+	}
+}
+
+// soundsList prints the sound ids and their categories.
+func soundsList(args []string) error {
+	fs := flag.NewFlagSet("sounds list", flag.ContinueOnError)
+	var category lametric.SoundCategory
+	fs.Var(textFlag{&category}, "category", fmt.Sprintf("Only list sounds from a category, one of %v", lametric.AllSoundCategories()))
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "CATEGORY\tID")
+	for _, id := range soundIDs(category) {
+		fmt.Fprintf(tw, "%s\t%s\n", id.Category(), id)
+	}
+	return tw.Flush()
+}
+
+// soundsPreview plays each sound on a device, showing the sound id as the frame text.
+func soundsPreview(cfg config.Config, args []string) error {
+	fs := flag.NewFlagSet("sounds preview", flag.ContinueOnError)
+	deviceName := fs.String("device", "", "The name (or address) of the device to play the sounds on")
+	delay := fs.Duration("delay", 5*time.Second, "The delay between sounds")
+	interactive := fs.Bool("interactive", false, "Wait for enter between sounds (q to stop)")
+	var category lametric.SoundCategory
+	fs.Var(textFlag{&category}, "category", fmt.Sprintf("Only preview sounds from a category, one of %v", lametric.AllSoundCategories()))
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *deviceName == "" {
+		return fmt.Errorf("usage: sounds preview --device <name> [--category c] [--delay d] [--interactive]")
+	}
+	device, ok := cfg.Device(*deviceName)
+	if !ok {
+		return fmt.Errorf("device %q not found", *deviceName)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	stdin := bufio.NewReader(os.Stdin)
+	ids := soundIDs(category)
+	for index, id := range ids {
+		log.Printf("playing %s (%d/%d)", id, index+1, len(ids))
+		if err := deliver(ctx, sourceSounds, device, previewNotification(id)); err != nil {
+			return err
+		}
+		if index == len(ids)-1 {
+			break
+		}
+		if *interactive {
+			fmt.Fprint(os.Stderr, "enter for the next sound, q to stop: ")
+			line, err := stdin.ReadString('\n')
+			if err != nil || strings.TrimSpace(strings.ToLower(line)) == "q" {
+				return nil
+			}
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(*delay):
+		}
+	}
+	return nil
+}
+
+func soundIDs(category lametric.SoundCategory) []lametric.SoundID {
+	if category != "" {
+		return lametric.SoundIDs(category)
+	}
+	return lametric.AllSoundIDs()
+}
+
+func previewNotification(id lametric.SoundID) lametric.Notification {
+	return lametric.Notification{
+		Priority: lametric.NotificationPriorityWarning,
+		Model: lametric.NotificationModel{
+			Frames: []lametric.Frame{
+				{Text: string(id)},
+			},
+			Sound: &lametric.Sound{
+				Category: id.Category(),
+				ID:       id,
+				Repeat:   1,
+			},
+			Cycles: 1,
+		},
+	}
+}
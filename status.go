@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/wcharczuk/lametric/pkg/config"
+	"github.com/wcharczuk/lametric/pkg/health"
+)
+
+// ANSI colors.
+const (
+	colorRed    = "\033[31m"
+	colorGreen  = "\033[32m"
+	colorYellow = "\033[33m"
+	colorNone   = "\033[39m"
+	colorReset  = "\033[0m"
+	clearScreen = "\033[H\033[2J"
+)
+
+// status prints the health of each lametric device, refreshing it with `-watch`.
+func status(cfg config.Config, args []string) error {
+	fs := flag.NewFlagSet("status", flag.ContinueOnError)
+	watch := fs.Bool("watch", false, "Refresh the status until interrupted")
+	interval := fs.Duration("interval", 5*time.Second, "The refresh interval with -watch")
+	noColor := fs.Bool("no-color", os.Getenv("NO_COLOR") != "" || !isTerminal(os.Stdout), "Disable colors")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	checker := newHealthChecker(cfg, nil)
+	// events are routed by `serve`, not while looking at the status
+	checker.OnEvent = nil
+	for {
+		statuses := checker.Check(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if *watch {
+			fmt.Fprint(os.Stdout, clearScreen)
+		}
+		if err := printStatuses(os.Stdout, statuses, !*noColor); err != nil {
+			return err
+		}
+		if !*watch {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(*interval):
+		}
+	}
+}
+
+// printStatuses writes a table of device statuses.
+func printStatuses(w io.Writer, statuses []health.Status, color bool) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	// the header is wrapped in (default) color codes too so the columns line up
+	fmt.Fprintf(tw, "DEVICE\t%s\tMODEL\tFIRMWARE\tQUEUE\tBRIGHTNESS\tVOLUME\tLAST SEEN\tERROR\n", colorize("STATUS", colorNone, color))
+	for _, s := range statuses {
+		state, stateColor := "up", colorGreen
+		switch {
+		case !s.Reachable:
+			state, stateColor = "down", colorRed
+		case !s.Authorized:
+			state, stateColor = "unauthorized", colorYellow
+		}
+		state = colorize(state, stateColor, color)
+		lastSeen := "never"
+		if !s.LastSeen.IsZero() {
+			lastSeen = s.LastSeen.Local().Format(time.Stamp)
+		}
+		var errText string
+		if s.Err != nil {
+			errText = s.Err.Error()
+		}
+		brightness, volume, queue := "-", "-", "-"
+		if s.Up() {
+			brightness = fmt.Sprintf("%d%% (%s)", s.Brightness, s.BrightnessMode)
+			volume = fmt.Sprintf("%d%%", s.Volume)
+			queue = fmt.Sprint(s.QueueDepth)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			s.Name, state, orDash(s.Model), orDash(s.Firmware), queue, brightness, volume, lastSeen, errText)
+	}
+	return tw.Flush()
+}
+
+// colorize wraps text in a color if colors are enabled.
+func colorize(text, color string, enabled bool) string {
+	if !enabled {
+		return text
+	}
+	return color + text + colorReset
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+// isTerminal returns if a file is a terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
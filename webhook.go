@@ -0,0 +1,170 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wcharczuk/lametric/pkg/config"
+	"github.com/wcharczuk/lametric/pkg/lametric"
+	"github.com/wcharczuk/lametric/pkg/notify"
+	"github.com/wcharczuk/lametric/pkg/trace"
+)
+
+// maxWebhookBody is the largest webhook body that's read.
+const maxWebhookBody = 1 << 20
+
+// webhookMessage is the template data for a webhook.
+type webhookMessage struct {
+	Payload string
+	JSON    interface{}
+	Query   url.Values
+}
+
+// webhookResult is the delivery result for a device in a webhook response.
+type webhookResult struct {
+	Device string `json:"device"`
+	OK     bool   `json:"ok"`
+	Error  string `json:"error,omitempty"`
+	// RetryAt is set if the notification was deferred because the device breaker was open.
+	RetryAt *time.Time `json:"retryAt,omitempty"`
+}
+
+// authorized returns if a request has the server bearer token, if one is configured.
+func authorized(cfg config.Config, req *http.Request) bool {
+	return cfg.Server.Token == "" || subtle.ConstantTimeCompare([]byte(req.Header.Get("Authorization")), []byte("Bearer "+cfg.Server.Token)) == 1
+}
+
+// webhookHandler returns the handler for `POST /notify`, which broadcasts a notification.
+//
+// With a `template` query parameter the body is template data, otherwise it's a
+// notification as json; `device` query parameters select the devices, otherwise
+// all the devices are sent to. The trace is continued from a `traceparent` header.
+//
+// The spans cover receipt, rendering, routing and each delivery down to the
+// device api request. Notifications are sent as they're received; there's no
+// dedupe or outbox stage to trace.
+func webhookHandler(cfg config.Config) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		req = trace.Extract(req)
+		ctx, span := trace.Start(req.Context(), "webhook.receive", trace.KindServer)
+		rw.Header().Set(trace.HeaderTraceparent, span.Context.Traceparent())
+		fail := func(statusCode int, err error) {
+			span.SetAttribute("http.status_code", statusCode)
+			span.Finish(err)
+			http.Error(rw, err.Error(), statusCode)
+		}
+
+		if req.Method != http.MethodPost {
+			fail(http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", req.Method))
+			return
+		}
+		if !authorized(cfg, req) {
+			fail(http.StatusUnauthorized, fmt.Errorf("invalid or missing bearer token"))
+			return
+		}
+		body, err := io.ReadAll(io.LimitReader(req.Body, maxWebhookBody))
+		if err != nil {
+			fail(http.StatusBadRequest, err)
+			return
+		}
+
+		query := req.URL.Query()
+		_, renderSpan := trace.Start(ctx, "webhook.render", trace.KindInternal)
+		notification, err := webhookNotification(cfg, query, body)
+		renderSpan.SetAttribute("template", query.Get("template"))
+		renderSpan.Finish(err)
+		if err != nil {
+			fail(http.StatusBadRequest, err)
+			return
+		}
+
+		_, routeSpan := trace.Start(ctx, "webhook.route", trace.KindInternal)
+		devices, err := cfg.SelectDevices(query["device"])
+		routeSpan.SetAttribute("devices", len(devices))
+		routeSpan.Finish(err)
+		if err != nil {
+			fail(http.StatusNotFound, err)
+			return
+		}
+
+		results := broadcast(ctx, sourceWebhook, devices, notification)
+		output := make([]webhookResult, len(results))
+		statusCode := http.StatusBadGateway
+		for index, result := range results {
+			output[index] = webhookResult{Device: result.Device.Label(), OK: result.Err == nil}
+			if result.Err != nil {
+				output[index].Error = result.Err.Error()
+			} else {
+				statusCode = http.StatusOK
+			}
+			if result.Deferred() {
+				retryAt := result.RetryAt.UTC()
+				output[index].RetryAt = &retryAt
+			}
+		}
+		if len(results) == 0 {
+			statusCode = http.StatusOK
+		}
+		// if every device was deferred the sender can retry once a breaker lets requests through.
+		if deferred := results.Deferred(); len(results) > 0 && len(deferred) == len(results) {
+			statusCode = http.StatusServiceUnavailable
+			rw.Header().Set("Retry-After", strconv.Itoa(retryAfter(deferred)))
+		}
+		span.SetAttribute("http.status_code", statusCode)
+		span.Finish(results.Err())
+		rw.Header().Set("Content-Type", "application/json")
+		rw.WriteHeader(statusCode)
+		_ = json.NewEncoder(rw).Encode(output)
+	})
+}
+
+// retryAfter returns the seconds until the first of the deferred deliveries can be retried.
+func retryAfter(deferred deliveries) int {
+	first := deferred[0].RetryAt
+	for _, result := range deferred[1:] {
+		if result.RetryAt.Before(first) {
+			first = result.RetryAt
+		}
+	}
+	seconds := int(math.Ceil(time.Until(first).Seconds()))
+	if seconds < 1 {
+		return 1
+	}
+	return seconds
+}
+
+// webhookNotification returns the notification for a webhook body.
+func webhookNotification(cfg config.Config, query url.Values, body []byte) (lametric.Notification, error) {
+	name := query.Get("template")
+	if name == "" {
+		var notification lametric.Notification
+		if err := json.Unmarshal(body, &notification); err != nil {
+			return notification, fmt.Errorf("invalid notification; %w", err)
+		}
+		if sound := notification.Model.Sound; sound != nil {
+			if err := sound.Validate(); err != nil {
+				return notification, fmt.Errorf("invalid notification; %w", err)
+			}
+		}
+		return notification, nil
+	}
+	template, ok := cfg.Templates[name]
+	if !ok {
+		return template, fmt.Errorf("template %q not found", name)
+	}
+	data := webhookMessage{
+		Payload: strings.TrimSpace(string(body)),
+		Query:   query,
+	}
+	// the body is decoded if it's json, otherwise `.JSON` is left empty.
+	_ = json.Unmarshal(body, &data.JSON)
+	return notify.Render(template, data)
+}
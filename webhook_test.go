@@ -0,0 +1,41 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/wcharczuk/lametric/pkg/config"
+	"github.com/wcharczuk/lametric/pkg/trace"
+)
+
+func TestWebhookHandlerAuthorization(t *testing.T) {
+	cfg := config.Config{Server: config.Server{Token: "secret"}}
+	handler := webhookHandler(cfg)
+	testCases := []struct {
+		Method        string
+		Authorization string
+		Expect        int
+	}{
+		{Method: http.MethodGet, Authorization: "Bearer secret", Expect: http.StatusMethodNotAllowed},
+		{Method: http.MethodPost, Expect: http.StatusUnauthorized},
+		{Method: http.MethodPost, Authorization: "Bearer wrong", Expect: http.StatusUnauthorized},
+		// no devices are configured, so there's nothing to send.
+		{Method: http.MethodPost, Authorization: "Bearer secret", Expect: http.StatusOK},
+	}
+	for _, tc := range testCases {
+		req := httptest.NewRequest(tc.Method, "/notify", strings.NewReader(`{"model":{"frames":[{"text":"hello"}]}}`))
+		if tc.Authorization != "" {
+			req.Header.Set("Authorization", tc.Authorization)
+		}
+		rw := httptest.NewRecorder()
+		handler.ServeHTTP(rw, req)
+		if rw.Code != tc.Expect {
+			t.Errorf("%s with %q; expected %d, got %d: %s", tc.Method, tc.Authorization, tc.Expect, rw.Code, rw.Body.String())
+		}
+		if rw.Header().Get(trace.HeaderTraceparent) == "" {
+			t.Errorf("%s with %q; expected a traceparent header", tc.Method, tc.Authorization)
+		}
+	}
+}